@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -15,10 +14,13 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/document/editor"
 	"github.com/stateful/runme/internal/document/identity"
+	"github.com/stateful/runme/internal/executable"
 	runnerv1 "github.com/stateful/runme/internal/gen/proto/go/runme/runner/v1"
 	"github.com/stateful/runme/internal/project"
 	"github.com/stateful/runme/internal/runner/client"
+	"github.com/stateful/runme/internal/sandbox"
 	"github.com/stateful/runme/internal/tui"
 	"github.com/stateful/runme/internal/tui/prompt"
 	"golang.org/x/exp/slices"
@@ -30,9 +32,19 @@ func getIdentityResolver() *identity.IdentityResolver {
 	return identity.NewResolver(identity.DefaultLifecycleIdentity)
 }
 
+func getNamingStrategy() (document.NamingStrategy, error) {
+	return document.ParseNamingStrategy(fNaming)
+}
+
 func getProject() (*project.Project, error) {
+	namingStrategy, err := getNamingStrategy()
+	if err != nil {
+		return nil, err
+	}
+
 	opts := []project.ProjectOption{
 		project.WithIdentityResolver(getIdentityResolver()),
+		project.WithNamingStrategy(namingStrategy),
 	}
 
 	logger, err := getLogger(false)
@@ -86,6 +98,11 @@ func getProject() (*project.Project, error) {
 		}
 	}
 
+	// Languages declared in the project override the user's ones.
+	if err := languages.Load(executable.ProjectLanguagesPath(proj.Root())); err != nil {
+		return nil, err
+	}
+
 	return proj, nil
 }
 
@@ -132,19 +149,43 @@ func getAllProjectTasks(cmd *cobra.Command) ([]project.Task, error) {
 }
 
 func getCodeBlocks() (document.CodeBlocks, error) {
-	source, err := os.ReadFile(filepath.Join(fChdir, fFileName))
+	path := filepath.Join(fChdir, fFileName)
+
+	source, err := os.ReadFile(path)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
+	namingStrategy, err := getNamingStrategy()
+	if err != nil {
+		return nil, err
+	}
+
 	doc := document.New(source, getIdentityResolver())
+	doc.SetNamingStrategy(namingStrategy)
+	doc.SetMDX(document.IsMDX(path))
 
-	node, err := doc.Root()
-	if err != nil {
+	// Code blocks of failed imports and templates are skipped.
+	blocks, err := document.CollectCodeBlocksWithImports(doc, path)
+	if err != nil && !isDocumentError(err) {
 		return nil, err
 	}
 
-	return document.CollectCodeBlocks(node), nil
+	blocks, err = document.ExpandTemplates(blocks)
+	if err != nil && !isDocumentError(err) {
+		return nil, err
+	}
+
+	return blocks, nil
+}
+
+// deserializeMarkdown deserializes a Markdown document read from
+// source. MDX expressions are parsed only in MDX documents.
+func deserializeMarkdown(source string, data []byte, identityResolver *identity.IdentityResolver) (*editor.Notebook, error) {
+	if document.IsMDX(source) {
+		return editor.DeserializeMDX(data, identityResolver)
+	}
+	return editor.Deserialize(data, identityResolver)
 }
 
 func getLogger(devMode bool) (*zap.Logger, error) {
@@ -255,6 +296,7 @@ func setRunnerFlags(cmd *cobra.Command, serverAddr *string) func() ([]client.Run
 		SessionStrategy           string
 		TLSDir                    string
 		EnableBackgroundProcesses bool
+		Sandbox                   string
 	)
 
 	cmd.Flags().StringVarP(serverAddr, "server", "s", os.Getenv("RUNME_SERVER_ADDR"), "Server address to connect runner to")
@@ -265,6 +307,9 @@ func setRunnerFlags(cmd *cobra.Command, serverAddr *string) func() ([]client.Run
 
 	cmd.Flags().BoolVar(&EnableBackgroundProcesses, "background", false, "Enable running background blocks as background processes")
 
+	cmd.Flags().StringVar(&Sandbox, "sandbox", "false", "Restrict writes to the project and temp directories (Linux only). Use --sandbox=offline to disable network too")
+	cmd.Flags().Lookup("sandbox").NoOptDefVal = "true"
+
 	cmd.Flags().StringVar(&SessionStrategy, "session-strategy", func() string {
 		if val, ok := os.LookupEnv("RUNME_SESSION_STRATEGY"); ok {
 			return val
@@ -311,7 +356,14 @@ func setRunnerFlags(cmd *cobra.Command, serverAddr *string) func() ([]client.Run
 			client.WithInsecure(fInsecure),
 			client.WithEnableBackgroundProcesses(EnableBackgroundProcesses),
 			client.WithEnvs([]string{fmt.Sprintf("%s=%d", envStackDepth, stackDepth)}),
+			client.WithLanguages(languages),
+		}
+
+		sandboxLevel, err := sandbox.ParseLevel(Sandbox)
+		if err != nil {
+			return nil, err
 		}
+		runOpts = append(runOpts, client.WithSandboxLevel(sandboxLevel))
 
 		switch strings.ToLower(SessionStrategy) {
 		case "manual":
@@ -349,7 +401,7 @@ func promptEnvVars(cmd *cobra.Command, envs []string, tasks ...project.Task) err
 		block := task.CodeBlock
 
 		if block.PromptEnv() {
-			varPrompts := getCommandExportExtractMatches(block.Lines())
+			varPrompts := document.ExtractExports(block.Lines())
 			for _, ev := range varPrompts {
 				if slices.Contains(keys, ev.Key) {
 					block.GetBlock().SetLine(ev.LineNumber, "")
@@ -369,48 +421,7 @@ func promptEnvVars(cmd *cobra.Command, envs []string, tasks ...project.Task) err
 	return nil
 }
 
-func getCommandExportExtractMatches(lines []string) []CommandExportExtractMatch {
-	test := regexp.MustCompile(exportExtractRegex)
-	result := []CommandExportExtractMatch{}
-
-	for i, line := range lines {
-		for _, match := range test.FindAllStringSubmatch(line, -1) {
-			e := match[0]
-
-			parts := strings.SplitN(strings.TrimSpace(e)[len("export "):], "=", 2)
-			if len(parts) == 0 {
-				continue
-			}
-			key := parts[0]
-			ph := strings.TrimSpace(parts[1])
-
-			isExecValue := strings.HasPrefix(ph, "$(") && strings.HasSuffix(ph, ")")
-			if isExecValue {
-				continue
-			}
-
-			hasStringValue := strings.HasPrefix(ph, "\"") || strings.HasPrefix(ph, "'")
-			placeHolder := ph
-			if hasStringValue {
-				placeHolder = ph[1 : len(ph)-1]
-			}
-
-			value := placeHolder
-
-			result = append(result, CommandExportExtractMatch{
-				Key:            key,
-				Value:          value,
-				Match:          e,
-				HasStringValue: hasStringValue,
-				LineNumber:     i,
-			})
-		}
-	}
-
-	return result
-}
-
-func promptForEnvVar(cmd *cobra.Command, ev CommandExportExtractMatch) (string, error) {
+func promptForEnvVar(cmd *cobra.Command, ev document.ExportMatch) (string, error) {
 	label := fmt.Sprintf("Set Environment Variable %q:", ev.Key)
 	ip := prompt.InputParams{Label: label, PlaceHolder: ev.Value}
 	if ev.HasStringValue {
@@ -429,7 +440,7 @@ func promptForEnvVar(cmd *cobra.Command, ev CommandExportExtractMatch) (string,
 	return val, nil
 }
 
-func replaceVarValue(ev CommandExportExtractMatch, newValue string) string {
+func replaceVarValue(ev document.ExportMatch, newValue string) string {
 	parts := strings.SplitN(ev.Match, "=", 2)
 	replacedText := fmt.Sprintf("%s=%q", parts[0], newValue)
 	return replacedText
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/stateful/runme/internal/document/editor"
+	"github.com/stateful/runme/internal/document/identity"
+)
+
+func convertCmd() *cobra.Command {
+	var (
+		output      string
+		force       bool
+		skipOutputs bool
+	)
+
+	cmd := cobra.Command{
+		Use:   "convert FILE",
+		Short: "Convert Jupyter notebooks to Markdown and back",
+		Long: `Converts a Jupyter notebook (.ipynb) to a Runme Markdown file or a Markdown file to a Jupyter notebook.
+
+Code cells become fenced code blocks with the language and metadata as attributes.
+Text outputs of notebook cells are written as separate "text" blocks with the "output"
+attribute below the code unless --skip-outputs is set. These blocks become outputs again
+when a Markdown file is converted to a notebook.
+Attributes and the frontmatter are kept in the notebook metadata so that a notebook
+can be converted back to the same Markdown.
+
+By default, the result is written next to the source file with the extension replaced.
+Use --output - to write to stdout.`,
+		Example: `runme convert notebook.ipynb
+runme convert README.md --output notebook.ipynb`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			source := args[0]
+
+			data, err := readMarkdown(source)
+			if err != nil {
+				return err
+			}
+
+			toMarkdown := strings.EqualFold(filepath.Ext(source), ".ipynb")
+
+			if output == "" {
+				if source == "-" {
+					output = "-"
+				} else if toMarkdown {
+					output = strings.TrimSuffix(source, filepath.Ext(source)) + ".md"
+				} else {
+					output = strings.TrimSuffix(source, filepath.Ext(source)) + ".ipynb"
+				}
+			}
+
+			if output != "-" && !force {
+				if _, err := os.Stat(output); err == nil {
+					return errors.Errorf("%s already exists; use --force to overwrite it", output)
+				}
+			}
+
+			var result []byte
+
+			if toMarkdown {
+				notebook, err := editor.DeserializeIPYNB(data)
+				if err != nil {
+					return err
+				}
+				if skipOutputs {
+					for _, cell := range notebook.Cells {
+						cell.Outputs = nil
+					}
+				} else {
+					editor.OutputsToCells(notebook)
+				}
+				result, err = editor.Serialize(notebook, nil)
+				if err != nil {
+					return errors.Wrap(err, "failed to serialize")
+				}
+			} else {
+				// Don't add identities to keep the document unchanged.
+				identityResolver := identity.NewResolver(identity.UnspecifiedLifecycleIdentity)
+				notebook, err := deserializeMarkdown(source, data, identityResolver)
+				if err != nil {
+					return errors.Wrap(err, "failed to deserialize")
+				}
+				result, err = editor.SerializeIPYNB(notebook)
+				if err != nil {
+					return err
+				}
+			}
+
+			return writeMarkdown(output, result)
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "File to write the result to. Use - for stdout.")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite the output file if it exists.")
+	cmd.Flags().BoolVar(&skipOutputs, "skip-outputs", false, "Do not include outputs of notebook cells.")
+
+	return &cmd
+}
@@ -0,0 +1,287 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/document/identity"
+	"github.com/stateful/runme/internal/export"
+	"github.com/stateful/runme/internal/project"
+)
+
+const (
+	exportFormatShell    = "sh"
+	exportFormatMake     = "make"
+	exportFormatJust     = "just"
+	exportFormatTaskfile = "taskfile"
+	exportFormatGitHub   = "github-actions"
+	exportFormatGitLab   = "gitlab-ci"
+)
+
+var exportFormats = []string{
+	exportFormatShell,
+	exportFormatMake,
+	exportFormatJust,
+	exportFormatTaskfile,
+	exportFormatGitHub,
+	exportFormatGitLab,
+}
+
+func exportCmd() *cobra.Command {
+	var (
+		format   string
+		output   string
+		inline   bool
+		category string
+	)
+
+	cmd := cobra.Command{
+		Use:   "export [FILE]",
+		Short: "Export a runbook to a format which doesn't require Runme",
+		Long: `Exports a Markdown file to a format which can be run without Runme.
+
+Supported formats:
+  sh        a standalone shell script which runs all cells in the document order
+  make      a Makefile with a target for every named task in the project
+  just      a Justfile with a recipe for every named task in the project
+  taskfile  a Taskfile (taskfile.dev) with a task for every named task in the project
+  github-actions, gitlab-ci
+            a CI pipeline with a step for every named cell in the document
+
+Targets call "runme run" unless --inline is set. In this case, scripts of shell tasks
+are written directly. Categories of tasks are preserved as groups and dependencies
+from the "dependsOn" attribute are kept. If FILE is provided, only its tasks are exported.
+
+Pipelines are triggered manually and the "inputs" from the frontmatter become their
+inputs. Cells with "excludeFromRunAll" are skipped. Use --category to create a pipeline
+from tasks in a category across the project instead of a single document.
+
+Exported shell scripts prompt for values of exported variables which are not set yet.
+Prompts are printed with printf followed by "read -r" because "read -p" is not
+supported by POSIX shells and means something else in zsh.
+
+By default, the file from --filename is exported as a shell script and the result
+is written to stdout.`,
+		Example: `runme export --format sh README.md --output setup.sh
+runme export --format make --output Makefile
+runme export --format github-actions --category deploy --output .github/workflows/deploy.yml`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			category = strings.TrimSpace(category)
+
+			switch format {
+			case exportFormatMake, exportFormatJust, exportFormatTaskfile:
+				return exportTasks(cmd, args, format, output, inline, category)
+			case exportFormatGitHub, exportFormatGitLab:
+				return exportCI(cmd, args, format, output, category)
+			}
+
+			if category != "" {
+				return errors.Errorf("--category is not supported by %s", format)
+			}
+
+			source := filepath.Join(fChdir, fFileName)
+			if len(args) > 0 {
+				source = args[0]
+			}
+
+			data, err := readMarkdown(source)
+			if err != nil {
+				return err
+			}
+
+			// Don't add identities as they are not a part of the result.
+			identityResolver := identity.NewResolver(identity.UnspecifiedLifecycleIdentity)
+			doc := document.New(data, identityResolver)
+			doc.SetMDX(document.IsMDX(source))
+
+			dir, err := filepath.Abs(filepath.Dir(source))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			var buf bytes.Buffer
+
+			switch format {
+			case exportFormatShell:
+				err = export.ShellScript(&buf, doc, export.ShellScriptOptions{
+					Source:    filepath.Base(source),
+					Dir:       dir,
+					Languages: languages,
+				})
+			default:
+				return errors.Errorf("unsupported format %q; use one of: %s", format, strings.Join(exportFormats, ", "))
+			}
+			if err != nil {
+				return errors.Wrap(err, "failed to export")
+			}
+
+			return writeExport(cmd, output, buf.Bytes(), 0o755)
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.Flags().StringVar(&format, "format", exportFormatShell, "Format of the result. One of: "+strings.Join(exportFormats, ", "))
+	cmd.Flags().StringVarP(&output, "output", "o", "", "File to write the result to. By default, it is written to stdout.")
+	cmd.Flags().BoolVar(&inline, "inline", false, "Write scripts of shell tasks instead of calling \"runme run\". Only for make, just and taskfile.")
+	cmd.Flags().StringVarP(&category, "category", "c", "", "Export only tasks from a specific category. Not supported by sh.")
+
+	return &cmd
+}
+
+// getExportTasks returns project tasks filtered by a document
+// from args and by category, and the root of the project.
+func getExportTasks(cmd *cobra.Command, args []string, category string) ([]project.Task, string, error) {
+	proj, err := getProject()
+	if err != nil {
+		return nil, "", err
+	}
+
+	loader, err := newProjectLoader(cmd, fAllowUnknown, fAllowUnnamed)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tasks, err := loader.LoadTasks(proj)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tasks, err = project.FilterTasks(tasks, func(t project.Task) (bool, error) {
+		if len(args) > 0 {
+			path, err := filepath.Abs(args[0])
+			if err != nil {
+				return false, errors.WithStack(err)
+			}
+			if t.DocumentPath != path {
+				return false, nil
+			}
+		}
+		if category != "" && !hasCategory(t.CodeBlock, category) {
+			return false, nil
+		}
+		return true, nil
+	})
+
+	return tasks, proj.Root(), err
+}
+
+func exportTasks(cmd *cobra.Command, args []string, format, output string, inline bool, category string) error {
+	tasks, root, err := getExportTasks(cmd, args, category)
+	if err != nil {
+		return err
+	}
+
+	// Directories of inlined tasks are relative
+	// to the location of the result.
+	dir := fChdir
+	if output != "" && output != "-" {
+		dir = filepath.Dir(output)
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	opts := export.TasksOptions{Dir: dir, Root: root, Inline: inline, Languages: languages}
+
+	var buf bytes.Buffer
+
+	switch format {
+	case exportFormatMake:
+		err = export.Makefile(&buf, tasks, opts)
+	case exportFormatJust:
+		err = export.Justfile(&buf, tasks, opts)
+	case exportFormatTaskfile:
+		err = export.Taskfile(&buf, tasks, opts)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to export")
+	}
+
+	return writeExport(cmd, output, buf.Bytes(), 0o644)
+}
+
+func exportCI(cmd *cobra.Command, args []string, format, output, category string) error {
+	var (
+		tasks []project.Task
+		opts  export.CIOptions
+		err   error
+	)
+
+	if category != "" {
+		tasks, _, err = getExportTasks(cmd, args, category)
+		if err != nil {
+			return err
+		}
+		opts.Name = category
+		opts.Source = fmt.Sprintf("tasks in the %q category", category)
+	} else {
+		source := filepath.Join(fChdir, fFileName)
+		if len(args) > 0 {
+			source = args[0]
+		}
+
+		data, err := readMarkdown(source)
+		if err != nil {
+			return err
+		}
+
+		path, err := filepath.Abs(source)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		identityResolver := identity.NewResolver(identity.UnspecifiedLifecycleIdentity)
+		doc := document.New(data, identityResolver)
+		doc.SetMDX(document.IsMDX(source))
+		node, err := doc.Root()
+		if err != nil {
+			return errors.Wrap(err, "failed to parse document")
+		}
+
+		for _, block := range document.CollectCodeBlocks(node) {
+			tasks = append(tasks, project.Task{CodeBlock: block, DocumentPath: path})
+		}
+
+		opts.Name = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+		opts.Source = filepath.Base(source)
+	}
+
+	opts.Dir, err = filepath.Abs(fChdir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	opts.Languages = languages
+
+	var buf bytes.Buffer
+
+	switch format {
+	case exportFormatGitHub:
+		err = export.GitHubActions(&buf, tasks, opts)
+	case exportFormatGitLab:
+		err = export.GitLabCI(&buf, tasks, opts)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to export")
+	}
+
+	return writeExport(cmd, output, buf.Bytes(), 0o644)
+}
+
+func writeExport(cmd *cobra.Command, output string, data []byte, mode os.FileMode) error {
+	if output == "" || output == "-" {
+		_, err := cmd.OutOrStdout().Write(data)
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(output, data, mode))
+}
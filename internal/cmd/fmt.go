@@ -83,7 +83,7 @@ func fmtFiles(files []string, flatten bool, formatJSON bool, write bool, outputt
 		var formatted []byte
 
 		if flatten {
-			notebook, err := editor.Deserialize(data, identityResolver)
+			notebook, err := deserializeMarkdown(file, data, identityResolver)
 			if err != nil {
 				return errors.Wrap(err, "failed to deserialize")
 			}
@@ -104,6 +104,7 @@ func fmtFiles(files []string, flatten bool, formatJSON bool, write bool, outputt
 			}
 		} else {
 			doc := document.New(data, identityResolver)
+			doc.SetMDX(document.IsMDX(file))
 			astNode, err := doc.RootAST()
 			if err != nil {
 				return errors.Wrap(err, "failed to parse source")
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/stateful/runme/internal/runner"
+)
+
+func limitsCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:    "limits",
+		Hidden: true,
+		Short:  "Resource limits for executed programs",
+		Long:   "Commands used by the runner to execute programs with resource limits",
+	}
+
+	cmd.AddCommand(limitsExecCmd())
+
+	setDefaultFlags(&cmd)
+
+	return &cmd
+}
+
+func limitsExecCmd() *cobra.Command {
+	var rlimits runner.Rlimits
+
+	cmd := cobra.Command{
+		Use:   "exec [--cpu SECONDS] [--nofile N] [--as BYTES] [--nproc N] -- PROGRAM [ARGS]...",
+		Short: "Execute a program with resource limits",
+		Long:  "Sets resource limits with setrlimit(2) and replaces itself with the program. Linux only.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runner.ExecWithRlimits(rlimits, args)
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.Flags().Uint64Var(&rlimits.CPU, "cpu", 0, "CPU time limit in seconds")
+	cmd.Flags().Uint64Var(&rlimits.NoFile, "nofile", 0, "Maximum number of open file descriptors")
+	cmd.Flags().Uint64Var(&rlimits.AS, "as", 0, "Maximum size of the address space in bytes")
+	cmd.Flags().Uint64Var(&rlimits.NProc, "nproc", 0, "Maximum number of processes of the user")
+
+	return &cmd
+}
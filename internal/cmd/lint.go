@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/lint"
+	"github.com/stateful/runme/internal/version"
+)
+
+const (
+	lintFormatText  = "text"
+	lintFormatJSON  = "json"
+	lintFormatSARIF = "sarif"
+)
+
+func lintCmd() *cobra.Command {
+	var (
+		format string
+		output string
+		envs   []string
+	)
+
+	cmd := cobra.Command{
+		Use:   "lint [FILE...]",
+		Short: "Check runbooks for common problems",
+		Long: `Check runbooks for common problems, like unnamed code blocks, duplicate names,
+unsupported languages, malformed or unknown attributes, missing frontmatter IDs,
+undeclared environment variables, and working directories which don't exist.
+
+Without arguments, all files of the project are checked. Use --format json or
+--format sarif to consume the results in CI. The command fails if any issue
+has the "error" severity.`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch format {
+			case lintFormatText, lintFormatJSON, lintFormatSARIF:
+			default:
+				return errors.Errorf("unknown format %q, expected %q, %q or %q", format, lintFormatText, lintFormatJSON, lintFormatSARIF)
+			}
+
+			namingStrategy, err := getNamingStrategy()
+			if err != nil {
+				return err
+			}
+
+			files := args
+			if len(files) == 0 {
+				files, envs, err = getLintProjectFiles(cmd, envs)
+				if err != nil {
+					return err
+				}
+			}
+
+			issues, err := lintFiles(files, lint.Options{
+				Env:            envs,
+				NamingStrategy: namingStrategy,
+				Languages:      languages,
+			})
+			if err != nil {
+				return err
+			}
+
+			var data []byte
+			switch format {
+			case lintFormatJSON:
+				if issues == nil {
+					issues = []lint.Issue{}
+				}
+				data, err = json.MarshalIndent(issues, "", "  ")
+				data = append(data, '\n')
+			case lintFormatSARIF:
+				data, err = lint.SARIF(issues, version.BuildVersion)
+				data = append(data, '\n')
+			default:
+				data = []byte(formatLintIssues(issues))
+			}
+			if err != nil {
+				return errors.Wrap(err, "failed to encode issues")
+			}
+
+			if err := writeExport(cmd, output, data, 0o644); err != nil {
+				return err
+			}
+
+			for _, issue := range issues {
+				if issue.Severity == lint.SeverityError {
+					cmd.SilenceUsage = true
+					return errors.New("found issues with the error severity")
+				}
+			}
+
+			return nil
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.Flags().StringVar(&format, "format", lintFormatText, "Output format: text, json or sarif.")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write issues to a file instead of stdout.")
+	cmd.Flags().StringArrayVar(&envs, "env", nil, "Name of an environment variable which is set outside of runbooks. Can be repeated.")
+
+	return &cmd
+}
+
+// getLintProjectFiles returns files of the project and
+// names of environment variables declared in its env files.
+func getLintProjectFiles(cmd *cobra.Command, envs []string) ([]string, []string, error) {
+	files, err := getProjectFiles(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	proj, err := getProject()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	projEnvs, err := proj.LoadEnvsAsMap()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for name := range projEnvs {
+		envs = append(envs, name)
+	}
+	sort.Strings(envs)
+
+	return files, envs, nil
+}
+
+func lintFiles(files []string, opts lint.Options) ([]lint.Issue, error) {
+	var result []lint.Issue
+
+	for _, file := range files {
+		data, err := readMarkdown(file)
+		if err != nil {
+			return nil, err
+		}
+
+		fileOpts := opts
+		fileOpts.Dir = filepath.Dir(file)
+		if load := document.LoaderFor(file); load != nil {
+			data, err = load(data)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to load %s", file)
+			}
+			fileOpts.Converted = true
+		}
+
+		path := file
+		if rel, err := filepath.Rel(fChdir, file); err == nil && filepath.IsLocal(rel) {
+			path = rel
+		}
+
+		issues, err := lint.Lint(path, data, fileOpts)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, issues...)
+	}
+
+	return result, nil
+}
+
+func formatLintIssues(issues []lint.Issue) string {
+	if len(issues) == 0 {
+		return "No issues found.\n"
+	}
+
+	var (
+		result string
+		counts = make(map[lint.Severity]int)
+	)
+	for _, issue := range issues {
+		result += issue.String() + "\n"
+		counts[issue.Severity]++
+	}
+
+	return result + fmt.Sprintf(
+		"\n%d error(s), %d warning(s), %d note(s)\n",
+		counts[lint.SeverityError],
+		counts[lint.SeverityWarning],
+		counts[lint.SeverityNote],
+	)
+}
@@ -13,7 +13,7 @@ func printCmd() *cobra.Command {
 	cmd := cobra.Command{
 		Use:               "print",
 		Short:             "Print a selected snippet",
-		Long:              "Print will display the details of the corresponding command block based on its name.",
+		Long:              "Print will display the details of the corresponding command block based on its name.\nA command block can also be addressed by headings of the section it's in, for example, \"README.md#Deploy/Staging\".\nHeadings containing \"/\" can be addressed only by their anchor, for example, \"README.md#cicd\" for \"CI/CD\".",
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: validCmdNames,
 		RunE: func(cmd *cobra.Command, args []string) error {
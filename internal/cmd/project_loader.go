@@ -10,6 +10,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/stateful/runme/internal/document"
 	"github.com/stateful/runme/internal/project"
 )
 
@@ -18,6 +19,7 @@ type projectLoader struct {
 	allowUnnamed bool
 	ctx          context.Context
 	w            io.Writer
+	errW         io.Writer
 	r            io.Reader
 	isTerminal   bool
 }
@@ -34,6 +36,7 @@ func newProjectLoader(cmd *cobra.Command, allowUnknown, allowUnnamed bool) (*pro
 		allowUnnamed: allowUnnamed,
 		ctx:          cmd.Context(),
 		w:            cmd.OutOrStdout(),
+		errW:         cmd.ErrOrStderr(),
 		r:            cmd.InOrStdin(),
 		isTerminal:   isTerminal(fd),
 	}, nil
@@ -57,7 +60,7 @@ func (pl projectLoader) LoadTasks(proj *project.Project) ([]project.Task, error)
 	filtered := make([]project.Task, 0, len(tasks))
 
 	for _, task := range tasks {
-		if !pl.allowUnknown && task.CodeBlock.IsUnknown() {
+		if !pl.allowUnknown && isUnknownTask(task) {
 			continue
 		}
 
@@ -71,6 +74,12 @@ func (pl projectLoader) LoadTasks(proj *project.Project) ([]project.Task, error)
 	return filtered, nil
 }
 
+// isUnknownTask returns true if the task's language is neither
+// supported out of the box nor declared as a custom language.
+func isUnknownTask(task project.Task) bool {
+	return task.CodeBlock.IsUnknown() && !languages.IsSupported(task.CodeBlock.Language())
+}
+
 func (pl projectLoader) LoadAllTasks(proj *project.Project) ([]project.Task, error) {
 	_, tasks, err := pl.load(proj, false)
 	return tasks, err
@@ -110,6 +119,10 @@ func (pl projectLoader) loadInTerminal(proj *project.Project, onlyFiles bool) ([
 		return nil, nil, resultModel.err
 	}
 
+	for _, err := range resultModel.warnings {
+		pl.warn(err)
+	}
+
 	return resultModel.files, resultModel.tasks, nil
 }
 
@@ -127,6 +140,10 @@ func (pl projectLoader) loadWithoutTerminal(proj *project.Project, onlyFiles boo
 		switch event.Type {
 		case project.LoadEventError:
 			err := project.ExtractDataFromLoadEvent[project.LoadEventErrorData](event).Err
+			if isDocumentError(err) {
+				pl.warn(err)
+				continue
+			}
 			return nil, nil, err
 
 		case project.LoadEventFoundFile:
@@ -146,6 +163,20 @@ func (pl projectLoader) loadWithoutTerminal(proj *project.Project, onlyFiles boo
 	return files, tasks, nil
 }
 
+func (pl projectLoader) warn(err error) {
+	_, _ = fmt.Fprintf(pl.errW, "WARNING: %s\n", err)
+}
+
+// isDocumentError returns true if the error is caused by failed imports
+// or templates of a document. Such errors don't stop loading other tasks.
+func isDocumentError(err error) bool {
+	var (
+		importErr   *document.ImportError
+		templateErr *document.TemplateError
+	)
+	return errors.As(err, &importErr) || errors.As(err, &templateErr)
+}
+
 type loadTasksEvent struct {
 	Event project.LoadEvent
 }
@@ -160,6 +191,7 @@ type loadTasksModel struct {
 
 	finished bool
 	err      error
+	warnings []error
 
 	tasks []project.Task
 	files []string
@@ -219,6 +251,10 @@ func (m loadTasksModel) handleTask(event project.LoadEvent) (tea.Model, tea.Cmd)
 	switch event.Type {
 	case project.LoadEventError:
 		data := project.ExtractDataFromLoadEvent[project.LoadEventErrorData](event)
+		if isDocumentError(data.Err) {
+			m.warnings = append(m.warnings, data.Err)
+			break
+		}
 		m.err = data.Err
 		cmd = tea.Quit
 
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/stateful/runme/internal/document/editor"
+	"github.com/stateful/runme/internal/document/identity"
+	"github.com/stateful/runme/internal/renderer/html"
+	"github.com/stateful/runme/internal/runner/client"
+)
+
+func renderCmd() *cobra.Command {
+	var (
+		asHTML     bool
+		reportPath string
+		output     string
+		title      string
+	)
+
+	cmd := cobra.Command{
+		Use:   "render [FILE]",
+		Short: "Render a runbook with outputs of its cells",
+		Long: `Renders a document as a self-contained HTML page with outputs of executed cells.
+
+Outputs, exit codes and durations come from a report written by "runme run --report"
+or from outputs stored in the document itself, for example, a Jupyter notebook (.ipynb)
+or a serialized notebook (.json). Cells are matched with the report by name.
+ANSI colors in outputs are preserved.
+
+By default, the file from --filename is rendered and the result is written to stdout.`,
+		Example: `runme run --all --report report.json
+runme render --html --report report.json --output postmortem.html`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !asHTML {
+				return errors.New("no format selected; use --html")
+			}
+
+			source := filepath.Join(fChdir, fFileName)
+			if len(args) > 0 {
+				source = args[0]
+			}
+
+			data, err := readMarkdown(source)
+			if err != nil {
+				return err
+			}
+
+			notebook, err := readNotebook(source, data)
+			if err != nil {
+				return err
+			}
+
+			opts := html.Options{Title: title}
+			if opts.Title == "" {
+				opts.Title = filepath.Base(source)
+			}
+
+			if reportPath != "" {
+				opts.Report, err = client.ReadReport(reportPath)
+				if err != nil {
+					return err
+				}
+			}
+
+			if source != "-" {
+				opts.Document, err = filepath.Abs(source)
+				if err != nil {
+					return errors.WithStack(err)
+				}
+			}
+
+			var buf bytes.Buffer
+			if err := html.Render(&buf, notebook, opts); err != nil {
+				return errors.Wrap(err, "failed to render")
+			}
+
+			return writeExport(cmd, output, buf.Bytes(), 0o644)
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.Flags().BoolVar(&asHTML, "html", false, "Render as a self-contained HTML page.")
+	cmd.Flags().StringVar(&reportPath, "report", "", "Report from \"runme run --report\" with outputs of cells.")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "File to write the result to. By default, it is written to stdout.")
+	cmd.Flags().StringVar(&title, "title", "", "Title of the page. By default, it's the file name.")
+
+	return &cmd
+}
+
+// readNotebook deserializes a Markdown file,
+// a Jupyter notebook or a serialized notebook.
+func readNotebook(source string, data []byte) (*editor.Notebook, error) {
+	switch strings.ToLower(filepath.Ext(source)) {
+	case ".ipynb":
+		return editor.DeserializeIPYNB(data)
+	case ".json":
+		var notebook editor.Notebook
+		if err := json.Unmarshal(data, &notebook); err != nil {
+			return nil, errors.Wrap(err, "failed to parse notebook")
+		}
+		return &notebook, nil
+	}
+
+	// Don't add identities as they are not a part of the result.
+	identityResolver := identity.NewResolver(identity.UnspecifiedLifecycleIdentity)
+	notebook, err := deserializeMarkdown(source, data, identityResolver)
+	return notebook, errors.Wrap(err, "failed to deserialize")
+}
@@ -8,6 +8,9 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/executable"
 )
 
 var (
@@ -22,8 +25,13 @@ var (
 	fInsecure              bool
 	fLogEnabled            bool
 	fLogFilePath           string
+	fNaming                string
 )
 
+// languages are custom languages declared by the user. Languages
+// declared in the project are added when the project is loaded.
+var languages = executable.NewLanguages()
+
 func Root() *cobra.Command {
 	cmd := cobra.Command{
 		Use:           "runme",
@@ -31,7 +39,7 @@ func Root() *cobra.Command {
 		Long:          "Runme executes commands inside your runbooks, docs, and READMEs. Parses commands\ndirectly from markdown files to make them executable.",
 		SilenceErrors: true,
 		SilenceUsage:  true,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			if strings.HasPrefix(fChdir, "~") {
 				cmd.PrintErrf("WARNING: --chdir starts with ~ which should be resolved by shell. Try re-running with --chdir %s (note lack of =) if it fails.\n\n", fChdir)
 
@@ -54,6 +62,8 @@ func Root() *cobra.Command {
 			if fFileMode && !cmd.Flags().Changed("allow-unnamed") {
 				fAllowUnnamed = true
 			}
+
+			return languages.Load(filepath.Join(GetDefaultConfigHome(), executable.LanguagesFileName))
 		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			return nil, cobra.ShellCompDirectiveNoFileComp
@@ -66,6 +76,7 @@ func Root() *cobra.Command {
 
 	pflags.BoolVar(&fAllowUnknown, "allow-unknown", true, "Display snippets without known executor")
 	pflags.BoolVar(&fAllowUnnamed, "allow-unnamed", false, "Allow scripts without explicit names")
+	pflags.StringVar(&fNaming, "naming", string(document.NamingStrategyCommand), "Strategy for naming scripts without explicit names: \"command\" or \"heading\". Frontmatter \"naming\" takes precedence")
 
 	pflags.StringVar(&fChdir, "chdir", getCwd(), "Switch to a different working directory before executing the command")
 	pflags.StringVar(&fFileName, "filename", "README.md", "Name of the README file")
@@ -96,14 +107,20 @@ func Root() *cobra.Command {
 
 	cmd.AddCommand(branchCmd)
 	cmd.AddCommand(codeServerCmd())
+	cmd.AddCommand(convertCmd())
+	cmd.AddCommand(exportCmd())
 	cmd.AddCommand(environmentCmd())
 	cmd.AddCommand(fmtCmd())
+	cmd.AddCommand(limitsCmd())
+	cmd.AddCommand(lintCmd())
 	cmd.AddCommand(listCmd())
 	cmd.AddCommand(loginCmd())
 	cmd.AddCommand(logoutCmd())
 	cmd.AddCommand(printCmd())
+	cmd.AddCommand(renderCmd())
 	cmd.AddCommand(extensionCmd())
 	cmd.AddCommand(runCmd())
+	cmd.AddCommand(sandboxCmd())
 	cmd.AddCommand(serverCmd())
 	cmd.AddCommand(shellCmd())
 	cmd.AddCommand(suggestCmd)
@@ -18,24 +18,14 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rwtodd/Go.Sed/sed"
 	"github.com/spf13/cobra"
+	"github.com/stateful/runme/internal/document"
 	"github.com/stateful/runme/internal/project"
+	runnerpkg "github.com/stateful/runme/internal/runner"
 	"github.com/stateful/runme/internal/runner/client"
 	"github.com/stateful/runme/internal/tui"
 	"golang.org/x/exp/slices"
 )
 
-const (
-	exportExtractRegex string = `(\n*)export (\w+=)(("[^"]*")|('[^']*')|[^;\n]+)`
-)
-
-type CommandExportExtractMatch struct {
-	Key            string
-	Value          string
-	Match          string
-	HasStringValue bool
-	LineNumber     int
-}
-
 func runCmd() *cobra.Command {
 	var (
 		dryRun                bool
@@ -48,13 +38,15 @@ func runCmd() *cobra.Command {
 		category              string
 		getRunnerOpts         func() ([]client.RunnerOption, error)
 		runIndex              int
+		printUsage            bool
+		reportPath            string
 	)
 
 	cmd := cobra.Command{
 		Use:               "run <commands>",
 		Aliases:           []string{"exec"},
 		Short:             "Run a selected command",
-		Long:              "Run a selected command identified based on its unique parsed name.",
+		Long:              "Run a selected command identified based on its unique parsed name.\nA command can also be addressed by headings of the section it's in, for example, \"README.md#Deploy/Staging\".\nHeadings containing \"/\" can be addressed only by their anchor, for example, \"README.md#cicd\" for \"CI/CD\".",
 		Args:              cobra.ArbitraryArgs,
 		ValidArgsFunction: validCmdNames,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -122,10 +114,6 @@ func runCmd() *cobra.Command {
 							return err
 						}
 
-						if err := replace(replaceScripts, task.CodeBlock.Lines()); err != nil {
-							return err
-						}
-
 						runTasks = append(runTasks, task)
 					}
 				}
@@ -135,6 +123,12 @@ func runCmd() *cobra.Command {
 				return errors.New("No tasks to execute with the category provided")
 			}
 
+			for _, task := range runTasks {
+				if err := replace(replaceScripts, task.CodeBlock.Lines()); err != nil {
+					return err
+				}
+			}
+
 			ctx, cancel := ctxWithSigCancel(cmd.Context())
 			defer cancel()
 
@@ -216,6 +210,22 @@ func runCmd() *cobra.Command {
 
 			infoMsgPrefix := playColor.Sprint(" ► ")
 
+			if printUsage {
+				if err := client.ApplyOptions(runner, client.WithResourceUsageHandler(func(task project.Task, usage *runnerpkg.ResourceUsage) {
+					_, _ = fmt.Fprintf(
+						cmd.ErrOrStderr(),
+						"%s %s %s %s %s\n",
+						infoMsgPrefix,
+						textColor.Sprint("Task"),
+						blockColor.Sprint(task.CodeBlock.Name()),
+						textColor.Sprint("used"),
+						usage,
+					)
+				})); err != nil {
+					return err
+				}
+			}
+
 			multiRunner := client.MultiRunner{
 				Runner: runner,
 				PreRunMsg: func(tasks []project.Task, parallel bool) string {
@@ -274,6 +284,13 @@ func runCmd() *cobra.Command {
 				PreRunOpts: preRunOpts,
 			}
 
+			var recorder *client.ReportRecorder
+			if reportPath != "" {
+				recorder = client.NewReportRecorder()
+				multiRunner.PreRunTaskOpts = recorder.Options
+				multiRunner.PostRun = recorder.Finish
+			}
+
 			if parallel {
 				multiRunner.StdoutPrefix = fmt.Sprintf("[%s] ", blockColor.Sprintf("%%s"))
 			}
@@ -281,7 +298,21 @@ func runCmd() *cobra.Command {
 			defer multiRunner.Cleanup(cmd.Context())
 
 			if dryRun {
-				return runner.DryRunTask(ctx, runTasks[0], cmd.ErrOrStderr()) // #nosec G602; runBlocks is checked
+				for i, task := range runTasks {
+					if len(runTasks) > 1 {
+						if i > 0 {
+							_, _ = fmt.Fprintln(cmd.ErrOrStderr())
+						}
+						_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "// task %q\n", task.CodeBlock.Name())
+					}
+
+					trimReplacedLines(replaceScripts, task.CodeBlock.Lines())
+
+					if err := runner.DryRunTask(ctx, task, cmd.ErrOrStderr()); err != nil {
+						return err
+					}
+				}
+				return nil
 			}
 
 			err = inRawMode(func() error {
@@ -293,9 +324,24 @@ func runCmd() *cobra.Command {
 					return err
 				}
 
-				return runner.RunTask(ctx, runTasks[0]) // #nosec G602; runBlocks comes from the parent scope and is checked
+				if recorder == nil {
+					return runner.RunTask(ctx, runTasks[0]) // #nosec G602; runBlocks comes from the parent scope and is checked
+				}
+
+				if err := client.ApplyOptions(runner, recorder.Options(runTasks[0])...); err != nil {
+					return err
+				}
+				err := runner.RunTask(ctx, runTasks[0])
+				recorder.Finish(runTasks[0], err)
+				return err
 			})
 
+			if recorder != nil {
+				if rerr := recorder.Write(reportPath); rerr != nil {
+					return errors.Wrap(rerr, "failed to write report")
+				}
+			}
+
 			if err != nil {
 				if err != nil && errors.Is(err, io.ErrClosedPipe) {
 					err = nil
@@ -314,6 +360,8 @@ func runCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&skipPrompts, "skip-prompts", false, "Skip prompting for variables.")
 	cmd.Flags().StringVarP(&category, "category", "c", "", "Run from a specific category.")
 	cmd.Flags().IntVarP(&runIndex, "index", "i", -1, "Index of command to run, 0-based. (Ignored in project mode)")
+	cmd.Flags().BoolVar(&printUsage, "usage", false, "Print resource usage (wall time, CPU time, max RSS) of each task.")
+	cmd.Flags().StringVar(&reportPath, "report", "", "Write outputs, exit codes and durations of tasks as JSON to a file. Use with \"runme render --html\".")
 	cmd.PreRun = func(cmd *cobra.Command, args []string) {
 		skipPromptsExplicitly = cmd.Flags().Changed("skip-prompts")
 	}
@@ -360,6 +408,19 @@ func replace(scripts []string, lines []string) error {
 	return nil
 }
 
+// trimReplacedLines removes new line characters which sed appends
+// to every replaced line. It's done only for dry-run where they
+// would show up as blank lines in the printed script.
+func trimReplacedLines(scripts []string, lines []string) {
+	if len(scripts) == 0 {
+		return
+	}
+
+	for idx, line := range lines {
+		lines[idx] = strings.TrimRight(line, "\n")
+	}
+}
+
 func inRawMode(cb func() error) error {
 	if !isTerminal(os.Stdout.Fd()) {
 		return cb()
@@ -375,7 +436,10 @@ func inRawMode(cb func() error) error {
 	return err
 }
 
-const fileNameSeparator = "/"
+const (
+	fileNameSeparator    = "/"
+	headingPathSeparator = "#"
+)
 
 func splitRunArgument(name string) (queryFile string, queryName string, err error) {
 	parts := strings.SplitN(name, fileNameSeparator, 2)
@@ -461,19 +525,63 @@ func (p RunBlockPrompt) View() string {
 	return blockPromptAppStyle.Render(content)
 }
 
-func lookupTaskWithPrompt(cmd *cobra.Command, query string, tasks []project.Task) (task project.Task, err error) {
+// filterTasksByQuery returns tasks matching a query in the form
+// "{file}/{task-name}" or, to address tasks by headings of sections
+// they are in, "{file}#{heading}/{heading}".
+//
+// A query is a heading path only if no task matches it by name,
+// so tasks with "#" in their names can still be run.
+func filterTasksByQuery(tasks []project.Task, query string) ([]project.Task, error) {
+	result, err := filterTasksByName(tasks, query)
+	if err == nil {
+		return result, nil
+	}
+
+	if queryFile, headingPath, ok := strings.Cut(query, headingPathSeparator); ok {
+		return project.FilterTasksByHeadingPath(tasks, queryFile, document.ParseHeadingPath(headingPath))
+	}
+
+	return result, err
+}
+
+func filterTasksByName(tasks []project.Task, query string) ([]project.Task, error) {
 	queryFile, queryName, err := splitRunArgument(query)
 	if err != nil {
-		return task, err
+		return nil, err
+	}
+
+	result, err := project.FilterTasksByFileAndTaskName(tasks, queryFile, queryName)
+
+	// The file query can contain the separator too, for example,
+	// "docs/README\.md/build" which "runme export" generates.
+	// Try the following separators if the first one doesn't match.
+	for next := queryName; err != nil; {
+		idx := strings.Index(next, fileNameSeparator)
+		if idx == -1 {
+			break
+		}
+		queryFile += fileNameSeparator + next[:idx]
+		next = next[idx+1:]
+
+		if tasks, err := project.FilterTasksByFileAndTaskName(tasks, queryFile, next); err == nil {
+			return tasks, nil
+		}
 	}
 
-	filteredTasks, err := project.FilterTasksByFileAndTaskName(tasks, queryFile, queryName)
+	return result, err
+}
+
+func lookupTaskWithPrompt(cmd *cobra.Command, query string, tasks []project.Task) (task project.Task, err error) {
+	filteredTasks, err := filterTasksByQuery(tasks, query)
 	if err != nil {
 		return task, err
 	}
 
 	if len(filteredTasks) > 1 {
 		if !isTerminal(os.Stdout.Fd()) {
+			if strings.Contains(query, headingPathSeparator) {
+				return task, fmt.Errorf("multiple matches found in section; please add a task name in the form \"{file}%s{heading}%s{task-name}\"", headingPathSeparator, document.HeadingPathSeparator)
+			}
 			return task, fmt.Errorf("multiple matches found for code block; please use a file specifier in the form \"{file}%s{task-name}\"", fileNameSeparator)
 		}
 
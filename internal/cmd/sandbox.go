@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/stateful/runme/internal/sandbox"
+)
+
+func sandboxCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:    "sandbox",
+		Hidden: true,
+		Short:  "Sandbox for executed programs",
+		Long:   "Commands used by the runner to execute programs in a sandbox",
+	}
+
+	cmd.AddCommand(sandboxExecCmd())
+
+	setDefaultFlags(&cmd)
+
+	return &cmd
+}
+
+func sandboxExecCmd() *cobra.Command {
+	var writablePaths []string
+
+	cmd := cobra.Command{
+		Use:   "exec [--writable PATH]... -- PROGRAM [ARGS]...",
+		Short: "Execute a program with restricted writes",
+		Long:  "Restricts writes to the provided paths and replaces itself with the program. Linux only.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sandbox.Exec(writablePaths, args)
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.Flags().StringArrayVar(&writablePaths, "writable", nil, "Path where writing is allowed")
+
+	return &cmd
+}
@@ -84,7 +84,7 @@ The kernel is used to run long running processes like shells and interacting wit
 				mux := http.NewServeMux()
 				compress1KB := connect.WithCompressMinBytes(1024)
 				if enableRunner {
-					runnerService, err := runner.NewRunnerServiceHandler(logger)
+					runnerService, err := runner.NewRunnerServiceHandler(logger, languages)
 					if err != nil {
 						return err
 					}
@@ -153,7 +153,7 @@ The kernel is used to run long running processes like shells and interacting wit
 			parserv1.RegisterParserServiceServer(server, editorservice.NewParserServiceServer(logger))
 			projectv1.RegisterProjectServiceServer(server, projectservice.NewProjectServiceServer(logger))
 			if enableRunner {
-				runnerService, err := runner.NewRunnerService(logger)
+				runnerService, err := runner.NewRunnerService(logger, languages)
 				if err != nil {
 					return err
 				}
@@ -1,59 +1,153 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+
+	"github.com/stateful/runme/internal/document"
 	"github.com/stateful/runme/internal/project"
 	"github.com/stateful/runme/internal/tasks"
 )
 
 func tasksCmd() *cobra.Command {
+	var (
+		category string
+		envs     []string
+		output   string
+		merge    bool
+	)
+
 	cmd := cobra.Command{
-		Use:    "tasks",
-		Short:  "Generates task.json for VS Code editor. Caution, this is experimental.",
+		Use:   "tasks [NAME]",
+		Short: "Generates task.json for VS Code editor. Caution, this is experimental.",
+		Long: `Generates tasks.json for VS Code editor with a task for every named task in the project.
+
+Each task runs "runme run" from the directory of its document. Tasks can be
+filtered by a name, optionally prefixed with a file name, or by a category.
+
+Use --merge to add tasks to an existing tasks.json. Tasks with the same labels
+are replaced and other tasks are kept. Comments are not preserved.`,
+		Example: `runme tasks --output .vscode/tasks.json --merge
+runme tasks --category test`,
 		Hidden: true,
-		Args:   cobra.ExactArgs(1),
+		Args:   cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-		generateBlocks:
+			if merge && (output == "" || output == "-") {
+				return errors.New("--merge requires --output")
+			}
+
 			projTasks, err := getProjectTasks(cmd)
 			if err != nil {
 				return err
 			}
 
-			task, err := lookupTaskWithPrompt(cmd, args[0], projTasks)
-			if err != nil {
-				if project.IsTaskNotFoundError(err) && !fAllowUnnamed {
-					fAllowUnnamed = true
-					goto generateBlocks
+			// All tasks are passed to the generator
+			// so that labels don't depend on filters.
+			filtered := projTasks
+
+			if len(args) > 0 {
+				filtered, err = filterTasksByQuery(filtered, args[0])
+				if err != nil {
+					return err
 				}
+			}
 
-				return err
+			if category = strings.TrimSpace(category); category != "" {
+				filtered, err = project.FilterTasks(filtered, func(task project.Task) (bool, error) {
+					return hasCategory(task.CodeBlock, category), nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			included := make(map[string]bool, len(filtered))
+			for _, task := range filtered {
+				included[task.ID()] = true
 			}
 
-			tasksDef, err := tasks.GenerateFromShellCommand(
-				task.CodeBlock.Name(),
-				task.CodeBlock.Lines()[0],
-				&tasks.ShellCommandOpts{
-					Cwd: fChdir,
-				},
-			)
+			env := make(map[string]string, len(envs))
+			for _, e := range envs {
+				k, v, ok := strings.Cut(e, "=")
+				if !ok {
+					return errors.Errorf("invalid env %q; use KEY=VALUE", e)
+				}
+				env[k] = v
+			}
+			if len(env) == 0 {
+				env = nil
+			}
+
+			dir, err := filepath.Abs(fChdir)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			tasksDef, err := tasks.GenerateFromProjectTasks(projTasks, &tasks.ProjectTasksOpts{
+				Dir:     dir,
+				Env:     env,
+				Include: func(task project.Task) bool { return included[task.ID()] },
+			})
 			if err != nil {
 				return errors.Wrap(err, "failed to generate tasks.json")
 			}
 
-			encoder := json.NewEncoder(cmd.OutOrStdout())
-			encoder.SetIndent("", "  ")
-			if err := encoder.Encode(tasksDef); err != nil {
-				return errors.Wrap(err, "failed to marshal tasks.json")
+			var data []byte
+
+			if merge {
+				existing, err := os.ReadFile(output)
+				if err != nil && !os.IsNotExist(err) {
+					return errors.WithStack(err)
+				}
+				data, err = tasks.Merge(existing, tasksDef)
+				if err != nil {
+					return err
+				}
+			} else {
+				var buf bytes.Buffer
+				encoder := json.NewEncoder(&buf)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(tasksDef); err != nil {
+					return errors.Wrap(err, "failed to marshal tasks.json")
+				}
+				data = buf.Bytes()
 			}
 
-			return nil
+			if output == "" || output == "-" {
+				_, err := cmd.OutOrStdout().Write(data)
+				return errors.WithStack(err)
+			}
+
+			if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+				return errors.WithStack(err)
+			}
+			return errors.WithStack(os.WriteFile(output, data, 0o644))
 		},
 	}
 
 	setDefaultFlags(&cmd)
 
+	cmd.Flags().StringVarP(&category, "category", "c", "", "Generate tasks only from a specific category.")
+	cmd.Flags().StringArrayVar(&envs, "env", nil, "Environment variable in the form of KEY=VALUE set for every task.")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "File to write tasks.json to. By default, it is written to stdout.")
+	cmd.Flags().BoolVar(&merge, "merge", false, "Merge tasks into the existing file from --output.")
+
 	return &cmd
 }
+
+// hasCategory returns true if one of comma-separated categories
+// of block is category. Surrounding spaces are ignored.
+func hasCategory(block *document.CodeBlock, category string) bool {
+	for _, c := range strings.Split(block.Category(), ",") {
+		if strings.TrimSpace(c) == category {
+			return true
+		}
+	}
+	return false
+}
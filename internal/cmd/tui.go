@@ -43,7 +43,7 @@ func tuiCmd() *cobra.Command {
 				found := false
 
 				for _, task := range tasks {
-					if !fAllowUnknown && task.CodeBlock.IsUnknown() {
+					if !fAllowUnknown && isUnknownTask(task) {
 						continue
 					}
 
@@ -267,7 +267,7 @@ func (m *tuiModel) filterCodeBlocks() {
 	}
 
 	m.tasks, _ = project.FilterTasks(m.unfilteredTasks, func(t project.Task) (bool, error) {
-		if !m.allowUnknown && t.CodeBlock.IsUnknown() {
+		if !m.allowUnknown && isUnknownTask(t) {
 			return false, nil
 		}
 
@@ -8,6 +8,7 @@ import (
 	"slices"
 	"strings"
 
+	"github.com/pkg/errors"
 	"go.uber.org/multierr"
 )
 
@@ -18,6 +19,17 @@ type attributeParser interface {
 	Write(attr Attributes, w io.Writer) error
 }
 
+// typedAttributeParser is implemented by parsers which keep
+// types of values, like booleans, numbers, lists and objects.
+type typedAttributeParser interface {
+	// ParseTyped is like Parse, but it also returns values
+	// which are not strings with their original types.
+	ParseTyped(raw []byte) (Attributes, TypedAttributes, error)
+	// WriteTyped is like Write, but values from typed
+	// take precedence over the ones from attr.
+	WriteTyped(attr Attributes, typed TypedAttributes, w io.Writer) error
+}
+
 // Original attribute language used by runme prior to v1.3.0
 //
 // Only supports strings, and does not support spaces. Example:
@@ -122,32 +134,63 @@ func (*babikMLParser) Write(attr Attributes, w io.Writer) error {
 type jsonParser struct{}
 
 func (p *jsonParser) Parse(raw []byte) (Attributes, error) {
-	bytes := raw
+	attr, _, err := p.ParseTyped(raw)
+	return attr, err
+}
+
+// ParseTyped returns all values as strings in attr, so they can be used
+// by existing code. Values which are not strings are also returned
+// in typed. Their strings in attr are JSON-encoded values.
+func (p *jsonParser) ParseTyped(raw []byte) (Attributes, TypedAttributes, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
 
 	parsedAttr := make(map[string]interface{})
 
-	if err := json.Unmarshal(bytes, &parsedAttr); err != nil {
-		return nil, err
+	if err := decoder.Decode(&parsedAttr); err != nil {
+		return nil, nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, nil, errors.New("invalid character after top-level value")
 	}
 
 	attr := make(Attributes, len(parsedAttr))
+	var typed TypedAttributes
 
 	for k, v := range parsedAttr {
 		if strVal, ok := v.(string); ok {
 			attr[k] = strVal
-		} else {
-			if stringified, err := json.Marshal(v); err == nil {
-				attr[k] = string(stringified)
-			}
+			continue
+		}
+		v = normalizeTypedValue(v)
+		if stringified, err := json.Marshal(v); err == nil {
+			attr[k] = string(stringified)
 		}
+		if typed == nil {
+			typed = make(TypedAttributes)
+		}
+		typed[k] = v
 	}
 
-	return attr, nil
+	return attr, typed, nil
 }
 
 func (p *jsonParser) Write(attr Attributes, w io.Writer) error {
+	return p.WriteTyped(attr, nil, w)
+}
+
+func (p *jsonParser) WriteTyped(attr Attributes, typed TypedAttributes, w io.Writer) error {
+	values := make(map[string]interface{}, len(attr))
+	for k, v := range attr {
+		if typedVal, ok := typed[k]; ok {
+			values[k] = typedVal
+		} else {
+			values[k] = v
+		}
+	}
+
 	// TODO: name at front...
-	res, err := json.Marshal(attr)
+	res, err := json.Marshal(values)
 	if err != nil {
 		return err
 	}
@@ -173,23 +216,111 @@ func newFailoverAttributeParser(parsers []attributeParser, writer attributeParse
 }
 
 func (p *failoverAttributeParser) Parse(raw []byte) (attr Attributes, finalErr error) {
-	for _, parser := range p.parsers {
-		attr, err := parser.Parse(raw)
+	attr, _, _, finalErr = p.parse(raw)
+	return
+}
+
+// parse is like Parse, but it also returns typed values, if the parser
+// supports them, and warnings about attributes which were not parsed
+// by the first parser.
+func (p *failoverAttributeParser) parse(raw []byte) (attr Attributes, typed TypedAttributes, warnings []AttributeWarning, finalErr error) {
+	for idx, parser := range p.parsers {
+		var err error
+		if typedParser, ok := parser.(typedAttributeParser); ok {
+			attr, typed, err = typedParser.ParseTyped(raw)
+		} else {
+			attr, err = parser.Parse(raw)
+		}
 
 		if err == nil {
-			return attr, nil
+			if idx > 0 {
+				warnings = fallbackWarnings(raw, finalErr)
+			}
+			return attr, typed, warnings, nil
 		}
 
 		finalErr = multierr.Append(finalErr, err)
 	}
 
-	return
+	return nil, nil, nil, finalErr
+}
+
+// AttributeWarningKind is a kind of a problem with attributes.
+type AttributeWarningKind string
+
+const (
+	// AttributeWarningMalformed is reported when attributes
+	// can't be parsed, so some of them are ignored.
+	AttributeWarningMalformed AttributeWarningKind = "malformed"
+	// AttributeWarningUnknown is reported for unknown
+	// attributes which look like typos of known ones.
+	AttributeWarningUnknown AttributeWarningKind = "unknown"
+	// AttributeWarningInvalidValue is reported for values
+	// which don't match the type or allowed values.
+	AttributeWarningInvalidValue AttributeWarningKind = "invalid-value"
+	// AttributeWarningDeprecated is reported for deprecated aliases.
+	AttributeWarningDeprecated AttributeWarningKind = "deprecated"
+)
+
+// AttributeWarning describes a problem with attributes
+// of a code block which doesn't prevent running it.
+type AttributeWarning struct {
+	Kind AttributeWarningKind
+	// Attribute is a name of the attribute. It's empty
+	// if the warning is about all attributes.
+	Attribute string
+	Message   string
+}
+
+func (w AttributeWarning) String() string {
+	if w.Attribute == "" {
+		return w.Message
+	}
+	return fmt.Sprintf("attribute %q: %s", w.Attribute, w.Message)
+}
+
+// fallbackWarnings returns warnings for attributes parsed
+// with a fallback parser. Attributes which start like JSON
+// are malformed JSON. Otherwise, items which are not
+// key=value pairs are reported as they are ignored.
+func fallbackWarnings(raw []byte, err error) (result []AttributeWarning) {
+	if startsAsJSON(raw) {
+		errs := multierr.Errors(err)
+		return []AttributeWarning{{Kind: AttributeWarningMalformed, Message: fmt.Sprintf("malformed JSON attributes: %v", errs[0])}}
+	}
+
+	for _, item := range bytes.Fields(bytes.Trim(bytes.TrimSpace(raw), "{}")) {
+		if !bytes.Contains(item, []byte{'='}) {
+			result = append(result, AttributeWarning{Kind: AttributeWarningMalformed, Message: fmt.Sprintf("%q is ignored; expected key=value", item)})
+		}
+	}
+	return result
+}
+
+// startsAsJSON returns true if the first non-space
+// byte after the opening brace is a quote.
+func startsAsJSON(raw []byte) bool {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || raw[0] != '{' {
+		return false
+	}
+	raw = bytes.TrimSpace(raw[1:])
+	return len(raw) > 0 && raw[0] == '"'
 }
 
 func (p *failoverAttributeParser) Write(attr Attributes, w io.Writer) error {
 	return p.writer.Write(attr, w)
 }
 
+// WriteTyped writes attributes keeping types of values from typed,
+// if the writer supports them. Otherwise, it's the same as Write.
+func (p *failoverAttributeParser) WriteTyped(attr Attributes, typed TypedAttributes, w io.Writer) error {
+	if typedWriter, ok := p.writer.(typedAttributeParser); ok && len(typed) > 0 {
+		return typedWriter.WriteTyped(attr, typed, w)
+	}
+	return p.writer.Write(attr, w)
+}
+
 var DefaultDocumentParser = newFailoverAttributeParser(
 	[]attributeParser{
 		&jsonParser{},
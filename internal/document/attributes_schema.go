@@ -0,0 +1,243 @@
+package document
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AttributeType is a type of a value of a code block attribute.
+type AttributeType string
+
+const (
+	AttributeTypeString AttributeType = "string"
+	AttributeTypeBool   AttributeType = "bool"
+	AttributeTypeInt    AttributeType = "int"
+	// AttributeTypeList is a comma-separated list of strings
+	// or a list of strings in the JSON attribute format.
+	AttributeTypeList AttributeType = "list"
+)
+
+// AttributeSpec describes a known attribute of code blocks.
+type AttributeSpec struct {
+	Name string
+	Type AttributeType
+	// Default is a value used when the attribute
+	// is not set or its value is invalid.
+	Default string
+	// Values are allowed values. Any value
+	// of the type is allowed if it's empty.
+	Values []string
+	// Aliases are deprecated names of the attribute.
+	Aliases     []string
+	Description string
+}
+
+var attributeSchema = []AttributeSpec{
+	{Name: "name", Type: AttributeTypeString, Description: "Name of the code block used to run it."},
+	{Name: "id", Type: AttributeTypeString, Description: "Identity of the code block."},
+	{Name: "interactive", Type: AttributeTypeBool, Default: "true", Description: "Run the code block in an interactive terminal."},
+	{Name: "background", Type: AttributeTypeBool, Default: "false", Description: "Run the code block in the background."},
+	{Name: "closeTerminalOnSuccess", Type: AttributeTypeBool, Default: "true", Aliases: []string{"close_terminal_on_success"}, Description: "Close the terminal when the code block succeeds."},
+	{Name: "promptEnv", Type: AttributeTypeBool, Default: "true", Aliases: []string{"prompt_env"}, Description: "Prompt for values of exported environment variables."},
+	{Name: "excludeFromRunAll", Type: AttributeTypeBool, Default: "false", Aliases: []string{"exclude_from_run_all"}, Description: "Skip the code block when running all code blocks."},
+	{Name: "category", Type: AttributeTypeString, Description: "Category used to run a group of code blocks."},
+	{Name: "dependsOn", Type: AttributeTypeList, Aliases: []string{"depends_on"}, Description: "Names of code blocks which need to run before this one."},
+	{Name: "cwd", Type: AttributeTypeString, Description: "Working directory of the code block."},
+	{Name: "interpreter", Type: AttributeTypeString, Description: "Program which runs the code block."},
+	{Name: "output", Type: AttributeTypeString, Description: "Marks a code block which keeps an output of the preceding code block."},
+	{Name: "mimeType", Type: AttributeTypeString, Aliases: []string{"mime_type"}, Description: "MIME type of the output of the code block."},
+	{Name: "terminalRows", Type: AttributeTypeInt, Aliases: []string{"terminal_rows"}, Description: "Number of rows of the terminal."},
+	{Name: "template", Type: AttributeTypeBool, Default: "false", Description: "Use the code block as a template for other code blocks."},
+	{Name: "use", Type: AttributeTypeString, Description: "Name of a template to expand."},
+	{Name: "sandbox", Type: AttributeTypeString, Values: []string{"false", "off", "0", "true", "on", "1", "offline"}, Description: "Restrict writes and network access of the code block."},
+	{Name: "capture", Type: AttributeTypeString, Description: "Session variable which captures the response of an HTTP code block."},
+	{Name: "db", Type: AttributeTypeString, Description: "Database of an SQL code block."},
+	{Name: "format", Type: AttributeTypeString, Values: []string{"table", "json", "csv"}, Description: "Output format of an SQL code block."},
+	{Name: "goVersion", Type: AttributeTypeString, Aliases: []string{"go_version"}, Description: "Go version used to build a Go code block."},
+	{Name: "toolchain", Type: AttributeTypeString, Description: "Toolchain used to build a compiled code block."},
+	{Name: "wrapMain", Type: AttributeTypeBool, Default: "false", Description: "Wrap a C or C++ code block in the main function."},
+	{Name: "cpuLimit", Type: AttributeTypeString, Description: "Limit of CPU time of the code block, for example, 90s or a number of seconds."},
+	{Name: "memoryLimit", Type: AttributeTypeString, Description: "Limit of memory of the code block, for example, 512MiB or a number of bytes."},
+	{Name: "openFilesLimit", Type: AttributeTypeInt, Description: "Limit of open files of the code block."},
+	{Name: "processLimit", Type: AttributeTypeInt, Description: "Limit of processes of the code block."},
+}
+
+var attributeSpecs = func() map[string]*AttributeSpec {
+	result := make(map[string]*AttributeSpec, len(attributeSchema))
+	for i := range attributeSchema {
+		spec := &attributeSchema[i]
+		result[spec.Name] = spec
+		for _, alias := range spec.Aliases {
+			result[alias] = spec
+		}
+	}
+	return result
+}()
+
+// KnownAttributes returns specs of all known attributes.
+func KnownAttributes() []AttributeSpec {
+	result := make([]AttributeSpec, len(attributeSchema))
+	copy(result, attributeSchema)
+	return result
+}
+
+// LookupAttribute returns a spec of the attribute
+// by its name or any of its deprecated aliases.
+func LookupAttribute(name string) (AttributeSpec, bool) {
+	spec, ok := attributeSpecs[name]
+	if !ok {
+		return AttributeSpec{}, false
+	}
+	return *spec, true
+}
+
+// Get returns a value of the attribute. Values set
+// with deprecated aliases are returned as well.
+func (a Attributes) Get(name string) string {
+	value, _ := a.Lookup(name)
+	return value
+}
+
+// Lookup is like Get, but it also reports whether the attribute is set.
+func (a Attributes) Lookup(name string) (string, bool) {
+	if value, ok := a[name]; ok {
+		return value, true
+	}
+	if spec, ok := attributeSpecs[name]; ok {
+		for _, alias := range spec.Aliases {
+			if value, ok := a[alias]; ok {
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Bool returns a boolean value of the attribute. The default
+// value from the schema is returned if the value is invalid.
+func (a Attributes) Bool(name string) bool {
+	value, err := strconv.ParseBool(a.Get(name))
+	if err != nil {
+		if spec, ok := attributeSpecs[name]; ok {
+			value, _ = strconv.ParseBool(spec.Default)
+		}
+	}
+	return value
+}
+
+// List returns values of a comma-separated list attribute.
+func (a Attributes) List(name string) (result []string) {
+	for _, item := range strings.Split(a.Get(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// ValidateAttributes checks attributes against the schema. It reports
+// values of a wrong type, deprecated aliases, and unknown attributes
+// which look like typos of known ones. Other unknown attributes are
+// allowed as they can be used by other tools. Attributes with
+// a prefix, like "runme.dev/id", are not checked.
+func ValidateAttributes(attr Attributes) (result []AttributeWarning) {
+	for _, name := range sortedAttrs(attr) {
+		if strings.Contains(name, "/") {
+			continue
+		}
+
+		spec, ok := attributeSpecs[name]
+		if !ok {
+			if suggestion := suggestAttribute(name); suggestion != "" {
+				result = append(result, AttributeWarning{
+					Kind:      AttributeWarningUnknown,
+					Attribute: name,
+					Message:   fmt.Sprintf("unknown attribute; did you mean %q?", suggestion),
+				})
+			}
+			continue
+		}
+
+		if name != spec.Name {
+			result = append(result, AttributeWarning{
+				Kind:      AttributeWarningDeprecated,
+				Attribute: name,
+				Message:   fmt.Sprintf("deprecated; use %q instead", spec.Name),
+			})
+		}
+
+		if message := validateAttributeValue(spec, attr[name]); message != "" {
+			result = append(result, AttributeWarning{
+				Kind:      AttributeWarningInvalidValue,
+				Attribute: name,
+				Message:   message,
+			})
+		}
+	}
+	return result
+}
+
+func validateAttributeValue(spec *AttributeSpec, value string) string {
+	switch spec.Type {
+	case AttributeTypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Sprintf("invalid value %q; expected true or false", value)
+		}
+	case AttributeTypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Sprintf("invalid value %q; expected an integer", value)
+		}
+	}
+
+	if len(spec.Values) > 0 && value != "" {
+		for _, allowed := range spec.Values {
+			if strings.EqualFold(value, allowed) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("invalid value %q; expected one of %s", value, strings.Join(spec.Values, ", "))
+	}
+
+	return ""
+}
+
+// suggestAttribute returns a known attribute which
+// is the closest to the name, if it's close enough.
+func suggestAttribute(name string) string {
+	lower := strings.ToLower(name)
+
+	best, bestDistance := "", 3
+	for _, spec := range attributeSchema {
+		if d := editDistance(lower, strings.ToLower(spec.Name)); d < bestDistance {
+			best, bestDistance = spec.Name, d
+		}
+	}
+
+	// Short names are too similar to each other.
+	if bestDistance > 0 && len(name) < 5 {
+		return ""
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
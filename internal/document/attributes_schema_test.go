@@ -0,0 +1,127 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAttributes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		attr     Attributes
+		expected []AttributeWarning
+	}{
+		{
+			name: "Valid",
+			attr: Attributes{"name": "echo", "interactive": "false", "terminalRows": "20", "format": "JSON", "runme.dev/id": "x"},
+		},
+		{
+			name: "Typo",
+			attr: Attributes{"interative": "false"},
+			expected: []AttributeWarning{
+				{Kind: AttributeWarningUnknown, Attribute: "interative", Message: `unknown attribute; did you mean "interactive"?`},
+			},
+		},
+		{
+			name: "WrongCase",
+			attr: Attributes{"excludefromrunall": "true"},
+			expected: []AttributeWarning{
+				{Kind: AttributeWarningUnknown, Attribute: "excludefromrunall", Message: `unknown attribute; did you mean "excludeFromRunAll"?`},
+			},
+		},
+		{
+			name: "CustomAttribute",
+			attr: Attributes{"owner": "platform-team"},
+		},
+		{
+			name: "InvalidBool",
+			attr: Attributes{"background": "yes"},
+			expected: []AttributeWarning{
+				{Kind: AttributeWarningInvalidValue, Attribute: "background", Message: `invalid value "yes"; expected true or false`},
+			},
+		},
+		{
+			name: "InvalidInt",
+			attr: Attributes{"terminalRows": "many"},
+			expected: []AttributeWarning{
+				{Kind: AttributeWarningInvalidValue, Attribute: "terminalRows", Message: `invalid value "many"; expected an integer`},
+			},
+		},
+		{
+			name: "NotAllowedValue",
+			attr: Attributes{"sandbox": "strict"},
+			expected: []AttributeWarning{
+				{Kind: AttributeWarningInvalidValue, Attribute: "sandbox", Message: `invalid value "strict"; expected one of false, off, 0, true, on, 1, offline`},
+			},
+		},
+		{
+			name: "ResourceLimits",
+			attr: Attributes{"cpuLimit": "90s", "memoryLimit": "512MiB", "openFilesLimit": "64", "processLimit": "many"},
+			expected: []AttributeWarning{
+				{Kind: AttributeWarningInvalidValue, Attribute: "processLimit", Message: `invalid value "many"; expected an integer`},
+			},
+		},
+		{
+			name: "DeprecatedAlias",
+			attr: Attributes{"depends_on": "build"},
+			expected: []AttributeWarning{
+				{Kind: AttributeWarningDeprecated, Attribute: "depends_on", Message: `deprecated; use "dependsOn" instead`},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ValidateAttributes(tc.attr))
+		})
+	}
+}
+
+func TestAttributes_Accessors(t *testing.T) {
+	attr := Attributes{
+		"background": "invalid",
+		"prompt_env": "false",
+		"depends_on": "build, test,",
+	}
+
+	assert.False(t, attr.Bool("background"))
+	assert.True(t, attr.Bool("interactive"))
+	assert.False(t, attr.Bool("promptEnv"))
+	assert.Equal(t, "false", attr.Get("promptEnv"))
+	assert.Equal(t, []string{"build", "test"}, attr.List("dependsOn"))
+	assert.Equal(t, "", attr.Get("unknown"))
+}
+
+func TestLookupAttribute(t *testing.T) {
+	spec, ok := LookupAttribute("openFilesLimit")
+	require.True(t, ok)
+	assert.Equal(t, AttributeTypeInt, spec.Type)
+
+	spec, ok = LookupAttribute("go_version")
+	require.True(t, ok)
+	assert.Equal(t, "goVersion", spec.Name)
+
+	_, ok = LookupAttribute("unknown")
+	assert.False(t, ok)
+}
+
+func TestCodeBlock_AttributeWarnings(t *testing.T) {
+	data := []byte("# Title\n\n```sh {name=echo interative=false terminalRows=x}\necho hello\n```\n")
+
+	doc := New(data, identityResolver)
+	node, err := doc.Root()
+	require.NoError(t, err)
+
+	blocks := CollectCodeBlocks(node)
+	require.Len(t, blocks, 1)
+
+	warnings := blocks[0].AttributeWarnings()
+	require.Len(t, warnings, 2)
+	assert.Equal(t, "interative", warnings[0].Attribute)
+	assert.Equal(t, AttributeWarningUnknown, warnings[0].Kind)
+	assert.Equal(t, "terminalRows", warnings[1].Attribute)
+	assert.Equal(t, AttributeWarningInvalidValue, warnings[1].Kind)
+	assert.True(t, blocks[0].Interactive())
+}
@@ -155,4 +155,29 @@ func Test_attributes(t *testing.T) {
 			assert.Equal(t, "{\"float\":\"13.3\",\"key\":\"value\",\"val\":\"20\"}", serialized.String())
 		}
 	})
+	t.Run("failoverAttributesParserWarnings", func(t *testing.T) {
+		parser := DefaultDocumentParser
+
+		_, _, warnings, err := parser.parse([]byte(`{"name":"echo"}`))
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+
+		_, _, warnings, err = parser.parse([]byte("{ name=echo }"))
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+
+		_, _, warnings, err = parser.parse([]byte("{ name=pull image=nginx:latest }"))
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+
+		_, _, warnings, err = parser.parse([]byte(`{"name":"echo",}`))
+		require.NoError(t, err)
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0].String(), "malformed JSON attributes")
+
+		attr, _, warnings, err := parser.parse([]byte("{ name=echo interactive }"))
+		require.NoError(t, err)
+		assert.Equal(t, Attributes{"name": "echo"}, attr)
+		assert.Equal(t, []AttributeWarning{{Kind: AttributeWarningMalformed, Message: `"interactive" is ignored; expected key=value`}}, warnings)
+	})
 }
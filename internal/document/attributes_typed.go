@@ -0,0 +1,73 @@
+package document
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// TypedAttributes are attributes with values which are not strings.
+// Values are bool, int64, float64, []any or map[string]any. They are
+// available only for attributes in the JSON format, for example,
+// {"matrix":{"os":["linux","darwin"]},"dependsOn":["build","test"]}.
+type TypedAttributes map[string]any
+
+// Get returns a typed value of the attribute. Values set
+// with deprecated aliases are returned as well.
+func (t TypedAttributes) Get(name string) (any, bool) {
+	if value, ok := t[name]; ok {
+		return value, true
+	}
+	if spec, ok := attributeSpecs[name]; ok {
+		for _, alias := range spec.Aliases {
+			if value, ok := t[alias]; ok {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// normalizeTypedValue converts json.Number to int64, if it's
+// an integer, or float64. Nested values are converted as well.
+func normalizeTypedValue(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return i
+		}
+		f, _ := strconv.ParseFloat(string(v), 64)
+		return f
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeTypedValue(item)
+		}
+		return v
+	case map[string]any:
+		for key, item := range v {
+			v[key] = normalizeTypedValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// WriteAttributes writes attributes in the default format. Values from
+// typed take precedence over the ones from attr, so they keep their types.
+func WriteAttributes(attr Attributes, typed TypedAttributes, w io.Writer) error {
+	return DefaultDocumentParser.WriteTyped(attr, typed, w)
+}
+
+// TypedAttributes returns attributes which are not strings
+// with their original types. It's nil if there are none.
+func (b *CodeBlock) TypedAttributes() TypedAttributes { return b.typedAttributes }
+
+// AttributeValue returns a value of the attribute with its original
+// type. It's a string if the attribute is not typed.
+func (b *CodeBlock) AttributeValue(name string) (any, bool) {
+	if value, ok := b.typedAttributes.Get(name); ok {
+		return value, true
+	}
+	return b.attributes.Lookup(name)
+}
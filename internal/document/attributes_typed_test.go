@@ -0,0 +1,82 @@
+package document
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONParser_Typed(t *testing.T) {
+	parser := &jsonParser{}
+
+	src := []byte(`{"name":"test","interactive":false,"retries":3,"timeout":1.5,"dependsOn":["build","lint"],"matrix":{"os":["linux","darwin"],"go":[1.21]}}`)
+
+	attr, typed, err := parser.ParseTyped(src)
+	require.NoError(t, err)
+
+	assert.Equal(t, Attributes{
+		"name":        "test",
+		"interactive": "false",
+		"retries":     "3",
+		"timeout":     "1.5",
+		"dependsOn":   `["build","lint"]`,
+		"matrix":      `{"go":[1.21],"os":["linux","darwin"]}`,
+	}, attr)
+
+	assert.Equal(t, TypedAttributes{
+		"interactive": false,
+		"retries":     int64(3),
+		"timeout":     1.5,
+		"dependsOn":   []any{"build", "lint"},
+		"matrix": map[string]any{
+			"os": []any{"linux", "darwin"},
+			"go": []any{1.21},
+		},
+	}, typed)
+
+	w := bytes.NewBuffer(nil)
+	require.NoError(t, parser.WriteTyped(attr, typed, w))
+	assert.Equal(t, `{"dependsOn":["build","lint"],"interactive":false,"matrix":{"go":[1.21],"os":["linux","darwin"]},"name":"test","retries":3,"timeout":1.5}`, w.String())
+
+	// Without typed values, all values are written as strings.
+	w.Reset()
+	require.NoError(t, parser.Write(attr, w))
+	assert.Equal(t, `{"dependsOn":"[\"build\",\"lint\"]","interactive":"false","matrix":"{\"go\":[1.21],\"os\":[\"linux\",\"darwin\"]}","name":"test","retries":"3","timeout":"1.5"}`, w.String())
+}
+
+func TestJSONParser_TrailingData(t *testing.T) {
+	_, _, err := (&jsonParser{}).ParseTyped([]byte(`{"name":"test"} extra`))
+	assert.Error(t, err)
+}
+
+func TestCodeBlock_TypedAttributes(t *testing.T) {
+	data := []byte("# Title\n\n```sh {\"name\":\"deploy\",\"dependsOn\":[\"build\",\"test\"],\"matrix\":{\"env\":[\"staging\",\"prod\"]},\"interactive\":false}\necho deploy\n```\n\n```sh {name=build dependsOn=lint,test}\necho build\n```\n")
+
+	doc := New(data, identityResolver)
+	node, err := doc.Root()
+	require.NoError(t, err)
+
+	blocks := CollectCodeBlocks(node)
+	require.Len(t, blocks, 2)
+
+	deploy := blocks[0]
+	assert.Equal(t, []string{"build", "test"}, deploy.DependsOn())
+	assert.False(t, deploy.Interactive())
+
+	matrix, ok := deploy.AttributeValue("matrix")
+	require.True(t, ok)
+	assert.Equal(t, map[string]any{"env": []any{"staging", "prod"}}, matrix)
+
+	name, ok := deploy.AttributeValue("name")
+	require.True(t, ok)
+	assert.Equal(t, "deploy", name)
+
+	_, ok = deploy.AttributeValue("missing")
+	assert.False(t, ok)
+
+	build := blocks[1]
+	assert.Nil(t, build.TypedAttributes())
+	assert.Equal(t, []string{"lint", "test"}, build.DependsOn())
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"math"
 	"regexp"
-	"strconv"
 	"strings"
 	"unicode"
 
@@ -51,17 +50,21 @@ func (b CodeBlocks) Names() (result []string) {
 type Renderer func(ast.Node, []byte) ([]byte, error)
 
 type CodeBlock struct {
-	id            string
-	idGenerated   bool
-	attributes    map[string]string
-	document      *Document
-	inner         *ast.FencedCodeBlock
-	intro         string
-	language      string
-	lines         []string
-	name          string
-	nameGenerated bool
-	value         []byte
+	id           string
+	idGenerated  bool
+	attributes   Attributes
+	attrWarnings []AttributeWarning
+	// typedAttributes are attributes which are not strings.
+	typedAttributes TypedAttributes
+	document        *Document
+	inner           *ast.FencedCodeBlock
+	fence           codeFence
+	intro           string
+	language        string
+	lines           []string
+	name            string
+	nameGenerated   bool
+	value           []byte
 }
 
 func newCodeBlock(
@@ -72,14 +75,14 @@ func newCodeBlock(
 	source []byte,
 	render Renderer,
 ) (*CodeBlock, error) {
-	attributes, err := getAttributes(node, source, DefaultDocumentParser)
+	attributes, typedAttributes, attributeWarnings, err := getAttributes(node, source, DefaultDocumentParser)
 	if err != nil {
 		return nil, err
 	}
 
 	id, hasID := identityResolver.GetCellID(node, attributes)
 
-	name, hasName := getName(node, source, nameResolver, attributes)
+	name, hasName := getName(node, source, nameResolver, attributes, document.lastHeading)
 
 	value, err := render(node, source)
 	if err != nil {
@@ -87,26 +90,37 @@ func newCodeBlock(
 	}
 
 	return &CodeBlock{
-		id:            id,
-		idGenerated:   !hasID,
-		attributes:    attributes,
-		document:      document,
-		inner:         node,
-		intro:         getIntro(node, source),
-		language:      getLanguage(node, source),
-		lines:         getLines(node, source),
-		name:          name,
-		nameGenerated: !hasName,
-		value:         value,
+		id:              id,
+		idGenerated:     !hasID,
+		attributes:      attributes,
+		attrWarnings:    attributeWarnings,
+		document:        document,
+		typedAttributes: typedAttributes,
+		inner:           node,
+		fence:           getCodeFence(node, source),
+		intro:           getIntro(node, source),
+		language:        getLanguage(node, source),
+		lines:           getLines(node, source),
+		name:            name,
+		nameGenerated:   !hasName,
+		value:           value,
 	}, nil
 }
 
 func (b *CodeBlock) Clone() *CodeBlock {
-	attributes := make(map[string]string, len(b.attributes))
+	attributes := make(Attributes, len(b.attributes))
 	for key, value := range b.attributes {
 		attributes[key] = value
 	}
 
+	var typedAttributes TypedAttributes
+	if b.typedAttributes != nil {
+		typedAttributes = make(TypedAttributes, len(b.typedAttributes))
+		for key, value := range b.typedAttributes {
+			typedAttributes[key] = value
+		}
+	}
+
 	lines := make([]string, len(b.lines))
 	copy(lines, b.lines)
 
@@ -114,34 +128,34 @@ func (b *CodeBlock) Clone() *CodeBlock {
 	copy(value, b.value)
 
 	return &CodeBlock{
-		id:            b.id,
-		idGenerated:   b.idGenerated,
-		attributes:    attributes,
-		intro:         b.intro,
-		language:      b.language,
-		lines:         lines,
-		name:          b.name,
-		nameGenerated: b.nameGenerated,
-		value:         value,
+		id:              b.id,
+		idGenerated:     b.idGenerated,
+		attributes:      attributes,
+		attrWarnings:    b.attrWarnings,
+		typedAttributes: typedAttributes,
+		fence:           b.fence,
+		intro:           b.intro,
+		language:        b.language,
+		lines:           lines,
+		name:            b.name,
+		nameGenerated:   b.nameGenerated,
+		value:           value,
 	}
 }
 
-func (b *CodeBlock) Attributes() map[string]string { return b.attributes }
+func (b *CodeBlock) Attributes() Attributes { return b.attributes }
+
+// AttributeWarnings returns problems found while parsing attributes.
+func (b *CodeBlock) AttributeWarnings() []AttributeWarning { return b.attrWarnings }
 
 func (b *CodeBlock) Document() *Document { return b.document }
 
 func (b *CodeBlock) Interactive() bool {
-	val, err := strconv.ParseBool(b.Attributes()["interactive"])
-	if err != nil {
-		return true
-	}
-
-	return val
+	return b.attributes.Bool("interactive")
 }
 
 func (b *CodeBlock) Background() bool {
-	val, _ := strconv.ParseBool(b.Attributes()["background"])
-	return val
+	return b.attributes.Bool("background")
 }
 
 func (CodeBlock) Kind() BlockKind { return CodeBlockKind }
@@ -155,6 +169,19 @@ func (b *CodeBlock) Content() []byte {
 	return bytes.Join(lines[1:len(lines)-1], []byte{'\n'})
 }
 
+// Fence returns the opening fence of the code block,
+// for example, "```" or "~~~".
+func (b *CodeBlock) Fence() string {
+	return b.fence.fence
+}
+
+// LinePrefixes returns the text before the opening fence and
+// before the following lines of the code block nested in block
+// quotes or list items, for example, "1. " and "   ".
+func (b *CodeBlock) LinePrefixes() (firstLinePrefix, linePrefix string) {
+	return b.fence.firstLinePrefix, b.fence.linePrefix
+}
+
 func (b *CodeBlock) Intro() string {
 	return b.intro
 }
@@ -196,33 +223,41 @@ func (b *CodeBlock) SetLine(p int, v string) {
 }
 
 func (b *CodeBlock) Category() string {
-	return b.Attributes()["category"]
+	return b.attributes.Get("category")
+}
+
+// DependsOn returns names of code blocks which
+// need to run before this one.
+func (b *CodeBlock) DependsOn() []string {
+	// It can be a JSON list, for example, {"dependsOn":["build","test"]}.
+	if value, ok := b.typedAttributes.Get("dependsOn"); ok {
+		if items, ok := value.([]any); ok {
+			result := make([]string, 0, len(items))
+			for _, item := range items {
+				if name, ok := item.(string); ok && name != "" {
+					result = append(result, name)
+				}
+			}
+			return result
+		}
+	}
+	return b.attributes.List("dependsOn")
 }
 
 func (b *CodeBlock) Cwd() string {
-	return b.Attributes()["cwd"]
+	return b.attributes.Get("cwd")
 }
 
 func (b *CodeBlock) Interpreter() string {
-	return b.Attributes()["interpreter"]
+	return b.attributes.Get("interpreter")
 }
 
 func (b *CodeBlock) PromptEnv() bool {
-	val, err := strconv.ParseBool(b.Attributes()["promptEnv"])
-	if err != nil {
-		return true
-	}
-
-	return val
+	return b.attributes.Bool("promptEnv")
 }
 
 func (b *CodeBlock) ExcludeFromRunAll() bool {
-	val, err := strconv.ParseBool(b.Attributes()["excludeFromRunAll"])
-	if err != nil {
-		return false
-	}
-
-	return val
+	return b.attributes.Bool("excludeFromRunAll")
 }
 
 type TextRange struct {
@@ -259,29 +294,69 @@ func rawAttributes(source []byte) []byte {
 	}
 
 	if start >= 0 && stop >= 0 {
+		// JSON attributes can contain nested objects,
+		// for example, {"matrix":{"os":["linux"]}}.
+		if end := matchingBrace(source, start-1); end > stop {
+			stop = end
+		}
 		return bytes.TrimSpace(source[start-1 : stop+1])
 	}
 
 	return nil
 }
 
-func getAttributes(node *ast.FencedCodeBlock, source []byte, parser attributeParser) (Attributes, error) {
+// matchingBrace returns an index of the brace closing the one
+// at start. Braces in JSON strings are skipped. It returns -1
+// if the brace is not closed.
+func matchingBrace(source []byte, start int) int {
+	depth := 0
+	inString := false
+
+	for i := start; i < len(source); i++ {
+		c := source[i]
+		switch {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func getAttributes(node *ast.FencedCodeBlock, source []byte, parser *failoverAttributeParser) (Attributes, TypedAttributes, []AttributeWarning, error) {
 	attributes := make(map[string]string)
 
+	var (
+		typed    TypedAttributes
+		warnings []AttributeWarning
+	)
+
 	if node.Info != nil {
 		codeBlockInfo := node.Info.Text(source)
 		rawAttrs := rawAttributes(codeBlockInfo)
 
 		if len(bytes.TrimSpace(rawAttrs)) > 0 {
-			attr, err := parser.Parse(rawAttrs)
+			attr, typedAttr, attrWarnings, err := parser.parse(rawAttrs)
 			if err != nil {
-				return nil, err
+				return nil, nil, nil, err
 			}
 
 			attributes = attr
+			typed = typedAttr
+			warnings = append(attrWarnings, ValidateAttributes(attr)...)
 		}
 	}
-	return attributes, nil
+	return attributes, typed, warnings, nil
 }
 
 // TODO(mxs): use guesslang model
@@ -298,6 +373,105 @@ func normalizeIntro(s string) string {
 	return replaceEndingRe.ReplaceAllString(s, ".")
 }
 
+const defaultFence = "```"
+
+// codeFence describes how a fenced code block is written in the source.
+type codeFence struct {
+	// fence is the opening fence, for example, "```" or "~~~~".
+	fence string
+	// firstLinePrefix is the text before the opening fence,
+	// for example, "1. " in a list item or "> " in a block quote.
+	firstLinePrefix string
+	// linePrefix is the text before the following lines.
+	linePrefix string
+}
+
+func getCodeFence(node *ast.FencedCodeBlock, source []byte) codeFence {
+	result := codeFence{fence: defaultFence}
+
+	// The opening fence is on the line with the info string or,
+	// if there is none, on the line before the first line of code.
+	var openingStart, openingEnd int
+	switch {
+	case node.Info != nil:
+		openingEnd = node.Info.Segment.Start
+	case node.Lines().Len() > 0:
+		openingEnd = lineStart(source, node.Lines().At(0).Start) - 1
+		if openingEnd < 0 {
+			return result
+		}
+	default:
+		return result
+	}
+	openingStart = lineStart(source, openingEnd)
+
+	prefix, fence, ok := splitFenceLine(source[openingStart:openingEnd], 0, 0)
+	if !ok {
+		return result
+	}
+	result.fence = string(fence)
+	result.firstLinePrefix = string(prefix)
+	result.linePrefix = blankMarkers(result.firstLinePrefix)
+
+	// The closing fence is on the line after the last line of code.
+	closingStart := openingEnd
+	if n := node.Lines().Len(); n > 0 {
+		closingStart = node.Lines().At(n - 1).Start
+	}
+	if idx := bytes.IndexByte(source[closingStart:], '\n'); idx != -1 {
+		closingStart += idx + 1
+		closingEnd := len(source)
+		if idx := bytes.IndexByte(source[closingStart:], '\n'); idx != -1 {
+			closingEnd = closingStart + idx
+		}
+		prefix, _, ok := splitFenceLine(source[closingStart:closingEnd], fence[0], len(fence))
+		if ok && len(bytes.Trim(prefix, " \t>")) == 0 {
+			result.linePrefix = string(prefix)
+		}
+	}
+
+	return result
+}
+
+// splitFenceLine splits a line ending with a fence into the text before
+// the fence and the fence. If c is not zero, the fence must consist of c
+// and be at least minLen long.
+func splitFenceLine(line []byte, c byte, minLen int) (prefix, fence []byte, ok bool) {
+	line = bytes.TrimRight(line, " \t\r")
+	if len(line) == 0 {
+		return nil, nil, false
+	}
+	if c == 0 {
+		c = line[len(line)-1]
+	}
+	if c != '`' && c != '~' {
+		return nil, nil, false
+	}
+	i := len(line)
+	for i > 0 && line[i-1] == c {
+		i--
+	}
+	if len(line)-i < max(3, minLen) {
+		return nil, nil, false
+	}
+	return line[:i], line[i:], true
+}
+
+// blankMarkers replaces list item markers with spaces,
+// so "> 1. " becomes ">    ".
+func blankMarkers(prefix string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '>' || unicode.IsSpace(r) {
+			return r
+		}
+		return ' '
+	}, prefix)
+}
+
+func lineStart(source []byte, pos int) int {
+	return bytes.LastIndexByte(source[:pos], '\n') + 1
+}
+
 func getIntro(node *ast.FencedCodeBlock, source []byte) string {
 	if prevNode := node.PreviousSibling(); prevNode != nil && prevNode.Kind() == ast.KindParagraph {
 		return normalizeIntro(string(prevNode.Text(source)))
@@ -348,13 +522,15 @@ func sanitizeName(s string) string {
 	return b.String()
 }
 
-func getName(node *ast.FencedCodeBlock, source []byte, nameResolver *nameResolver, attributes Attributes) (string, bool) {
+func getName(node *ast.FencedCodeBlock, source []byte, nameResolver *nameResolver, attributes Attributes, heading string) (string, bool) {
 	hasName := false
 
 	var name string
 	if n, ok := attributes["name"]; ok && n != "" {
 		name = n
 		hasName = true
+	} else if heading != "" {
+		name = heading
 	} else {
 		lines := getLines(node, source)
 		if len(lines) > 0 {
@@ -6,7 +6,6 @@ import (
 	"sync"
 
 	"github.com/pkg/errors"
-	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/text"
@@ -22,6 +21,8 @@ type Document struct {
 	source           []byte
 	identityResolver *identity.IdentityResolver
 	nameResolver     *nameResolver
+	namingStrategy   NamingStrategy
+	path             string // set for imported documents
 	parser           parser.Parser
 	renderer         Renderer
 
@@ -39,6 +40,12 @@ type Document struct {
 	onceParseFrontmatter sync.Once
 	parseFrontmatterErr  error
 	frontmatter          *Frontmatter
+
+	// nameByHeading is true when the heading naming strategy is used.
+	// lastHeading is then a name derived from the last heading visited
+	// while building the blocks tree.
+	nameByHeading bool
+	lastHeading   string
 }
 
 func New(source []byte, identityResolver *identity.IdentityResolver) *Document {
@@ -49,7 +56,7 @@ func New(source []byte, identityResolver *identity.IdentityResolver) *Document {
 			namesCounter: map[string]int{},
 			cache:        map[interface{}]string{},
 		},
-		parser:               goldmark.DefaultParser(),
+		parser:               newParser(false),
 		renderer:             DefaultRenderer,
 		onceParse:            sync.Once{},
 		onceSplitSource:      sync.Once{},
@@ -58,6 +65,12 @@ func New(source []byte, identityResolver *identity.IdentityResolver) *Document {
 	}
 }
 
+// Path returns the absolute path of an imported document.
+// It's empty for documents which were not imported.
+func (d *Document) Path() string {
+	return d.path
+}
+
 func (d *Document) Content() []byte {
 	return d.content
 }
@@ -137,6 +150,8 @@ func (d *Document) parse() {
 	d.onceParse.Do(func() {
 		d.rootASTNode = d.parser.Parse(text.NewReader(d.content))
 
+		d.nameByHeading = d.resolveNamingStrategy() == NamingStrategyHeading
+
 		node := &Node{}
 		if err := d.buildBlocksTree(d.rootASTNode, node); err != nil {
 			d.parseErr = err
@@ -155,6 +170,10 @@ func (d *Document) parse() {
 
 func (d *Document) buildBlocksTree(parent ast.Node, node *Node) error {
 	for astNode := parent.FirstChild(); astNode != nil; astNode = astNode.NextSibling() {
+		if heading, ok := astNode.(*ast.Heading); ok && d.nameByHeading {
+			d.lastHeading = headingName(string(heading.Text(d.content)))
+		}
+
 		switch astNode.Kind() {
 		case ast.KindFencedCodeBlock:
 			block, err := newCodeBlock(
@@ -237,3 +237,54 @@ func TestDocument_TrailingLineBreaks(t *testing.T) {
 		assert.Equal(t, 7, doc.TrailingLineBreaksCount())
 	})
 }
+
+func TestDocument_NestedCodeBlocks(t *testing.T) {
+	data := []byte(`1. Install:
+
+   ` + "```" + `sh {"name":"install"}
+   echo install
+   ` + "```" + `
+
+2. Configure
+   - nested item
+
+     ~~~bash {"name":"configure"}
+     echo configure
+     ~~~
+
+> ` + "````" + `sh
+> echo quoted
+> ` + "````" + `
+
+- ` + "```" + `sh
+  echo marker
+  ` + "```" + `
+`)
+	doc := New(data, identityResolver)
+	node, err := doc.Root()
+	require.NoError(t, err)
+
+	blocks := CollectCodeBlocks(node)
+	require.Len(t, blocks, 4)
+
+	assert.Equal(t, []string{"install", "configure", "echo-quoted", "echo-marker"}, blocks.Names())
+
+	type prefixes struct {
+		fence, first, rest string
+	}
+	var actual []prefixes
+	for _, block := range blocks {
+		first, rest := block.LinePrefixes()
+		actual = append(actual, prefixes{block.Fence(), first, rest})
+	}
+	assert.Equal(
+		t,
+		[]prefixes{
+			{"```", "   ", "   "},
+			{"~~~", "     ", "     "},
+			{"````", "> ", "> "},
+			{"```", "- ", "  "},
+		},
+		actual,
+	)
+}
@@ -8,7 +8,6 @@ import (
 	"log"
 	"os"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
@@ -44,6 +43,12 @@ type Cell struct {
 	Outputs          []*CellOutput         `json:"outputs,omitempty"`
 	TextRange        *TextRange            `json:"textRange,omitempty"`
 	ExecutionSummary *CellExecutionSummary `json:"executionSummary,omitempty"`
+	// TypedMetadata are values of metadata which are not strings,
+	// like booleans, numbers, lists and objects. Metadata contains
+	// their JSON encodings. They are serialized with their types.
+	TypedMetadata map[string]any `json:"typedMetadata,omitempty"`
+
+	attrWarnings []document.AttributeWarning
 }
 
 type CellExecutionSummary struct {
@@ -86,6 +91,31 @@ type Notebook struct {
 	Cells       []*Cell               `json:"cells"`
 	Metadata    map[string]string     `json:"metadata,omitempty"`
 	Frontmatter *document.Frontmatter `json:"frontmatter,omitempty"`
+	// Warnings are problems with attributes of code cells
+	// found during deserialization.
+	Warnings []AttributeWarning `json:"warnings,omitempty"`
+}
+
+// AttributeWarning is a problem with attributes of a code cell.
+type AttributeWarning struct {
+	CellIndex int                           `json:"cellIndex"`
+	Attribute string                        `json:"attribute,omitempty"`
+	Kind      document.AttributeWarningKind `json:"kind"`
+	Message   string                        `json:"message"`
+}
+
+func collectAttributeWarnings(cells []*Cell) (result []AttributeWarning) {
+	for idx, cell := range cells {
+		for _, w := range cell.attrWarnings {
+			result = append(result, AttributeWarning{
+				CellIndex: idx,
+				Attribute: w.Attribute,
+				Kind:      w.Kind,
+				Message:   w.Message,
+			})
+		}
+	}
+	return result
 }
 
 // This mimics what otherwise would happen in the extension
@@ -123,10 +153,10 @@ func toCellsRec(
 					return n.Item().Kind() == document.CodeBlockKind
 				})
 				if nodeWithCode == nil {
-					*cells = append(*cells, &Cell{
+					*cells = append(*cells, withLinePrefixes(&Cell{
 						Kind:  MarkupKind,
 						Value: fmtValue(block.Value()),
-					})
+					}, block.Unwrap()))
 				} else {
 					for _, listItemNode := range child.Children() {
 						nodeWithCode := document.FindNode(listItemNode, func(n *document.Node) bool {
@@ -135,10 +165,10 @@ func toCellsRec(
 						if nodeWithCode != nil {
 							toCellsRec(doc, listItemNode, cells, source)
 						} else {
-							*cells = append(*cells, &Cell{
+							*cells = append(*cells, withLinePrefixes(&Cell{
 								Kind:  MarkupKind,
 								Value: fmtValue(listItemNode.Item().Value()),
-							})
+							}, listItemNode.Item().Unwrap()))
 						}
 					}
 				}
@@ -150,10 +180,10 @@ func toCellsRec(
 				if nodeWithCode != nil {
 					toCellsRec(doc, child, cells, source)
 				} else {
-					*cells = append(*cells, &Cell{
+					*cells = append(*cells, withLinePrefixes(&Cell{
 						Kind:  MarkupKind,
 						Value: fmtValue(block.Value()),
-					})
+					}, block.Unwrap()))
 				}
 			}
 
@@ -167,7 +197,10 @@ func toCellsRec(
 				metadata[PrefixAttributeName(InternalAttributePrefix, "id")] = cellID
 			}
 			metadata[PrefixAttributeName(InternalAttributePrefix, "name")] = block.Name()
-			*cells = append(*cells, &Cell{
+			if fence := block.Fence(); fence != defaultFence {
+				metadata[fenceAttribute] = fence
+			}
+			cell := withLinePrefixes(&Cell{
 				Kind:       CodeKind,
 				Value:      string(block.Content()),
 				LanguageID: block.Language(),
@@ -176,7 +209,15 @@ func toCellsRec(
 					Start: textRange.Start + doc.ContentOffset(),
 					End:   textRange.End + doc.ContentOffset(),
 				},
-			})
+				TypedMetadata: block.TypedAttributes(),
+				attrWarnings:  block.AttributeWarnings(),
+			}, block.Unwrap())
+			// Prefer prefixes from the source as they
+			// also keep the indentation of the fences.
+			if firstLinePrefix, linePrefix := block.LinePrefixes(); firstLinePrefix != "" || linePrefix != "" {
+				setLinePrefixes(cell, firstLinePrefix, linePrefix)
+			}
+			*cells = append(*cells, cell)
 
 		case *document.MarkdownBlock:
 			value := block.Value()
@@ -195,33 +236,24 @@ func toCellsRec(
 				metadata["runme.dev/ast"] = string(jsonAstMetaData)
 			}
 
+			firstLinePrefix, linePrefix := linePrefixes(astNode)
+
 			isListItem := node.Item() != nil && node.Item().Unwrap().Kind() == ast.KindListItem
 			if childIdx == 0 && isListItem {
 				listItem := node.Item().Unwrap().(*ast.ListItem)
-				list := listItem.Parent().(*ast.List)
-
-				var prefix []byte
-
-				if !list.IsOrdered() {
-					prefix = append(prefix, []byte{list.Marker, ' '}...)
-				} else {
-					itemNumber := list.Start
-					tmp := node.Item().Unwrap()
-					for tmp.PreviousSibling() != nil {
-						tmp = tmp.PreviousSibling()
-						itemNumber++
-					}
-					prefix = append([]byte(strconv.Itoa(itemNumber)), '.', ' ')
-				}
-
-				value = append(prefix, value...)
+				value = append([]byte(listItemMarker(listItem)), value...)
+				// The marker is a part of the value, so the first line
+				// is prefixed only by the containers of the list item.
+				firstLinePrefix, _ = linePrefixes(listItem)
 			}
 
-			*cells = append(*cells, &Cell{
+			cell := &Cell{
 				Kind:     MarkupKind,
 				Value:    fmtValue(value),
 				Metadata: metadata,
-			})
+			}
+			setLinePrefixes(cell, firstLinePrefix, linePrefix)
+			*cells = append(*cells, cell)
 		}
 	}
 }
@@ -254,29 +286,11 @@ func PrefixAttributeName(prefix, name string) string {
 }
 
 func serializeFencedCodeAttributes(w io.Writer, cell *Cell) {
-	// Filter out private keys, i.e. starting with "_" or "runme.dev/".
-	// A key with a name "index" that comes from VS Code is also filtered out.
-	keys := make([]string, 0, len(cell.Metadata))
-	for k := range cell.Metadata {
-		if k == "index" || strings.HasPrefix(k, PrivateAttributePrefix) || strings.HasPrefix(k, InternalAttributePrefix) || len(k) == 0 {
-			continue
-		}
-		keys = append(keys, k)
-	}
-
-	attr := make(document.Attributes, len(keys))
-
-	for _, k := range keys {
-		if len(k) <= 0 {
-			continue
-		}
-
-		attr[k] = cell.Metadata[k]
-	}
+	attr := document.Attributes(publicAttributes(cell.Metadata))
 
 	if len(attr) > 0 {
 		_, _ = w.Write([]byte{' '})
-		_ = document.DefaultDocumentParser.Write(attr, w)
+		_ = document.WriteAttributes(attr, cell.TypedMetadata, w)
 	}
 }
 
@@ -289,40 +303,42 @@ func serializeCells(cells []*Cell) []byte {
 	var buf bytes.Buffer
 
 	for idx, cell := range cells {
-		value := cell.Value
+		var cellBuf bytes.Buffer
 
 		switch cell.Kind {
 		case CodeKind:
-			ticksCount := longestBacktickSeq(value)
-			if ticksCount < 3 {
-				ticksCount = 3
-			}
+			fence := codeFence(cell)
 
-			_, _ = buf.Write(bytes.Repeat([]byte{'`'}, ticksCount))
-			_, _ = buf.WriteString(cell.LanguageID)
+			_, _ = cellBuf.WriteString(fence)
+			_, _ = cellBuf.WriteString(cell.LanguageID)
 
-			serializeFencedCodeAttributes(&buf, cell)
+			serializeFencedCodeAttributes(&cellBuf, cell)
 
-			_ = buf.WriteByte('\n')
-			_, _ = buf.WriteString(cell.Value)
-			_ = buf.WriteByte('\n')
+			_ = cellBuf.WriteByte('\n')
+			_, _ = cellBuf.WriteString(cell.Value)
+			_ = cellBuf.WriteByte('\n')
 
-			serializeCellOutputsText(&buf, cell)
+			serializeCellOutputsText(&cellBuf, cell)
 
-			_, _ = buf.Write(bytes.Repeat([]byte{'`'}, ticksCount))
+			_, _ = cellBuf.WriteString(fence)
 
-			serializeCellOutputsImage(&buf, cell)
+			serializeCellOutputsImage(&cellBuf, cell)
 
 		case MarkupKind:
-			_, _ = buf.WriteString(cell.Value)
+			_, _ = cellBuf.WriteString(cell.Value)
 		}
 
-		nlRequired := 2
-		if idx == len(cells)-1 {
-			nlRequired = 1
+		if firstLinePrefix, linePrefix := cellLinePrefixes(cell); firstLinePrefix != "" || linePrefix != "" {
+			writePrefixedLines(&buf, cellBuf.Bytes(), firstLinePrefix, linePrefix)
+		} else {
+			_, _ = buf.Write(cellBuf.Bytes())
+		}
+
+		if countTrailingNewLines(buf.Bytes()) == 0 {
+			_ = buf.WriteByte('\n')
 		}
-		nlCount := countTrailingNewLines(buf.Bytes())
-		for i := nlCount; i < nlRequired; i++ {
+		if idx < len(cells)-1 && countTrailingNewLines(buf.Bytes()) < 2 {
+			_, _ = buf.WriteString(separatorLine(cell, cells[idx+1]))
 			_ = buf.WriteByte('\n')
 		}
 	}
@@ -40,7 +40,9 @@ $ echo "Hello, runme!"
 3. Item 3
 `)
 
-	testDataNestedFlattened = []byte(`# Examples
+	// testDataNestedFormatted is testDataNested after formatting.
+	// Cells in list items are separated by blank lines.
+	testDataNestedFormatted = []byte(`# Examples
 
 It can have an annotation with a name:
 
@@ -57,13 +59,13 @@ $ echo "Hello, runme!"
 
 1. Item 1
 
-` + "```" + `sh {"first":"","name":"echo-2","second":"2"}
-$ echo "Hello, runme!"
-` + "```" + `
+   ` + "```" + `sh {"first":"","name":"echo-2","second":"2"}
+   $ echo "Hello, runme!"
+   ` + "```" + `
 
-First inner paragraph
+   First inner paragraph
 
-Second inner paragraph
+   Second inner paragraph
 
 2. Item 2
 
@@ -274,26 +276,67 @@ func Test_serializeCells_nestedCode(t *testing.T) {
 	node, err := doc.Root()
 	require.NoError(t, err)
 	cells := toCells(doc, node, data)
-	assert.Equal(
-		t,
-		`# Development
-
-1. Ensure you have [dev](https://github.com/stateful/dev) setup and running
+	assert.Equal(t, string(data), string(serializeCells(cells)))
+}
 
-2. Install MacOS dependencies
+func Test_serializeCells_nestedCodeLossless(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "NestedList",
+			data: "1. Configure\n\n   - Nested item\n\n     ```sh\n     echo configure\n     ```\n\n   - Another item\n",
+		},
+		{
+			name: "Blockquote",
+			data: "> Quote with code:\n>\n> ```sh\n> echo quoted\n>\n> echo again\n> ```\n>\n> After code.\n",
+		},
+		{
+			name: "ListInBlockquote",
+			data: "> 1. Item\n>\n>    ```sh\n>    echo item\n>    ```\n",
+		},
+		{
+			name: "CodeAfterMarker",
+			data: "# Marker\n\n- ```sh\n  echo marker\n  ```\n",
+		},
+		{
+			name: "TildeFence",
+			data: "~~~sh\necho tilde\n~~~\n\n- Item\n\n  ~~~~sh\n  echo ~~~\n  ~~~~\n",
+		},
+		{
+			name: "LongFence",
+			data: "`````sh\necho 1\n`````\n",
+		},
+		{
+			name: "IndentedFence",
+			data: "# Indented\n\n- Item\n\n   ```sh\n   echo indented\n   ```\n",
+		},
+	}
 
-`+"```"+`sh
-brew bundle --no-lock
-`+"```"+`
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := []byte(tc.data)
+			doc := document.New(data, identityResolverNone)
+			node, err := doc.Root()
+			require.NoError(t, err)
+			cells := toCells(doc, node, data)
+			assert.Equal(t, tc.data, string(serializeCells(cells)))
+		})
+	}
 
-3. Setup pre-commit
+	t.Run("EditedValue", func(t *testing.T) {
+		data := []byte("> ~~~sh\n> echo 1\n> ~~~\n")
+		doc := document.New(data, identityResolverNone)
+		node, err := doc.Root()
+		require.NoError(t, err)
+		cells := toCells(doc, node, data)
+		require.Len(t, cells, 1)
+		assert.Equal(t, "echo 1", cells[0].Value)
 
-`+"```"+`sh
-pre-commit install
-`+"```"+`
-`,
-		string(serializeCells(cells)),
-	)
+		cells[0].Value = "echo 1\n~~~\necho 2"
+		assert.Equal(t, "> ~~~~sh\n> echo 1\n> ~~~\n> echo 2\n> ~~~~\n", string(serializeCells(cells)))
+	})
 }
 
 func Test_serializeCells(t *testing.T) {
@@ -15,8 +15,19 @@ import (
 const FrontmatterKey = "frontmatter"
 
 func Deserialize(data []byte, identityResolver *identity.IdentityResolver) (*Notebook, error) {
-	// Deserialize content to cells.
+	return deserialize(document.New(data, identityResolver), identityResolver)
+}
+
+// DeserializeMDX is like Deserialize, but it also
+// parses MDX expressions as raw blocks.
+func DeserializeMDX(data []byte, identityResolver *identity.IdentityResolver) (*Notebook, error) {
 	doc := document.New(data, identityResolver)
+	doc.SetMDX(true)
+	return deserialize(doc, identityResolver)
+}
+
+func deserialize(doc *document.Document, identityResolver *identity.IdentityResolver) (*Notebook, error) {
+	// Deserialize content to cells.
 	node, err := doc.Root()
 	if err != nil {
 		return nil, err
@@ -27,12 +38,15 @@ func Deserialize(data []byte, identityResolver *identity.IdentityResolver) (*Not
 		return nil, err
 	}
 
+	cells := toCells(doc, node, doc.Content())
+
 	notebook := &Notebook{
-		Cells:       toCells(doc, node, doc.Content()),
+		Cells:       cells,
 		Frontmatter: frontmatter,
 		Metadata: map[string]string{
 			PrefixAttributeName(InternalAttributePrefix, constants.FinalLineBreaksKey): strconv.Itoa(doc.TrailingLineBreaksCount()),
 		},
+		Warnings: collectAttributeWarnings(cells),
 	}
 
 	// Additionally, put raw frontmatter in notebook's metadata.
@@ -37,7 +37,7 @@ func TestEditor(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(
 		t,
-		string(testDataNestedFlattened),
+		string(testDataNestedFormatted),
 		string(result),
 	)
 }
@@ -162,6 +162,33 @@ Paragraph 1 with a link [Link1](https://example.com 'Link Title 1') and a second
 	assert.Equal(t, "Link1", nChild["Text"].(string))
 }
 
+func TestEditor_TypedMetadata(t *testing.T) {
+	data := []byte("# Matrix\n\n```sh {\"dependsOn\":[\"build\",\"lint\"],\"interactive\":false,\"matrix\":{\"os\":[\"linux\",\"darwin\"]},\"name\":\"test\",\"retries\":3}\ngo test ./...\n```\n")
+
+	notebook, err := Deserialize(data, identityResolverNone)
+	require.NoError(t, err)
+	require.Len(t, notebook.Cells, 2)
+
+	cell := notebook.Cells[1]
+	assert.Equal(t, `["build","lint"]`, cell.Metadata["dependsOn"])
+	assert.Equal(t, map[string]any{
+		"dependsOn":   []any{"build", "lint"},
+		"interactive": false,
+		"matrix":      map[string]any{"os": []any{"linux", "darwin"}},
+		"retries":     int64(3),
+	}, cell.TypedMetadata)
+
+	result, err := Serialize(notebook, nil)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(result))
+
+	// Values without types are serialized as strings.
+	cell.TypedMetadata = nil
+	result, err = Serialize(notebook, nil)
+	require.NoError(t, err)
+	assert.Contains(t, string(result), `"retries":"3"`)
+}
+
 func TestEditor_Frontmatter(t *testing.T) {
 	data := []byte(fmt.Sprintf(`+++
 prop1 = 'val1'
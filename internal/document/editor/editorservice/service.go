@@ -3,6 +3,8 @@ package editorservice
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"sort"
 	"strings"
 
 	"github.com/stateful/runme/internal/document"
@@ -45,11 +47,12 @@ func (s *parserServiceServer) Deserialize(_ context.Context, req *parserv1.Deser
 		}
 
 		cells = append(cells, &parserv1.Cell{
-			Kind:       parserv1.CellKind(cell.Kind),
-			Value:      cell.Value,
-			LanguageId: cell.LanguageID,
-			Metadata:   cell.Metadata,
-			TextRange:  tr,
+			Kind:              parserv1.CellKind(cell.Kind),
+			Value:             cell.Value,
+			LanguageId:        cell.LanguageID,
+			Metadata:          cell.Metadata,
+			TextRange:         tr,
+			TypedMetadataKeys: typedMetadataKeys(cell),
 		})
 	}
 
@@ -88,12 +91,23 @@ func (s *parserServiceServer) Deserialize(_ context.Context, req *parserv1.Deser
 		}
 	}
 
+	warnings := make([]*parserv1.AttributeWarning, 0, len(notebook.Warnings))
+	for _, w := range notebook.Warnings {
+		warnings = append(warnings, &parserv1.AttributeWarning{
+			CellIndex: uint32(w.CellIndex),
+			Attribute: w.Attribute,
+			Kind:      string(w.Kind),
+			Message:   w.Message,
+		})
+	}
+
 	return &parserv1.DeserializeResponse{
 		Notebook: &parserv1.Notebook{
 			Cells:       cells,
 			Metadata:    notebook.Metadata,
 			Frontmatter: frontmatter,
 		},
+		Warnings: warnings,
 	}, nil
 }
 
@@ -112,6 +126,7 @@ func (s *parserServiceServer) Serialize(_ context.Context, req *parserv1.Seriali
 			Metadata:         cell.Metadata,
 			Outputs:          outputs,
 			ExecutionSummary: executionSummary,
+			TypedMetadata:    typedMetadata(cell),
 		})
 	}
 
@@ -243,3 +258,38 @@ func min[T constraints.Ordered](a, b T) T {
 	}
 	return b
 }
+
+// typedMetadataKeys returns sorted keys of typed metadata of the cell.
+// Their values are already JSON-encoded in the cell's metadata.
+func typedMetadataKeys(cell *editor.Cell) []string {
+	if len(cell.TypedMetadata) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(cell.TypedMetadata))
+	for key := range cell.TypedMetadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// typedMetadata decodes JSON-encoded values of metadata with typed keys.
+// Values which are not valid JSON are serialized as strings.
+func typedMetadata(cell *parserv1.Cell) map[string]any {
+	var result map[string]any
+	for _, key := range cell.TypedMetadataKeys {
+		raw, ok := cell.Metadata[key]
+		if !ok {
+			continue
+		}
+		var value any
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]any)
+		}
+		result[key] = value
+	}
+	return result
+}
@@ -12,6 +12,7 @@ import (
 	ulid "github.com/stateful/runme/internal/ulid"
 	"github.com/stateful/runme/internal/version"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -294,6 +295,51 @@ func Test_parserServiceServer_Ast(t *testing.T) {
 	})
 }
 
+func Test_parserServiceServer_AttributeWarnings(t *testing.T) {
+	content := "# Title\n\n```sh { name=ok }\necho ok\n```\n\n```sh { name=typo interative=false }\necho typo\n```\n"
+
+	resp, err := deserialize(client, content, parserv1.RunmeIdentity_RUNME_IDENTITY_UNSPECIFIED)
+	require.NoError(t, err)
+	require.Len(t, resp.Notebook.Cells, 3)
+	require.Len(t, resp.Warnings, 1)
+
+	assert.True(
+		t,
+		proto.Equal(
+			&parserv1.AttributeWarning{
+				CellIndex: 2,
+				Attribute: "interative",
+				Kind:      "unknown",
+				Message:   `unknown attribute; did you mean "interactive"?`,
+			},
+			resp.Warnings[0],
+		),
+	)
+}
+
+func Test_parserServiceServer_TypedMetadata(t *testing.T) {
+	content := "# Title\n\n```sh {\"name\":\"deploy\",\"dependsOn\":[\"build\",\"test\"],\"interactive\":false}\necho deploy\n```\n"
+
+	resp, err := deserialize(client, content, parserv1.RunmeIdentity_RUNME_IDENTITY_UNSPECIFIED)
+	require.NoError(t, err)
+	require.Len(t, resp.Notebook.Cells, 2)
+
+	cell := resp.Notebook.Cells[1]
+	assert.Equal(t, []string{"dependsOn", "interactive"}, cell.TypedMetadataKeys)
+	assert.Equal(t, `["build","test"]`, cell.Metadata["dependsOn"])
+	assert.Equal(t, "false", cell.Metadata["interactive"])
+
+	// A client can change typed values using JSON.
+	cell.Metadata["dependsOn"] = `["build","test","lint"]`
+
+	serialized, err := client.Serialize(
+		context.Background(),
+		&parserv1.SerializeRequest{Notebook: resp.Notebook},
+	)
+	require.NoError(t, err)
+	assert.Contains(t, string(serialized.Result), "```sh {\"dependsOn\":[\"build\",\"test\",\"lint\"],\"interactive\":false,\"name\":\"deploy\"}\n")
+}
+
 func Test_parserServiceServer_Outputs(t *testing.T) {
 	t.Run("Text", func(t *testing.T) {
 		item := &parserv1.CellOutputItem{
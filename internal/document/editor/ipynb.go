@@ -0,0 +1,502 @@
+package editor
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ipynbMimeStdout = "application/vnd.code.notebook.stdout"
+	ipynbMimeStderr = "application/vnd.code.notebook.stderr"
+
+	// ipynbRunmeKey is a key in the notebook and cell metadata
+	// which keeps data necessary to convert a notebook back to Markdown.
+	ipynbRunmeKey = "runme"
+
+	// OutputAttribute marks a fenced code block in Markdown which keeps
+	// an output of the preceding code cell. Its value is "stdout", "stderr"
+	// or a MIME type.
+	OutputAttribute = "output"
+)
+
+// ipynbNotebook is a Jupyter notebook in the nbformat 4 format.
+// https://nbformat.readthedocs.io/en/latest/format_description.html
+type ipynbNotebook struct {
+	Cells         []*ipynbCell               `json:"cells"`
+	Metadata      map[string]json.RawMessage `json:"metadata"`
+	NBFormat      int                        `json:"nbformat"`
+	NBFormatMinor int                        `json:"nbformat_minor"`
+}
+
+type ipynbCell struct {
+	CellType       string                     `json:"cell_type"`
+	Metadata       map[string]json.RawMessage `json:"metadata"`
+	Source         ipynbText                  `json:"source"`
+	ExecutionCount *int                       `json:"execution_count,omitempty"`
+	Outputs        []*ipynbOutput             `json:"outputs,omitempty"`
+}
+
+func (c *ipynbCell) MarshalJSON() ([]byte, error) {
+	type cell ipynbCell
+
+	// Code cells require "execution_count" and "outputs"
+	// which are not allowed in other cells.
+	if c.CellType == "code" {
+		outputs := c.Outputs
+		if outputs == nil {
+			outputs = []*ipynbOutput{}
+		}
+		return json.Marshal(struct {
+			*cell
+			ExecutionCount *int           `json:"execution_count"`
+			Outputs        []*ipynbOutput `json:"outputs"`
+		}{(*cell)(c), c.ExecutionCount, outputs})
+	}
+
+	return json.Marshal((*cell)(c))
+}
+
+type ipynbOutput struct {
+	OutputType string                     `json:"output_type"`
+	Name       string                     `json:"name,omitempty"`
+	Text       ipynbText                  `json:"text,omitempty"`
+	Data       map[string]ipynbText       `json:"data,omitempty"`
+	Metadata   map[string]json.RawMessage `json:"metadata,omitempty"`
+	EName      string                     `json:"ename,omitempty"`
+	EValue     string                     `json:"evalue,omitempty"`
+	Traceback  []string                   `json:"traceback,omitempty"`
+}
+
+func (o *ipynbOutput) MarshalJSON() ([]byte, error) {
+	type output ipynbOutput
+
+	// Display data requires "metadata" even if it's empty.
+	if o.OutputType == "display_data" {
+		metadata := o.Metadata
+		if metadata == nil {
+			metadata = map[string]json.RawMessage{}
+		}
+		return json.Marshal(struct {
+			*output
+			Metadata map[string]json.RawMessage `json:"metadata"`
+		}{(*output)(o), metadata})
+	}
+
+	return json.Marshal((*output)(o))
+}
+
+// ipynbText is a multiline string which is stored
+// either as a string or as a list of lines.
+type ipynbText string
+
+func (t *ipynbText) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*t = ipynbText(strings.Join(lines, ""))
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*t = ipynbText(s)
+	return nil
+}
+
+func (t ipynbText) MarshalJSON() ([]byte, error) {
+	lines := strings.SplitAfter(string(t), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if lines == nil {
+		lines = []string{}
+	}
+	return json.Marshal(lines)
+}
+
+type ipynbRunmeNotebookMetadata struct {
+	Frontmatter string `json:"frontmatter,omitempty"`
+}
+
+// DeserializeIPYNB converts a Jupyter notebook to a Notebook.
+// Code cells keep their metadata as attributes and their outputs
+// as CellOutput.
+func DeserializeIPYNB(data []byte) (*Notebook, error) {
+	var nb ipynbNotebook
+	if err := json.Unmarshal(data, &nb); err != nil {
+		return nil, errors.Wrap(err, "failed to parse notebook")
+	}
+	if nb.NBFormat != 4 {
+		return nil, errors.Errorf("unsupported nbformat %d", nb.NBFormat)
+	}
+
+	notebook := &Notebook{
+		Metadata: map[string]string{},
+	}
+
+	var runme ipynbRunmeNotebookMetadata
+	if raw, ok := nb.Metadata[ipynbRunmeKey]; ok {
+		_ = json.Unmarshal(raw, &runme)
+	}
+	if runme.Frontmatter != "" {
+		notebook.Metadata[PrefixAttributeName(InternalAttributePrefix, FrontmatterKey)] = runme.Frontmatter
+	}
+
+	language := ipynbNotebookLanguage(nb.Metadata)
+
+	for _, c := range nb.Cells {
+		source := strings.TrimRight(string(c.Source), "\n")
+
+		if c.CellType != "code" {
+			if source == "" {
+				continue
+			}
+			notebook.Cells = append(notebook.Cells, &Cell{
+				Kind:  MarkupKind,
+				Value: source,
+			})
+			continue
+		}
+
+		cell := &Cell{
+			Kind:       CodeKind,
+			LanguageID: language,
+			Metadata:   map[string]string{},
+		}
+
+		for key, raw := range c.Metadata {
+			switch key {
+			case ipynbRunmeKey:
+				var attrs map[string]string
+				if err := json.Unmarshal(raw, &attrs); err == nil {
+					for k, v := range attrs {
+						cell.Metadata[k] = v
+					}
+				}
+			case "vscode":
+				var vscode struct {
+					LanguageID string `json:"languageId"`
+				}
+				if err := json.Unmarshal(raw, &vscode); err == nil && vscode.LanguageID != "" {
+					cell.LanguageID = vscode.LanguageID
+				}
+			default:
+				var s string
+				if err := json.Unmarshal(raw, &s); err == nil {
+					cell.Metadata[key] = s
+				} else {
+					cell.Metadata[key] = string(raw)
+				}
+			}
+		}
+
+		cell.LanguageID, source = ipynbCellMagic(cell.LanguageID, source)
+		cell.Value = source
+
+		for _, o := range c.Outputs {
+			if output := o.toCellOutput(); output != nil {
+				cell.Outputs = append(cell.Outputs, output)
+			}
+		}
+
+		notebook.Cells = append(notebook.Cells, cell)
+	}
+
+	return notebook, nil
+}
+
+func ipynbNotebookLanguage(metadata map[string]json.RawMessage) string {
+	var languageInfo struct {
+		Name string `json:"name"`
+	}
+	if raw, ok := metadata["language_info"]; ok {
+		if err := json.Unmarshal(raw, &languageInfo); err == nil && languageInfo.Name != "" {
+			return languageInfo.Name
+		}
+	}
+
+	var kernelspec struct {
+		Language string `json:"language"`
+	}
+	if raw, ok := metadata["kernelspec"]; ok {
+		if err := json.Unmarshal(raw, &kernelspec); err == nil && kernelspec.Language != "" {
+			return kernelspec.Language
+		}
+	}
+
+	return ""
+}
+
+// ipynbCellMagic handles cell magics like "%%bash" which change
+// the language of a cell. The magic line is removed from the source.
+func ipynbCellMagic(languageID, source string) (string, string) {
+	firstLine, rest, _ := strings.Cut(source, "\n")
+
+	switch strings.TrimSpace(firstLine) {
+	case "%%bash":
+		return "bash", rest
+	case "%%sh", "%%script sh":
+		return "sh", rest
+	case "%%javascript", "%%js", "%%script node":
+		return "javascript", rest
+	case "%%python", "%%python3":
+		return "python", rest
+	case "%%ruby":
+		return "ruby", rest
+	case "%%perl":
+		return "perl", rest
+	}
+
+	return languageID, source
+}
+
+func (o *ipynbOutput) toCellOutput() *CellOutput {
+	var items []*CellOutputItem
+
+	switch o.OutputType {
+	case "stream":
+		mime := ipynbMimeStdout
+		if o.Name == "stderr" {
+			mime = ipynbMimeStderr
+		}
+		// The trailing new line is added when serializing outputs.
+		value := strings.TrimSuffix(string(o.Text), "\n")
+		items = append(items, &CellOutputItem{Value: value, Type: "Buffer", Mime: mime})
+
+	case "error":
+		items = append(items, &CellOutputItem{
+			Value: strings.Join(o.Traceback, "\n"),
+			Type:  "Buffer",
+			Mime:  ipynbMimeStderr,
+		})
+
+	case "execute_result", "display_data":
+		mimes := make([]string, 0, len(o.Data))
+		for mime := range o.Data {
+			mimes = append(mimes, mime)
+		}
+		sort.Strings(mimes)
+
+		// Prefer images over the text representation.
+		for _, mime := range mimes {
+			if strings.HasPrefix(mime, "image/") && mime != "image/svg+xml" {
+				data := strings.Join(strings.Fields(string(o.Data[mime])), "")
+				items = append(items, &CellOutputItem{Data: data, Type: "Buffer", Mime: mime})
+			}
+		}
+		if text, ok := o.Data["text/plain"]; ok && len(items) == 0 {
+			items = append(items, &CellOutputItem{Value: string(text), Type: "Buffer", Mime: "text/plain"})
+		}
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	return &CellOutput{Items: items}
+}
+
+func newIPYNBOutput(item *CellOutputItem) *ipynbOutput {
+	switch {
+	case item.Mime == ipynbMimeStdout, item.Mime == ipynbMimeStderr:
+		name := "stdout"
+		if item.Mime == ipynbMimeStderr {
+			name = "stderr"
+		}
+		return &ipynbOutput{OutputType: "stream", Name: name, Text: ipynbText(item.Value + "\n")}
+	case strings.HasPrefix(item.Mime, "image/") && item.Data != "":
+		return &ipynbOutput{OutputType: "display_data", Data: map[string]ipynbText{item.Mime: ipynbText(item.Data)}}
+	default:
+		mime := item.Mime
+		if mime == "" {
+			mime = "text/plain"
+		}
+		return &ipynbOutput{OutputType: "display_data", Data: map[string]ipynbText{mime: ipynbText(item.Value)}}
+	}
+}
+
+func outputMime(name string) string {
+	switch name {
+	case "stdout":
+		return ipynbMimeStdout
+	case "stderr":
+		return ipynbMimeStderr
+	default:
+		return name
+	}
+}
+
+func outputName(mime string) string {
+	switch mime {
+	case ipynbMimeStdout:
+		return "stdout"
+	case ipynbMimeStderr:
+		return "stderr"
+	default:
+		return mime
+	}
+}
+
+// OutputsToCells moves text outputs of code cells into fenced code
+// blocks with OutputAttribute which follow the source of the cell.
+// Otherwise, the Markdown serializer would write them into the same
+// block as the source. Images are kept as they are written below
+// the code block.
+func OutputsToCells(notebook *Notebook) {
+	cells := make([]*Cell, 0, len(notebook.Cells))
+
+	for _, cell := range notebook.Cells {
+		cells = append(cells, cell)
+
+		if cell.Kind != CodeKind || len(cell.Outputs) == 0 {
+			continue
+		}
+
+		var images []*CellOutput
+
+		for _, output := range cell.Outputs {
+			var rest []*CellOutputItem
+
+			for _, item := range output.Items {
+				if strings.HasPrefix(item.Mime, "image/") {
+					rest = append(rest, item)
+					continue
+				}
+				cells = append(cells, &Cell{
+					Kind:       CodeKind,
+					LanguageID: "text",
+					Value:      strings.TrimSuffix(item.Value, "\n"),
+					Metadata:   map[string]string{OutputAttribute: outputName(item.Mime)},
+				})
+			}
+
+			if len(rest) > 0 {
+				images = append(images, &CellOutput{Items: rest})
+			}
+		}
+
+		cell.Outputs = images
+	}
+
+	notebook.Cells = cells
+}
+
+// cellsToOutputs is the reverse of OutputsToCells. Blocks with
+// OutputAttribute become outputs of the preceding code cell.
+func cellsToOutputs(cells []*Cell) []*Cell {
+	result := make([]*Cell, 0, len(cells))
+
+	for _, cell := range cells {
+		name := cell.Metadata[OutputAttribute]
+		last := len(result) - 1
+
+		if cell.Kind != CodeKind || name == "" || last < 0 || result[last].Kind != CodeKind {
+			if cell.Kind == CodeKind {
+				// Copy the cell as outputs might be added to it.
+				c := *cell
+				c.Outputs = append([]*CellOutput(nil), cell.Outputs...)
+				cell = &c
+			}
+			result = append(result, cell)
+			continue
+		}
+
+		result[last].Outputs = append(result[last].Outputs, &CellOutput{
+			Items: []*CellOutputItem{{Value: cell.Value, Type: "Buffer", Mime: outputMime(name)}},
+		})
+	}
+
+	return result
+}
+
+// SerializeIPYNB converts a Notebook to a Jupyter notebook.
+// Attributes of code cells and the frontmatter are stored
+// in metadata so that the notebook can be converted back.
+// Outputs of code cells, including blocks with OutputAttribute,
+// are written as notebook outputs.
+func SerializeIPYNB(notebook *Notebook) ([]byte, error) {
+	nb := ipynbNotebook{
+		Cells:         []*ipynbCell{},
+		Metadata:      map[string]json.RawMessage{},
+		NBFormat:      4,
+		NBFormatMinor: 4,
+	}
+
+	if frontmatter, ok := notebook.Metadata[PrefixAttributeName(InternalAttributePrefix, FrontmatterKey)]; ok {
+		raw, err := json.Marshal(ipynbRunmeNotebookMetadata{Frontmatter: frontmatter})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		nb.Metadata[ipynbRunmeKey] = raw
+	}
+
+	for _, cell := range cellsToOutputs(notebook.Cells) {
+		// Jupyter doesn't split Markdown into blocks,
+		// hence, consecutive markup cells are merged.
+		if last := len(nb.Cells) - 1; cell.Kind != CodeKind && last >= 0 && nb.Cells[last].CellType == "markdown" {
+			nb.Cells[last].Source += ipynbText("\n\n" + cell.Value)
+			continue
+		}
+
+		c := &ipynbCell{
+			Metadata: map[string]json.RawMessage{},
+			Source:   ipynbText(cell.Value),
+		}
+
+		switch cell.Kind {
+		case CodeKind:
+			c.CellType = "code"
+
+			if cell.LanguageID != "" {
+				raw, err := json.Marshal(map[string]string{"languageId": cell.LanguageID})
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				c.Metadata["vscode"] = raw
+			}
+
+			if attrs := publicAttributes(cell.Metadata); len(attrs) > 0 {
+				raw, err := json.Marshal(attrs)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				c.Metadata[ipynbRunmeKey] = raw
+			}
+
+			for _, output := range cell.Outputs {
+				for _, item := range output.Items {
+					c.Outputs = append(c.Outputs, newIPYNBOutput(item))
+				}
+			}
+		default:
+			c.CellType = "markdown"
+		}
+
+		nb.Cells = append(nb.Cells, c)
+	}
+
+	data, err := json.MarshalIndent(nb, "", " ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return append(data, '\n'), nil
+}
+
+// publicAttributes returns metadata without private keys, i.e. starting
+// with "_" or "runme.dev/". A key with a name "index" that comes from
+// VS Code is also filtered out.
+func publicAttributes(metadata map[string]string) map[string]string {
+	result := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if k == "index" || strings.HasPrefix(k, PrivateAttributePrefix) || strings.HasPrefix(k, InternalAttributePrefix) || len(k) == 0 {
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,114 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPYNB_RoundTrip(t *testing.T) {
+	data := []byte(`---
+shell: bash
+---
+
+# Examples
+
+Run it:
+
+` + "```sh" + ` {"interactive":"false","name":"hello"}
+echo hello
+` + "```" + `
+
+` + "```text" + ` {"output":"stdout"}
+hello
+` + "```" + `
+`)
+
+	notebook, err := Deserialize(data, identityResolverNone)
+	require.NoError(t, err)
+
+	ipynb, err := SerializeIPYNB(notebook)
+	require.NoError(t, err)
+	assert.Contains(t, string(ipynb), `"nbformat": 4`)
+	assert.Contains(t, string(ipynb), `"source": [
+    "# Examples\n",
+    "\n",
+    "Run it:"
+   ]`)
+	assert.Contains(t, string(ipynb), `"outputs": [
+    {
+     "output_type": "stream",
+     "name": "stdout",
+     "text": [
+      "hello\n"
+     ]
+    }
+   ]`)
+	// The source notebook is not modified.
+	assert.Len(t, notebook.Cells, 4)
+	assert.Empty(t, notebook.Cells[2].Outputs)
+
+	notebook, err = DeserializeIPYNB(ipynb)
+	require.NoError(t, err)
+	require.Len(t, notebook.Cells, 2)
+	assert.Equal(t, "echo hello", notebook.Cells[1].Value)
+	assert.Equal(
+		t,
+		[]*CellOutput{{Items: []*CellOutputItem{{Value: "hello", Type: "Buffer", Mime: ipynbMimeStdout}}}},
+		notebook.Cells[1].Outputs,
+	)
+
+	OutputsToCells(notebook)
+
+	result, err := Serialize(notebook, nil)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(result))
+}
+
+func TestDeserializeIPYNB(t *testing.T) {
+	data := []byte(`{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Title\n", "\n", "Text"]},
+  {
+   "cell_type": "code",
+   "execution_count": 1,
+   "metadata": {"tags": ["setup"]},
+   "outputs": [
+    {"output_type": "stream", "name": "stdout", "text": ["hello\n"]},
+    {"output_type": "display_data", "data": {"image/png": "aGVs\nbG8=\n", "text/plain": ["<Figure>"]}, "metadata": {}}
+   ],
+   "source": ["print('hello')"]
+  },
+  {"cell_type": "code", "execution_count": null, "metadata": {}, "outputs": [], "source": "%%bash\necho hi"}
+ ],
+ "metadata": {"kernelspec": {"display_name": "Python 3", "language": "python", "name": "python3"}},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}`)
+
+	notebook, err := DeserializeIPYNB(data)
+	require.NoError(t, err)
+	require.Len(t, notebook.Cells, 3)
+
+	assert.Equal(t, &Cell{Kind: MarkupKind, Value: "# Title\n\nText"}, notebook.Cells[0])
+
+	code := notebook.Cells[1]
+	assert.Equal(t, "python", code.LanguageID)
+	assert.Equal(t, "print('hello')", code.Value)
+	assert.Equal(t, map[string]string{"tags": `["setup"]`}, code.Metadata)
+	assert.Equal(
+		t,
+		[]*CellOutput{
+			{Items: []*CellOutputItem{{Value: "hello", Type: "Buffer", Mime: ipynbMimeStdout}}},
+			{Items: []*CellOutputItem{{Data: "aGVsbG8=", Type: "Buffer", Mime: "image/png"}}},
+		},
+		code.Outputs,
+	)
+
+	assert.Equal(t, "bash", notebook.Cells[2].LanguageID)
+	assert.Equal(t, "echo hi", notebook.Cells[2].Value)
+
+	_, err = DeserializeIPYNB([]byte(`{"nbformat": 3}`))
+	assert.ErrorContains(t, err, "unsupported nbformat 3")
+}
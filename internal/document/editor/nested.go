@@ -0,0 +1,155 @@
+package editor
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+const defaultFence = "```"
+
+var (
+	// linePrefixAttribute is a prefix of lines of a cell nested
+	// in block quotes or list items, for example, "> " or "   ".
+	linePrefixAttribute = PrefixAttributeName(InternalAttributePrefix, "linePrefix")
+	// firstLinePrefixAttribute is a prefix of the first line if it's
+	// different from linePrefixAttribute, for example, "1. ".
+	firstLinePrefixAttribute = PrefixAttributeName(InternalAttributePrefix, "firstLinePrefix")
+	// fenceAttribute is a fence of a code block if it's not "```".
+	fenceAttribute = PrefixAttributeName(InternalAttributePrefix, "fence")
+)
+
+// linePrefixes returns prefixes of the first and following lines
+// of a node nested in block quotes or list items. For example,
+// a code block which is the second block in a list item "1. "
+// is prefixed with "   ".
+func linePrefixes(node ast.Node) (firstLinePrefix, linePrefix string) {
+	onFirstLine := true
+
+	for child, parent := node, node.Parent(); parent != nil; child, parent = parent, parent.Parent() {
+		onFirstLine = onFirstLine && parent.FirstChild() == child
+
+		switch parent := parent.(type) {
+		case *ast.Blockquote:
+			firstLinePrefix = "> " + firstLinePrefix
+			linePrefix = "> " + linePrefix
+		case *ast.ListItem:
+			indent := strings.Repeat(" ", parent.Offset)
+			if onFirstLine {
+				firstLinePrefix = listItemMarker(parent) + firstLinePrefix
+			} else {
+				firstLinePrefix = indent + firstLinePrefix
+			}
+			linePrefix = indent + linePrefix
+		}
+	}
+
+	return firstLinePrefix, linePrefix
+}
+
+// listItemMarker returns a marker of the list item
+// followed by spaces up to its content, for example, "1. ".
+func listItemMarker(item *ast.ListItem) string {
+	list := item.Parent().(*ast.List)
+
+	marker := string(list.Marker)
+	if list.IsOrdered() {
+		itemNumber := list.Start
+		for tmp := ast.Node(item); tmp.PreviousSibling() != nil; tmp = tmp.PreviousSibling() {
+			itemNumber++
+		}
+		marker = strconv.Itoa(itemNumber) + marker
+	}
+
+	return marker + strings.Repeat(" ", max(1, item.Offset-len(marker)))
+}
+
+func withLinePrefixes(cell *Cell, node ast.Node) *Cell {
+	firstLinePrefix, linePrefix := linePrefixes(node)
+	setLinePrefixes(cell, firstLinePrefix, linePrefix)
+	return cell
+}
+
+func setLinePrefixes(cell *Cell, firstLinePrefix, linePrefix string) {
+	if firstLinePrefix == "" && linePrefix == "" {
+		return
+	}
+	if cell.Metadata == nil {
+		cell.Metadata = make(map[string]string)
+	}
+	cell.Metadata[linePrefixAttribute] = linePrefix
+	if firstLinePrefix != linePrefix {
+		cell.Metadata[firstLinePrefixAttribute] = firstLinePrefix
+	} else {
+		delete(cell.Metadata, firstLinePrefixAttribute)
+	}
+}
+
+func cellLinePrefixes(cell *Cell) (firstLinePrefix, linePrefix string) {
+	linePrefix = cell.Metadata[linePrefixAttribute]
+	firstLinePrefix, ok := cell.Metadata[firstLinePrefixAttribute]
+	if !ok {
+		firstLinePrefix = linePrefix
+	}
+	return firstLinePrefix, linePrefix
+}
+
+// writePrefixedLines writes data prefixing each line. Blank lines
+// are prefixed without trailing spaces, for example, ">" in block quotes.
+func writePrefixedLines(buf *bytes.Buffer, data []byte, firstLinePrefix, linePrefix string) {
+	data = bytes.TrimRight(data, "\n")
+	for i, line := range bytes.Split(data, []byte{'\n'}) {
+		prefix := linePrefix
+		if i == 0 {
+			prefix = firstLinePrefix
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			_, _ = buf.WriteString(strings.TrimRight(prefix, " "))
+		} else {
+			_, _ = buf.WriteString(prefix)
+			_, _ = buf.Write(line)
+		}
+		_ = buf.WriteByte('\n')
+	}
+}
+
+// separatorLine returns a blank line separating two cells. It keeps
+// cells in the same block quote together.
+func separatorLine(cell, next *Cell) string {
+	_, a := cellLinePrefixes(cell)
+	_, b := cellLinePrefixes(next)
+
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return strings.TrimRight(a[:i], " ")
+}
+
+// codeFence returns a fence of the code cell. The original fence,
+// like "~~~", is kept and lengthened if the value contains it.
+func codeFence(cell *Cell) string {
+	fence, ok := cell.Metadata[fenceAttribute]
+	if !ok || fence == "" {
+		ticksCount := longestBacktickSeq(cell.Value)
+		if ticksCount < 3 {
+			ticksCount = 3
+		}
+		return strings.Repeat("`", ticksCount)
+	}
+
+	c := fence[0]
+	longest, current := 0, 0
+	for i := 0; i < len(cell.Value); i++ {
+		if cell.Value[i] == c {
+			current++
+			longest = max(longest, current)
+		} else {
+			current = 0
+		}
+	}
+
+	return strings.Repeat(string(c), max(len(fence), longest+1))
+}
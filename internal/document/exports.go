@@ -0,0 +1,60 @@
+package document
+
+import (
+	"regexp"
+	"strings"
+)
+
+var exportExtractRegex = regexp.MustCompile(`(\n*)export (\w+=)(("[^"]*")|('[^']*')|[^;\n]+)`)
+
+// ExportMatch is an "export KEY=VALUE" statement found in a code block
+// for which a user can be prompted for a value.
+type ExportMatch struct {
+	Key            string
+	Value          string
+	Match          string
+	HasStringValue bool
+	LineNumber     int
+}
+
+// ExtractExports returns export statements from lines. Values which are
+// command substitutions, like $(cmd), are skipped as there is nothing to prompt for.
+func ExtractExports(lines []string) []ExportMatch {
+	result := []ExportMatch{}
+
+	for i, line := range lines {
+		for _, match := range exportExtractRegex.FindAllStringSubmatch(line, -1) {
+			e := match[0]
+
+			parts := strings.SplitN(strings.TrimSpace(e)[len("export "):], "=", 2)
+			if len(parts) == 0 {
+				continue
+			}
+			key := parts[0]
+			ph := strings.TrimSpace(parts[1])
+
+			isExecValue := strings.HasPrefix(ph, "$(") && strings.HasSuffix(ph, ")")
+			if isExecValue {
+				continue
+			}
+
+			hasStringValue := strings.HasPrefix(ph, "\"") || strings.HasPrefix(ph, "'")
+			placeHolder := ph
+			if hasStringValue {
+				placeHolder = ph[1 : len(ph)-1]
+			}
+
+			value := placeHolder
+
+			result = append(result, ExportMatch{
+				Key:            key,
+				Value:          value,
+				Match:          e,
+				HasStringValue: hasStringValue,
+				LineNumber:     i,
+			})
+		}
+	}
+
+	return result
+}
@@ -37,12 +37,24 @@ type RunmeMetadata struct {
 	Session  RunmeMetadataSession  `yaml:"session,omitempty" json:"session,omitempty" toml:"session,omitempty"`
 }
 
+// FrontmatterInput is a value which a document requires
+// from a user, for example, when it's run in CI.
+type FrontmatterInput struct {
+	Description string `yaml:"description,omitempty" json:"description,omitempty" toml:"description,omitempty"`
+	Default     string `yaml:"default,omitempty" json:"default,omitempty" toml:"default,omitempty"`
+	Required    bool   `yaml:"required,omitempty" json:"required,omitempty" toml:"required,omitempty"`
+}
+
 type Frontmatter struct {
-	Runme       RunmeMetadata `yaml:"runme,omitempty"`
-	Shell       string        `yaml:"shell"`
-	Cwd         string        `yaml:"cwd"`
-	Category    string        `yaml:"category"`
-	SkipPrompts bool          `yaml:"skipPrompts,omitempty"`
+	Runme       RunmeMetadata               `yaml:"runme,omitempty"`
+	Shell       string                      `yaml:"shell"`
+	Cwd         string                      `yaml:"cwd"`
+	Category    string                      `yaml:"category"`
+	DB          string                      `yaml:"db,omitempty"`
+	SkipPrompts bool                        `yaml:"skipPrompts,omitempty"`
+	Inputs      map[string]FrontmatterInput `yaml:"inputs,omitempty"`
+	Imports     []string                    `yaml:"imports,omitempty"`
+	Naming      string                      `yaml:"naming,omitempty"`
 
 	format string
 	raw    string // using string to be able to compare using ==
@@ -0,0 +1,282 @@
+package document
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"github.com/yuin/goldmark/ast"
+)
+
+// Import is a reference to code blocks of another document.
+// It's written as "path.md" or "path.md#section" where the section
+// is a heading text or its anchor, for example, "#log-in".
+type Import struct {
+	Path    string
+	Section string
+}
+
+func ParseImport(value string) Import {
+	path, section, _ := strings.Cut(strings.TrimSpace(value), "#")
+	return Import{Path: path, Section: section}
+}
+
+func (i Import) String() string {
+	if i.Section == "" {
+		return i.Path
+	}
+	return i.Path + "#" + i.Section
+}
+
+var includeDirectiveRegex = regexp.MustCompile(`^<!--\s*runme:include\s+(\S+)\s*-->$`)
+
+// parseIncludeDirective parses a comment like "<!-- runme:include shared.md#login -->".
+func parseIncludeDirective(block *MarkdownBlock) (Import, bool) {
+	if _, ok := block.Unwrap().(*ast.HTMLBlock); !ok {
+		return Import{}, false
+	}
+	match := includeDirectiveRegex.FindSubmatch(bytes.TrimSpace(block.Value()))
+	if match == nil {
+		return Import{}, false
+	}
+	return ParseImport(string(match[1])), true
+}
+
+// ImportError is returned when imports of a document fail.
+// It holds an error for each failed import.
+type ImportError struct {
+	Errs []error
+}
+
+func (e *ImportError) Error() string {
+	msgs := make([]string, 0, len(e.Errs))
+	for _, err := range e.Errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *ImportError) Unwrap() []error {
+	return e.Errs
+}
+
+// CollectCodeBlocksWithImports returns code blocks of the document
+// together with code blocks of imported documents. Paths of imports
+// are relative to the directory of the document located at path.
+//
+// Code blocks from the "imports" frontmatter list come first and code
+// blocks from include directives are placed where the directive is.
+// Imported code blocks keep their document, so they run in its context,
+// and their names are prefixed with the file name of the imported
+// document, for example, "shared.md:login".
+//
+// If some imports fail, the code blocks which could be collected
+// are returned together with an *ImportError.
+func CollectCodeBlocksWithImports(doc *Document, path string) (CodeBlocks, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var imp importer
+
+	blocks, err := imp.collect(doc, path, []string{path})
+	if err != nil {
+		return nil, err
+	}
+	if len(imp.errs) > 0 {
+		return blocks, &ImportError{Errs: imp.errs}
+	}
+	return blocks, nil
+}
+
+// importer collects code blocks following imports
+// and records errors of the failed ones.
+type importer struct {
+	errs []error
+}
+
+// collect returns code blocks of the document located at path.
+// visiting contains paths of the documents being imported,
+// including this one, and is used to detect import cycles.
+func (i *importer) collect(doc *Document, path string, visiting []string) (CodeBlocks, error) {
+	node, err := doc.Root()
+	if err != nil {
+		return nil, err
+	}
+
+	var result CodeBlocks
+
+	if fmtr, err := doc.Frontmatter(); err == nil && fmtr != nil {
+		for _, value := range fmtr.Imports {
+			result = append(result, i.importCodeBlocks(doc, ParseImport(value), filepath.Dir(path), visiting)...)
+		}
+	}
+
+	result = append(result, i.walk(doc, node.children, filepath.Dir(path), visiting)...)
+
+	return result, nil
+}
+
+// walk returns code blocks of the nodes and their descendants
+// together with code blocks of include directives among them.
+func (i *importer) walk(doc *Document, nodes []*Node, dir string, visiting []string) (result CodeBlocks) {
+	for _, node := range nodes {
+		switch item := node.Item().(type) {
+		case *CodeBlock:
+			result = append(result, item)
+		case *MarkdownBlock:
+			if imp, ok := parseIncludeDirective(item); ok {
+				result = append(result, i.importCodeBlocks(doc, imp, dir, visiting)...)
+			}
+		}
+		result = append(result, i.walk(doc, node.children, dir, visiting)...)
+	}
+	return result
+}
+
+func (i *importer) importCodeBlocks(doc *Document, imp Import, dir string, visiting []string) CodeBlocks {
+	blocks, err := i.importDocument(doc, imp, dir, visiting)
+	if err != nil {
+		i.errs = append(i.errs, err)
+	}
+	return blocks
+}
+
+func (i *importer) importDocument(doc *Document, imp Import, dir string, visiting []string) (CodeBlocks, error) {
+	if imp.Path == "" {
+		return nil, errors.Errorf("invalid import %q: missing path", imp)
+	}
+
+	path := imp.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, p := range visiting {
+		if p == path {
+			return nil, errors.Errorf("failed to import %q: import cycle", imp)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to import %q", imp)
+	}
+
+	imported := New(data, doc.identityResolver)
+	imported.SetNamingStrategy(doc.namingStrategy)
+	imported.SetMDX(IsMDX(path))
+	imported.path = path
+
+	var (
+		nested CodeBlocks
+		inner  importer
+	)
+
+	visiting = append(visiting[:len(visiting):len(visiting)], path)
+
+	if imp.Section == "" {
+		nested, err = inner.collect(imported, path, visiting)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to import %q", imp)
+		}
+	} else {
+		node, err := imported.Root()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to import %q", imp)
+		}
+		section := findSection(node, imp.Section)
+		if section == nil {
+			return nil, errors.Errorf("failed to import %q: section not found", imp)
+		}
+		nested = inner.walk(imported, section, filepath.Dir(path), visiting)
+	}
+
+	for _, err := range inner.errs {
+		i.errs = append(i.errs, errors.Wrapf(err, "failed to import %q", imp))
+	}
+
+	prefix := filepath.Base(path) + ":"
+	for _, block := range nested {
+		block.name = prefix + block.name
+	}
+
+	return nested, nil
+}
+
+// findSection returns top-level nodes under a heading matching
+// the section until the next heading of the same or a higher level.
+func findSection(root *Node, section string) []*Node {
+	return findSectionIn(root.children, section)
+}
+
+// findSectionIn is like findSection, but it looks for
+// the section in a list of sibling nodes.
+func findSectionIn(nodes []*Node, section string) []*Node {
+	var (
+		result []*Node
+		level  int
+	)
+
+	for _, child := range nodes {
+		heading := headingOf(child)
+
+		if level > 0 {
+			if heading != nil && heading.Level <= level {
+				break
+			}
+			result = append(result, child)
+			continue
+		}
+
+		if heading != nil && matchesSection(child.Item(), section) {
+			level = heading.Level
+			// Keep a non-nil result even if the section is empty.
+			result = []*Node{}
+		}
+	}
+
+	if level == 0 {
+		return nil
+	}
+	return result
+}
+
+func headingOf(node *Node) *ast.Heading {
+	block, ok := node.Item().(*MarkdownBlock)
+	if !ok {
+		return nil
+	}
+	heading, _ := block.Unwrap().(*ast.Heading)
+	return heading
+}
+
+func matchesSection(block Block, section string) bool {
+	// The first line works for both ATX and setext headings.
+	line, _, _ := strings.Cut(string(block.Value()), "\n")
+	text := strings.TrimSpace(strings.Trim(strings.TrimSpace(line), "#"))
+	return strings.EqualFold(text, section) || headingAnchor(text) == strings.ToLower(section)
+}
+
+// headingAnchor returns an anchor of a heading like GitHub does.
+func headingAnchor(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-':
+			_, _ = b.WriteRune(r)
+		case r == ' ':
+			_ = b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package document
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestParseImport(t *testing.T) {
+	assert.Equal(t, Import{Path: "shared.md"}, ParseImport("shared.md"))
+	assert.Equal(t, Import{Path: "lib/shared.md", Section: "log-in"}, ParseImport(" lib/shared.md#log-in "))
+	assert.Equal(t, "lib/shared.md#log-in", Import{Path: "lib/shared.md", Section: "log-in"}.String())
+}
+
+func TestCollectCodeBlocksWithImports(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(dir, "lib", "shared.md"), "# Shared\n\n## Log in\n\n```sh {\"name\":\"login\"}\necho login\n```\n\n### Details\n\n```sh {\"name\":\"details\"}\necho details\n```\n\nOther\n-----\n\n```sh {\"name\":\"other\"}\necho other\n```\n")
+
+	data := []byte("---\nimports:\n  - lib/shared.md#Other\n---\n\n```sh {\"name\":\"setup\"}\necho setup\n```\n\n<!-- runme:include lib/shared.md#log-in -->\n\n```sh {\"name\":\"deploy\"}\necho deploy\n```\n")
+	doc := New(data, identityResolver)
+
+	blocks, err := CollectCodeBlocksWithImports(doc, filepath.Join(dir, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shared.md:other", "setup", "shared.md:login", "shared.md:details", "deploy"}, blocks.Names())
+
+	// Imported code blocks keep their document.
+	for _, block := range blocks {
+		if block.Name() == "setup" || block.Name() == "deploy" {
+			assert.Same(t, doc, block.Document())
+			assert.Empty(t, block.Document().Path())
+		} else {
+			assert.NotSame(t, doc, block.Document())
+			assert.Equal(t, filepath.Join(dir, "lib", "shared.md"), block.Document().Path())
+		}
+	}
+
+	// The document itself is not changed.
+	node, err := doc.Root()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"setup", "deploy"}, CollectCodeBlocks(node).Names())
+}
+
+func TestCollectCodeBlocksWithImports_Nested(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(dir, "lib", "base.md"), "```sh {\"name\":\"base\"}\necho base\n```\n")
+	writeTestFile(t, filepath.Join(dir, "lib", "shared.md"), "<!-- runme:include base.md -->\n")
+
+	doc := New([]byte("<!-- runme:include lib/shared.md -->\n"), identityResolver)
+
+	blocks, err := CollectCodeBlocksWithImports(doc, filepath.Join(dir, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shared.md:base.md:base"}, blocks.Names())
+}
+
+func TestCollectCodeBlocksWithImports_NestedInSection(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(dir, "base.md"), "```sh {\"name\":\"base\"}\necho base\n```\n")
+	writeTestFile(t, filepath.Join(dir, "shared.md"), "# Setup\n\n<!-- runme:include base.md -->\n\n```sh {\"name\":\"setup\"}\necho setup\n```\n\n# Other\n\n```sh {\"name\":\"other\"}\necho other\n```\n")
+
+	doc := New([]byte("<!-- runme:include shared.md#setup -->\n"), identityResolver)
+
+	blocks, err := CollectCodeBlocksWithImports(doc, filepath.Join(dir, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shared.md:base.md:base", "shared.md:setup"}, blocks.Names())
+}
+
+func TestCollectCodeBlocksWithImports_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(dir, "a.md"), "<!-- runme:include b.md -->\n")
+	writeTestFile(t, filepath.Join(dir, "b.md"), "<!-- runme:include a.md -->\n")
+
+	t.Run("Cycle", func(t *testing.T) {
+		doc := New([]byte("<!-- runme:include a.md -->\n"), identityResolver)
+		_, err := CollectCodeBlocksWithImports(doc, filepath.Join(dir, "README.md"))
+		require.EqualError(t, err, `failed to import "a.md": failed to import "b.md": failed to import "a.md": import cycle`)
+	})
+
+	t.Run("CycleWithRoot", func(t *testing.T) {
+		doc := New([]byte("<!-- runme:include b.md -->\n"), identityResolver)
+		_, err := CollectCodeBlocksWithImports(doc, filepath.Join(dir, "a.md"))
+		require.EqualError(t, err, `failed to import "b.md": failed to import "a.md": import cycle`)
+	})
+
+	t.Run("MissingSection", func(t *testing.T) {
+		doc := New([]byte("```sh {\"name\":\"local\"}\necho local\n```\n\n<!-- runme:include a.md#missing -->\n"), identityResolver)
+		blocks, err := CollectCodeBlocksWithImports(doc, filepath.Join(dir, "README.md"))
+		require.ErrorContains(t, err, `failed to import "a.md#missing": section not found`)
+		assert.Equal(t, []string{"local"}, blocks.Names())
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		doc := New([]byte("---\nimports: [missing.md]\n---\n"), identityResolver)
+		_, err := CollectCodeBlocksWithImports(doc, filepath.Join(dir, "README.md"))
+		require.ErrorContains(t, err, `failed to import "missing.md"`)
+	})
+
+	t.Run("Many", func(t *testing.T) {
+		writeTestFile(t, filepath.Join(dir, "ok.md"), "```sh {\"name\":\"ok\"}\necho ok\n```\n")
+
+		doc := New([]byte("---\nimports: [missing.md, ok.md]\n---\n\n<!-- runme:include a.md#missing -->\n"), identityResolver)
+		blocks, err := CollectCodeBlocksWithImports(doc, filepath.Join(dir, "README.md"))
+		var importErr *ImportError
+		require.ErrorAs(t, err, &importErr)
+		assert.Len(t, importErr.Errs, 2)
+		assert.Equal(t, []string{"ok.md:ok"}, blocks.Names())
+	})
+}
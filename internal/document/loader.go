@@ -0,0 +1,140 @@
+package document
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+// Loader converts a document in a format other than Markdown into
+// Markdown. Code listings become fenced code blocks with attributes,
+// so they have the same semantics as code blocks in Markdown documents.
+// Headings and paragraphs are kept to provide names and descriptions.
+type Loader func(source []byte) ([]byte, error)
+
+var loaders = map[string]Loader{
+	".rst":      LoadRST,
+	".adoc":     LoadAsciiDoc,
+	".asciidoc": LoadAsciiDoc,
+	".asc":      LoadAsciiDoc,
+}
+
+// LoaderFor returns a loader for a file based on its extension.
+// It returns nil for Markdown and unsupported files.
+func LoaderFor(path string) Loader {
+	return loaders[strings.ToLower(filepath.Ext(path))]
+}
+
+// markdownWriter writes blocks of a Markdown document
+// separated by blank lines.
+type markdownWriter struct {
+	buf       bytes.Buffer
+	paragraph []string
+}
+
+func (w *markdownWriter) block(data string) {
+	w.flushParagraph()
+	if w.buf.Len() > 0 {
+		_ = w.buf.WriteByte('\n')
+	}
+	_, _ = w.buf.WriteString(data)
+	_ = w.buf.WriteByte('\n')
+}
+
+func (w *markdownWriter) heading(level int, text string) {
+	level = max(1, min(level, 6))
+	w.block(strings.Repeat("#", level) + " " + strings.TrimSpace(text))
+}
+
+// text adds a line to the current paragraph. A blank line ends it.
+func (w *markdownWriter) text(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		w.flushParagraph()
+		return
+	}
+	w.paragraph = append(w.paragraph, line)
+}
+
+func (w *markdownWriter) flushParagraph() {
+	if len(w.paragraph) == 0 {
+		return
+	}
+	lines := w.paragraph
+	w.paragraph = nil
+	w.block(strings.Join(lines, "\n"))
+}
+
+func (w *markdownWriter) codeBlock(language string, attributes Attributes, lines []string) {
+	fence := strings.Repeat("`", max(3, longestBacktickRun(lines)+1))
+
+	var b strings.Builder
+	_, _ = b.WriteString(fence)
+	_, _ = b.WriteString(language)
+	if len(attributes) > 0 {
+		_ = b.WriteByte(' ')
+		_ = DefaultDocumentParser.Write(attributes, &b)
+	}
+	_ = b.WriteByte('\n')
+	for _, line := range lines {
+		_, _ = b.WriteString(line)
+		_ = b.WriteByte('\n')
+	}
+	_, _ = b.WriteString(fence)
+
+	w.block(b.String())
+}
+
+func (w *markdownWriter) Bytes() []byte {
+	w.flushParagraph()
+	return w.buf.Bytes()
+}
+
+func longestBacktickRun(lines []string) (longest int) {
+	for _, line := range lines {
+		current := 0
+		for _, c := range line {
+			if c == '`' {
+				current++
+				longest = max(longest, current)
+			} else {
+				current = 0
+			}
+		}
+	}
+	return longest
+}
+
+// dedent removes the common indentation and
+// leading and trailing blank lines.
+func dedent(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if n := indentWidth(line); indent == -1 || n < indent {
+			indent = n
+		}
+	}
+
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		result = append(result, strings.TrimRight(line, " \t\r"))
+	}
+	return result
+}
+
+func indentWidth(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
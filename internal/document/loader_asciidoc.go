@@ -0,0 +1,244 @@
+package document
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	adocHeadingRegex        = regexp.MustCompile(`^(={1,6}|#{1,6})\s+(.+)$`)
+	adocAnchorRegex         = regexp.MustCompile(`^\[\[([^\],]+)(?:,[^\]]*)?\]\]$`)
+	adocAttributeListRegex  = regexp.MustCompile(`^\[([^\[\]]*)\]$`)
+	adocBlockTitleRegex     = regexp.MustCompile(`^\.([^.\s].*)$`)
+	adocAttributeEntryRegex = regexp.MustCompile(`^:!?[\w-]+!?:`)
+	adocListingRegex        = regexp.MustCompile(`^(-{4,}|\.{4,})$`)
+	adocCommentBlockRegex   = regexp.MustCompile(`^/{4,}$`)
+	adocOtherBlockRegex     = regexp.MustCompile(`^(={4,}|\*{4,}|_{4,}|\+{4,}|--)$`)
+	adocShorthandRegex      = regexp.MustCompile(`[#.%][^#.%]*`)
+)
+
+// adocPresentationAttributes are attributes of source blocks
+// which affect only how they are displayed.
+var adocPresentationAttributes = map[string]bool{
+	"indent":    true,
+	"linenums":  true,
+	"opts":      true,
+	"options":   true,
+	"role":      true,
+	"start":     true,
+	"subs":      true,
+	"title":     true,
+	"highlight": true,
+}
+
+// adocBlockAttributes are attributes of a block from the lines preceding it.
+type adocBlockAttributes struct {
+	style      string
+	language   string
+	id         string
+	title      string
+	attributes Attributes
+}
+
+func (a *adocBlockAttributes) isSource() bool {
+	return a != nil && a.language != "" && (a.style == "source" || a.style == "" || a.style == "listing")
+}
+
+// LoadAsciiDoc converts an AsciiDoc document into Markdown.
+//
+// Source blocks, like "[source,bash]" followed by a listing, become
+// code blocks. The block ID, for example, "[source#build,bash]" or
+// "[[build]]", names the code block. Other named attributes, like
+// "[source,bash,cwd=/tmp]", become attributes of the code block.
+// A block title, like ".Build the project", becomes a paragraph
+// before the code block.
+func LoadAsciiDoc(source []byte) ([]byte, error) {
+	lines := splitLines(source)
+
+	var (
+		w       markdownWriter
+		pending *adocBlockAttributes
+	)
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+
+		switch {
+		case line == "":
+			w.flushParagraph()
+			pending = nil
+
+		case adocCommentBlockRegex.MatchString(line):
+			w.flushParagraph()
+			i = adocDelimitedEnd(lines, i, line)
+
+		case strings.HasPrefix(line, "//"), adocAttributeEntryRegex.MatchString(line), line == "+":
+			// Comments, document attributes and list continuations.
+
+		case adocAnchorRegex.MatchString(line):
+			w.flushParagraph()
+			if pending == nil {
+				pending = &adocBlockAttributes{}
+			}
+			pending.id = adocAnchorRegex.FindStringSubmatch(line)[1]
+
+		case adocAttributeListRegex.MatchString(line):
+			w.flushParagraph()
+			attrs := parseAdocAttributeList(adocAttributeListRegex.FindStringSubmatch(line)[1])
+			if pending != nil {
+				if attrs.id == "" {
+					attrs.id = pending.id
+				}
+				if attrs.title == "" {
+					attrs.title = pending.title
+				}
+			}
+			pending = attrs
+
+		case adocBlockTitleRegex.MatchString(line) && len(w.paragraph) == 0:
+			if pending == nil {
+				pending = &adocBlockAttributes{}
+			}
+			pending.title = adocBlockTitleRegex.FindStringSubmatch(line)[1]
+
+		case adocListingRegex.MatchString(line):
+			w.flushParagraph()
+			end := adocDelimitedEnd(lines, i, line)
+			if pending.isSource() && line[0] == '-' {
+				adocCodeBlock(&w, pending, lines[i+1:min(end, len(lines))])
+			}
+			pending = nil
+			i = end
+
+		case adocOtherBlockRegex.MatchString(line):
+			// Content of other delimited blocks, like examples
+			// or sidebars, can contain source blocks.
+			w.flushParagraph()
+			pending = nil
+
+		case pending.isSource():
+			// A source block without delimiters ends with a blank line.
+			w.flushParagraph()
+			end := i
+			for end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+				end++
+			}
+			adocCodeBlock(&w, pending, lines[i:end])
+			pending = nil
+			i = end - 1
+
+		case adocHeadingRegex.MatchString(line) && len(w.paragraph) == 0:
+			m := adocHeadingRegex.FindStringSubmatch(line)
+			w.heading(len(m[1]), m[2])
+			pending = nil
+
+		default:
+			w.text(line)
+		}
+	}
+
+	return w.Bytes(), nil
+}
+
+// adocDelimitedEnd returns an index of the line closing
+// a delimited block which starts at start.
+func adocDelimitedEnd(lines []string, start int, delimiter string) int {
+	for i := start + 1; i < len(lines); i++ {
+		if strings.TrimRight(lines[i], " \t") == delimiter {
+			return i
+		}
+	}
+	return len(lines)
+}
+
+func adocCodeBlock(w *markdownWriter, attrs *adocBlockAttributes, lines []string) {
+	if attrs.title != "" {
+		w.text(attrs.title)
+	}
+
+	attributes := make(Attributes, len(attrs.attributes)+1)
+	for key, value := range attrs.attributes {
+		if !adocPresentationAttributes[key] {
+			attributes[key] = value
+		}
+	}
+	if attrs.id != "" && attributes["name"] == "" {
+		attributes["name"] = attrs.id
+	}
+
+	w.codeBlock(attrs.language, attributes, dedent(lines))
+}
+
+// parseAdocAttributeList parses a block attribute list, for example,
+// "source#build,bash,cwd=/tmp", without the square brackets.
+func parseAdocAttributeList(value string) *adocBlockAttributes {
+	result := &adocBlockAttributes{attributes: make(Attributes)}
+
+	var positional []string
+	for _, item := range splitAdocAttributes(value) {
+		if key, val, ok := strings.Cut(item, "="); ok && !strings.ContainsAny(key, " #.%") {
+			key = strings.TrimSpace(key)
+			val = strings.Trim(strings.TrimSpace(val), `"'`)
+			switch key {
+			case "id":
+				result.id = val
+			case "title":
+				result.title = val
+			case "language":
+				result.language = val
+			case "style":
+				result.style = val
+			default:
+				result.attributes[key] = val
+			}
+			continue
+		}
+		positional = append(positional, strings.TrimSpace(item))
+	}
+
+	if len(positional) > 0 {
+		style := positional[0]
+		// The style can have a shorthand ID, roles and options,
+		// for example, "source#build.wide%nowrap".
+		if idx := strings.IndexAny(style, "#.%"); idx != -1 {
+			for _, part := range adocShorthandRegex.FindAllString(style[idx:], -1) {
+				if part[0] == '#' {
+					result.id = part[1:]
+				}
+			}
+			style = style[:idx]
+		}
+		if result.style == "" {
+			result.style = style
+		}
+	}
+	if len(positional) > 1 && result.language == "" {
+		result.language = positional[1]
+	}
+
+	return result
+}
+
+// splitAdocAttributes splits attributes on commas outside quotes.
+func splitAdocAttributes(value string) (result []string) {
+	var (
+		current strings.Builder
+		quote   rune
+	)
+	for _, r := range value {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			result = append(result, current.String())
+			current.Reset()
+			continue
+		}
+		_, _ = current.WriteRune(r)
+	}
+	return append(result, current.String())
+}
@@ -0,0 +1,160 @@
+package document
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	rstCodeDirectiveRegex = regexp.MustCompile(`^(\s*)\.\.\s+(?:code-block|code|sourcecode)::\s*(\S*)\s*$`)
+	rstDirectiveRegex     = regexp.MustCompile(`^(\s*)\.\.\s+[\w:-]+::`)
+	rstCommentRegex       = regexp.MustCompile(`^(\s*)\.\.(?:\s|$)`)
+	rstOptionRegex        = regexp.MustCompile(`^\s+:([\w-]+):\s*(.*)$`)
+)
+
+// rstPresentationOptions are options of code blocks which
+// affect only how they are displayed.
+var rstPresentationOptions = map[string]bool{
+	"caption":         true,
+	"class":           true,
+	"dedent":          true,
+	"emphasize-lines": true,
+	"force":           true,
+	"lineno-start":    true,
+	"linenos":         true,
+	"number-lines":    true,
+}
+
+// LoadRST converts a reStructuredText document into Markdown.
+//
+// The "code-block", "code" and "sourcecode" directives become code
+// blocks. Their options become attributes, for example, ":name: build"
+// names the code block and ":cwd: /tmp" sets its working directory.
+// A ":caption:" becomes a paragraph before the code block.
+func LoadRST(source []byte) ([]byte, error) {
+	lines := splitLines(source)
+
+	var (
+		w      markdownWriter
+		styles []string // heading styles in order of appearance
+	)
+
+	headingLevel := func(style string) int {
+		for i, s := range styles {
+			if s == style {
+				return i + 1
+			}
+		}
+		styles = append(styles, style)
+		return len(styles)
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := rstCodeDirectiveRegex.FindStringSubmatch(line); m != nil {
+			indent := len(m[1])
+			language := m[2]
+
+			attributes := make(Attributes)
+			j := i + 1
+			for ; j < len(lines); j++ {
+				option := rstOptionRegex.FindStringSubmatch(lines[j])
+				if option == nil || indentWidth(lines[j]) <= indent {
+					break
+				}
+				attributes[option[1]] = strings.TrimSpace(option[2])
+			}
+
+			end := rstBlockEnd(lines, j, indent)
+
+			if caption := attributes["caption"]; caption != "" {
+				w.text(caption)
+			}
+			for key := range attributes {
+				if rstPresentationOptions[key] {
+					delete(attributes, key)
+				}
+			}
+
+			w.codeBlock(language, attributes, dedent(lines[j:end]))
+			i = end - 1
+			continue
+		}
+
+		if m := rstDirectiveRegex.FindStringSubmatch(line); m != nil {
+			// Content of other directives, like notes, is a regular
+			// content which can contain code blocks. Options are skipped.
+			w.flushParagraph()
+			indent := len(m[1])
+			for i+1 < len(lines) && rstOptionRegex.MatchString(lines[i+1]) && indentWidth(lines[i+1]) > indent {
+				i++
+			}
+			continue
+		}
+
+		if m := rstCommentRegex.FindStringSubmatch(line); m != nil {
+			w.flushParagraph()
+			i = rstBlockEnd(lines, i+1, len(m[1])) - 1
+			continue
+		}
+
+		// A heading with an overline and an underline.
+		if i+2 < len(lines) && isRSTAdornment(line) && strings.TrimSpace(lines[i+1]) != "" && lines[i+2] == line {
+			w.heading(headingLevel("o"+line[:1]), lines[i+1])
+			i += 2
+			continue
+		}
+
+		// A heading with an underline.
+		if i+1 < len(lines) && strings.TrimSpace(line) != "" && indentWidth(line) == 0 &&
+			isRSTAdornment(lines[i+1]) && utf8.RuneCountInString(lines[i+1]) >= utf8.RuneCountInString(line) {
+			w.heading(headingLevel("u"+lines[i+1][:1]), line)
+			i++
+			continue
+		}
+
+		// A paragraph ending with "::" is followed by a literal block.
+		if trimmed := strings.TrimSpace(line); strings.HasSuffix(trimmed, "::") {
+			if text := strings.TrimSpace(strings.TrimSuffix(trimmed, "::")); text != "" {
+				w.text(text + ":")
+			}
+			w.flushParagraph()
+			i = rstBlockEnd(lines, i+1, indentWidth(line)) - 1
+			continue
+		}
+
+		w.text(line)
+	}
+
+	return w.Bytes(), nil
+}
+
+// rstBlockEnd returns an index of the first non-blank line,
+// starting from start, which is not indented more than indent.
+func rstBlockEnd(lines []string, start, indent int) int {
+	for i := start; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "" && indentWidth(lines[i]) <= indent {
+			return i
+		}
+	}
+	return len(lines)
+}
+
+func isRSTAdornment(line string) bool {
+	line = strings.TrimRight(line, " \t")
+	if len(line) < 2 {
+		return false
+	}
+	c := line[0]
+	if !strings.ContainsRune("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", rune(c)) {
+		return false
+	}
+	return strings.Count(line, string(c)) == len(line)
+}
+
+func splitLines(source []byte) []string {
+	text := strings.ReplaceAll(string(source), "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
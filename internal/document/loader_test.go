@@ -0,0 +1,135 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoaderFor(t *testing.T) {
+	assert.NotNil(t, LoaderFor("docs/ops.rst"))
+	assert.NotNil(t, LoaderFor("docs/ops.ADOC"))
+	assert.NotNil(t, LoaderFor("docs/ops.asciidoc"))
+	assert.Nil(t, LoaderFor("README.md"))
+	assert.Nil(t, LoaderFor("main.go"))
+}
+
+func TestLoadRST(t *testing.T) {
+	source := []byte(`==========
+Operations
+==========
+
+Setup
+-----
+
+Install the tools
+first.
+
+.. code-block:: bash
+   :name: install
+   :caption: Install dependencies
+   :linenos:
+
+   echo installing
+     echo indented
+
+.. note::
+
+   Nested blocks work too.
+
+   .. code-block:: sh
+
+      echo "from note"
+
+.. a comment
+   spanning lines
+
+Literal example::
+
+    not a task
+
+Deploy
+------
+
+.. code:: bash
+   :name: deploy
+   :cwd: /tmp
+
+   pwd
+`)
+
+	result, err := LoadRST(source)
+	require.NoError(t, err)
+	assert.Equal(t, "# Operations\n\n"+
+		"## Setup\n\n"+
+		"Install the tools\nfirst.\n\n"+
+		"Install dependencies\n\n"+
+		"```bash {\"name\":\"install\"}\necho installing\n  echo indented\n```\n\n"+
+		"Nested blocks work too.\n\n"+
+		"```sh\necho \"from note\"\n```\n\n"+
+		"Literal example:\n\n"+
+		"## Deploy\n\n"+
+		"```bash {\"cwd\":\"/tmp\",\"name\":\"deploy\"}\npwd\n```\n",
+		string(result),
+	)
+
+	node, err := New(result, identityResolverNone).Root()
+	require.NoError(t, err)
+	blocks := CollectCodeBlocks(node)
+	require.Len(t, blocks, 3)
+	assert.Equal(t, "install", blocks[0].Name())
+	assert.Equal(t, "Install dependencies", blocks[0].Intro())
+	assert.Equal(t, "/tmp", blocks[2].Cwd())
+}
+
+func TestLoadAsciiDoc(t *testing.T) {
+	source := []byte(`= Operations
+:toc:
+
+== Build
+
+// a comment
+.Build the project
+[source#build,bash]
+----
+echo building
+----
+
+[[test]]
+[source,sh,cwd=/tmp,linenums]
+----
+pwd
+----
+
+[,bash]
+echo paragraph block
+
+....
+literal
+....
+
+====
+[source,python,name="hello"]
+----
+print("hi")
+----
+====
+
+----
+listing without a language
+----
+`)
+
+	result, err := LoadAsciiDoc(source)
+	require.NoError(t, err)
+	assert.Equal(t, "# Operations\n\n"+
+		"## Build\n\n"+
+		"Build the project\n\n"+
+		"```bash {\"name\":\"build\"}\necho building\n```\n\n"+
+		"```sh {\"cwd\":\"/tmp\",\"name\":\"test\"}\npwd\n```\n\n"+
+		"```bash\necho paragraph block\n```\n\n"+
+		"```python {\"name\":\"hello\"}\nprint(\"hi\")\n```\n",
+		string(result),
+	)
+}
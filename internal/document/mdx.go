@@ -0,0 +1,166 @@
+package document
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// KindMDXBlock is a NodeKind of MDXBlock.
+var KindMDXBlock = ast.NewNodeKind("MDXBlock")
+
+type MDXBlockType int
+
+const (
+	// MDXBlockESM is an import or export statement.
+	MDXBlockESM MDXBlockType = iota + 1
+	// MDXBlockJSX is a JSX element, for example, <Tabs>.
+	MDXBlockJSX
+	// MDXBlockExpression is a JavaScript expression in braces.
+	MDXBlockExpression
+)
+
+// MDXBlock is a block of MDX syntax which is not Markdown.
+// It's a raw block, so it is preserved byte-for-byte when
+// a document is rendered back to Markdown.
+type MDXBlock struct {
+	ast.BaseBlock
+
+	MDXBlockType MDXBlockType
+
+	// depth is a nesting level of braces in an expression.
+	depth int
+}
+
+func (n *MDXBlock) Kind() ast.NodeKind { return KindMDXBlock }
+
+func (n *MDXBlock) IsRaw() bool { return true }
+
+func (n *MDXBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+var (
+	mdxESMRegexp = regexp.MustCompile(`^(?:import\s+(?:[\w$*{}\s,]+\s+from\s+)?["'][^"']+["'];?\s*$|import\s*\{[^}]*$|export\s+(?:const|let|var|function|class|default|async\s+function)\b|export\s*[{*])`)
+	// JSX elements start with an upper-case letter, like <Tabs>,
+	// or are fragments, like <>. Lower-case elements are HTML blocks.
+	mdxJSXRegexp = regexp.MustCompile(`^</?(?:[A-Z][\w.:-]*(?:[\s/>]|$)|>)`)
+)
+
+// mdxBlockParser parses ESM statements, JSX elements and expressions
+// from MDX. A block ends with a blank line like an HTML block, so
+// Markdown, including fenced code blocks, separated by blank lines
+// inside JSX elements is parsed as usual.
+//
+// The ESM and JSX patterns are specific enough not to change how
+// regular Markdown documents are parsed. Expressions are parsed
+// only if enabled, because paragraphs can start with a brace.
+type mdxBlockParser struct {
+	expressions bool
+}
+
+func (b *mdxBlockParser) Trigger() []byte {
+	return []byte{'i', 'e', '<', '{'}
+}
+
+func (b *mdxBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || pc.BlockIndent() > 3 || ast.IsParagraph(pc.LastOpenedBlock().Node) {
+		return nil, parser.NoChildren
+	}
+
+	node := &MDXBlock{}
+
+	switch {
+	case parent.Kind() == ast.KindDocument && pos == 0 && mdxESMRegexp.Match(line):
+		node.MDXBlockType = MDXBlockESM
+	case line[pos] == '<' && mdxJSXRegexp.Match(line[pos:]):
+		node.MDXBlockType = MDXBlockJSX
+	case line[pos] == '{' && b.expressions:
+		node.MDXBlockType = MDXBlockExpression
+		node.depth = braceDepth(line)
+		// An expression followed by text is a part of a paragraph.
+		if node.depth <= 0 && !bytes.HasSuffix(util.TrimRightSpace(line), []byte{'}'}) {
+			return nil, parser.NoChildren
+		}
+	default:
+		return nil, parser.NoChildren
+	}
+
+	node.Lines().Append(segment)
+	reader.Advance(segment.Len() - util.TrimRightSpaceLength(line))
+	return node, parser.NoChildren
+}
+
+func (b *mdxBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	mdxBlock := node.(*MDXBlock)
+	line, segment := reader.PeekLine()
+
+	if util.IsBlank(line) {
+		return parser.Close
+	}
+	if mdxBlock.MDXBlockType == MDXBlockExpression {
+		if mdxBlock.depth <= 0 {
+			return parser.Close
+		}
+		mdxBlock.depth += braceDepth(line)
+	}
+
+	node.Lines().Append(segment)
+	reader.Advance(segment.Len() - util.TrimRightSpaceLength(line))
+	return parser.Continue | parser.NoChildren
+}
+
+func (b *mdxBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (b *mdxBlockParser) CanInterruptParagraph() bool {
+	return false
+}
+
+func (b *mdxBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+func braceDepth(line []byte) (depth int) {
+	for _, c := range line {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// IsMDX returns true if the file at path is an MDX document.
+func IsMDX(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".mdx")
+}
+
+// SetMDX enables parsing of MDX expressions, like "{props.value}",
+// which are otherwise parsed as paragraphs. It must be called before
+// the document is parsed.
+func (d *Document) SetMDX(enabled bool) {
+	d.parser = newParser(enabled)
+}
+
+// newParser returns a goldmark parser with the default block
+// parsers and the MDX block parser. MDX expressions are parsed
+// only if mdx is true.
+func newParser(mdx bool) parser.Parser {
+	p := goldmark.DefaultParser()
+	p.AddOptions(
+		// Run before the HTML block parser (900).
+		parser.WithBlockParsers(util.Prioritized(&mdxBlockParser{expressions: mdx}, 850)),
+	)
+	return p
+}
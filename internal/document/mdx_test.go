@@ -0,0 +1,125 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark/ast"
+)
+
+func TestDocument_MDX(t *testing.T) {
+	data := []byte(`import { Tabs, TabItem } from '@theme/Tabs'
+import Note from "./note.mdx"
+export const meta = { title: 'Deploy *now*' }
+
+# Deploy
+
+<Tabs groupId="os">
+  <TabItem value="mac" label="macOS">
+
+` + "```sh" + ` {"name":"install-mac"}
+brew install foo
+` + "```" + `
+
+  </TabItem>
+</Tabs>
+
+<Note type="warning" items={[1, 2]}>Be *careful* with ` + "`prod`" + `</Note>
+
+{/* a comment
+  spanning lines */}
+
+Text with {props.value} and <Badge color="red" />.
+
+<Callout
+  title="Multi line"
+  kind='info'
+/>
+
+export default ({ children }) => <Layout>{children}</Layout>
+`)
+
+	doc := New(data, identityResolverNone)
+	doc.SetMDX(true)
+	node, err := doc.Root()
+	require.NoError(t, err)
+
+	var mdxTypes []MDXBlockType
+	for _, child := range node.Children() {
+		if block, ok := child.Item().Unwrap().(*MDXBlock); ok {
+			mdxTypes = append(mdxTypes, block.MDXBlockType)
+		}
+	}
+	assert.Equal(
+		t,
+		[]MDXBlockType{MDXBlockESM, MDXBlockJSX, MDXBlockJSX, MDXBlockJSX, MDXBlockExpression, MDXBlockJSX, MDXBlockESM},
+		mdxTypes,
+	)
+
+	assert.Equal(t, []string{"install-mac"}, CollectCodeBlocks(node).Names())
+
+	rootAST, err := doc.RootAST()
+	require.NoError(t, err)
+	result, err := doc.renderer(rootAST, doc.Content())
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(result))
+}
+
+func TestDocument_MDXPatternsInMarkdown(t *testing.T) {
+	data := []byte(`import the data from the database
+export FOO=bar in your shell
+
+<details>
+<summary>More</summary>
+</details>
+
+A paragraph
+<Tabs>
+
+{the braces} in a *paragraph*
+second line
+`)
+
+	doc := New(data, identityResolverNone)
+	rootAST, err := doc.RootAST()
+	require.NoError(t, err)
+
+	err = ast.Walk(rootAST, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		assert.NotEqual(t, KindMDXBlock, n.Kind())
+		return ast.WalkContinue, nil
+	})
+	require.NoError(t, err)
+}
+
+func TestDocument_MDXExpressionInMarkdown(t *testing.T) {
+	data := []byte("# Title\n\n{the braces} in a *paragraph*\nsecond line\n")
+
+	doc := New(data, identityResolverNone)
+	node, err := doc.Root()
+	require.NoError(t, err)
+	require.Len(t, node.Children(), 2)
+	_, ok := node.Children()[1].Item().Unwrap().(*ast.Paragraph)
+	assert.True(t, ok)
+
+	rootAST, err := doc.RootAST()
+	require.NoError(t, err)
+	result, err := doc.renderer(rootAST, doc.Content())
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(result))
+
+	// In MDX, it's a paragraph starting with an inline expression.
+	doc = New(data, identityResolverNone)
+	doc.SetMDX(true)
+	node, err = doc.Root()
+	require.NoError(t, err)
+	require.Len(t, node.Children(), 2)
+	_, ok = node.Children()[1].Item().Unwrap().(*ast.Paragraph)
+	assert.True(t, ok)
+}
+
+func TestIsMDX(t *testing.T) {
+	assert.True(t, IsMDX("docs/README.mdx"))
+	assert.True(t, IsMDX("README.MDX"))
+	assert.False(t, IsMDX("README.md"))
+}
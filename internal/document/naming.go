@@ -0,0 +1,73 @@
+package document
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// NamingStrategy determines how code blocks without
+// the "name" attribute are named.
+type NamingStrategy string
+
+const (
+	// NamingStrategyCommand names a code block after the first
+	// line of its command, for example, "echo-hello".
+	NamingStrategyCommand NamingStrategy = "command"
+	// NamingStrategyHeading names a code block after the nearest
+	// preceding heading, for example, "deploy-to-staging". Code blocks
+	// without a preceding heading are named after the command.
+	NamingStrategyHeading NamingStrategy = "heading"
+)
+
+// ParseNamingStrategy returns a naming strategy by its name.
+// An empty value is the default strategy.
+func ParseNamingStrategy(value string) (NamingStrategy, error) {
+	switch NamingStrategy(strings.ToLower(strings.TrimSpace(value))) {
+	case "", NamingStrategyCommand:
+		return NamingStrategyCommand, nil
+	case NamingStrategyHeading:
+		return NamingStrategyHeading, nil
+	default:
+		return "", errors.Errorf("unknown naming strategy %q, expected %q or %q", value, NamingStrategyCommand, NamingStrategyHeading)
+	}
+}
+
+// SetNamingStrategy sets a strategy for naming code blocks without
+// the "name" attribute. The "naming" field in the frontmatter takes
+// precedence over it. It must be called before the document is parsed.
+func (d *Document) SetNamingStrategy(strategy NamingStrategy) {
+	d.namingStrategy = strategy
+}
+
+func (d *Document) resolveNamingStrategy() NamingStrategy {
+	if f, err := d.Frontmatter(); err == nil && f != nil && f.Naming != "" {
+		if strategy, err := ParseNamingStrategy(f.Naming); err == nil {
+			return strategy
+		}
+	}
+	if d.namingStrategy != "" {
+		return d.namingStrategy
+	}
+	return NamingStrategyCommand
+}
+
+// headingName returns a name of a code block derived from a heading,
+// for example, "deploy-to-staging" for "Deploy to Staging".
+func headingName(text string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				_ = b.WriteByte('-')
+			}
+			_, _ = b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
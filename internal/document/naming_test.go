@@ -0,0 +1,90 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_NamingStrategy(t *testing.T) {
+	data := []byte(`` + "```" + `sh
+echo before
+` + "```" + `
+
+# Setup
+
+` + "```" + `sh
+echo hello
+` + "```" + `
+
+## Deploy to *Staging*
+
+` + "```" + `sh
+kubectl apply -f staging.yaml
+` + "```" + `
+
+- ` + "```" + `sh
+  kubectl rollout status deploy/app
+  ` + "```" + `
+
+` + "```" + `sh {"name":"explicit"}
+echo named
+` + "```" + `
+`)
+
+	collectNames := func(t *testing.T, doc *Document) []string {
+		t.Helper()
+		node, err := doc.Root()
+		require.NoError(t, err)
+		return CollectCodeBlocks(node).Names()
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		doc := New(data, identityResolver)
+		assert.Equal(
+			t,
+			[]string{"echo-before", "echo-hello", "kubectl-apply", "kubectl-rollout", "explicit"},
+			collectNames(t, doc),
+		)
+	})
+
+	t.Run("Heading", func(t *testing.T) {
+		doc := New(data, identityResolver)
+		doc.SetNamingStrategy(NamingStrategyHeading)
+		assert.Equal(
+			t,
+			[]string{"echo-before", "setup", "deploy-to-staging", "deploy-to-staging-2", "explicit"},
+			collectNames(t, doc),
+		)
+	})
+
+	t.Run("Frontmatter", func(t *testing.T) {
+		doc := New(append([]byte("---\nnaming: heading\n---\n\n"), data...), identityResolver)
+		doc.SetNamingStrategy(NamingStrategyCommand)
+		assert.Equal(
+			t,
+			[]string{"echo-before", "setup", "deploy-to-staging", "deploy-to-staging-2", "explicit"},
+			collectNames(t, doc),
+		)
+	})
+}
+
+func TestParseNamingStrategy(t *testing.T) {
+	strategy, err := ParseNamingStrategy("")
+	require.NoError(t, err)
+	assert.Equal(t, NamingStrategyCommand, strategy)
+
+	strategy, err = ParseNamingStrategy("Heading")
+	require.NoError(t, err)
+	assert.Equal(t, NamingStrategyHeading, strategy)
+
+	_, err = ParseNamingStrategy("title")
+	assert.EqualError(t, err, `unknown naming strategy "title", expected "command" or "heading"`)
+}
+
+func Test_headingName(t *testing.T) {
+	assert.Equal(t, "deploy-to-staging", headingName("Deploy to Staging"))
+	assert.Equal(t, "step-1-build", headingName("Step 1 - Build:"))
+	assert.Equal(t, "café", headingName("  Café!  "))
+}
@@ -0,0 +1,58 @@
+package document
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// HeadingPathSeparator separates headings in a heading path.
+const HeadingPathSeparator = "/"
+
+// ErrSectionNotFound is returned when a heading path
+// doesn't identify any section of a document.
+var ErrSectionNotFound = errors.New("section not found")
+
+// ParseHeadingPath splits a heading path, for example, "Deploy/Staging",
+// into headings. Empty headings are skipped. A heading containing
+// the separator, like "CI/CD", can be addressed by its anchor, "cicd".
+func ParseHeadingPath(value string) (result []string) {
+	for _, heading := range strings.Split(value, HeadingPathSeparator) {
+		if heading = strings.TrimSpace(heading); heading != "" {
+			result = append(result, heading)
+		}
+	}
+	return result
+}
+
+// CodeBlocksInSection returns code blocks in a section identified
+// by a path of headings. Each heading in the path is looked up in
+// the section of the previous one, so "Deploy" and "Staging" identify
+// a "Staging" section nested at any level under a "Deploy" section.
+// Headings are matched by their text, case-insensitively, or by their
+// anchor, for example, "deploy-to-staging".
+//
+// Code blocks in subsections are included.
+func (d *Document) CodeBlocksInSection(path ...string) (CodeBlocks, error) {
+	root, err := d.Root()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := root.children
+	for _, heading := range path {
+		nodes = findSectionIn(nodes, heading)
+		if nodes == nil {
+			return nil, errors.Wrapf(ErrSectionNotFound, "%q", strings.Join(path, HeadingPathSeparator))
+		}
+	}
+
+	var result CodeBlocks
+	for _, node := range nodes {
+		if block, ok := node.Item().(*CodeBlock); ok {
+			result = append(result, block)
+		}
+		collectCodeBlocks(node, &result)
+	}
+	return result, nil
+}
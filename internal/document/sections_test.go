@@ -0,0 +1,68 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_CodeBlocksInSection(t *testing.T) {
+	data := []byte(`# Project
+
+## Deploy
+
+### Staging
+
+` + "```" + `sh {"name":"deploy-staging"}
+echo staging
+` + "```" + `
+
+#### Verify
+
+` + "```" + `sh {"name":"verify-staging"}
+echo verify
+` + "```" + `
+
+### Production
+
+` + "```" + `sh {"name":"deploy-production"}
+echo production
+` + "```" + `
+
+## Rollback
+
+### Staging
+
+` + "```" + `sh {"name":"rollback-staging"}
+echo rollback
+` + "```" + `
+`)
+
+	doc := New(data, identityResolver)
+
+	testCases := []struct {
+		path     []string
+		expected []string
+	}{
+		{[]string{"Deploy", "Staging"}, []string{"deploy-staging", "verify-staging"}},
+		{[]string{"Project", "Deploy", "Production"}, []string{"deploy-production"}},
+		{[]string{"rollback", "staging"}, []string{"rollback-staging"}},
+		{[]string{"Staging"}, []string{"deploy-staging", "verify-staging"}},
+		{[]string{"deploy", "staging", "verify"}, []string{"verify-staging"}},
+	}
+
+	for _, tc := range testCases {
+		blocks, err := doc.CodeBlocksInSection(tc.path...)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, blocks.Names(), "path: %v", tc.path)
+	}
+
+	_, err := doc.CodeBlocksInSection("Deploy", "Rollback")
+	assert.ErrorIs(t, err, ErrSectionNotFound)
+}
+
+func TestParseHeadingPath(t *testing.T) {
+	assert.Equal(t, []string{"Deploy", "Staging"}, ParseHeadingPath("Deploy/ Staging/"))
+	assert.Nil(t, ParseHeadingPath(""))
+}
@@ -0,0 +1,141 @@
+package document
+
+import (
+	"bytes"
+	stderrors "errors"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// IsTemplate returns true if the code block is a template
+// for other code blocks. Templates are not run directly.
+func (b *CodeBlock) IsTemplate() bool {
+	return b.attributes.Bool("template")
+}
+
+// Use returns a name of a template which the code block uses.
+func (b *CodeBlock) Use() string {
+	return b.attributes.Get("use")
+}
+
+// TemplateError is returned when a code block can't be expanded
+// from its template.
+type TemplateError struct {
+	Block string
+	Err   error
+}
+
+func (e *TemplateError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// ExpandTemplates replaces code blocks which use a template
+// with the template rendered using their attributes as parameters.
+// For example, a code block with attributes "use=scale replicas=3"
+// gets the lines of the template named "scale" where "{{ .replicas }}"
+// is replaced with "3". Attributes of the template are defaults for
+// the attributes of the code block.
+//
+// Templates are removed from the result. If a code block can't be
+// expanded, it's skipped and a *TemplateError is reported for it.
+// All such errors are joined and returned with the other code blocks.
+func ExpandTemplates(blocks CodeBlocks) (result CodeBlocks, _ error) {
+	templates := make(map[string]*CodeBlock)
+	for _, block := range blocks {
+		if block.IsTemplate() {
+			templates[block.Name()] = block
+		}
+	}
+
+	var errs []error
+
+	for _, block := range blocks {
+		if block.IsTemplate() {
+			continue
+		}
+
+		name := block.Use()
+		if name == "" {
+			result = append(result, block)
+			continue
+		}
+
+		tmpl, ok := templates[name]
+		if !ok {
+			errs = append(errs, &TemplateError{
+				Block: block.Name(),
+				Err:   errors.Errorf("code block %q uses unknown template %q", block.Name(), name),
+			})
+			continue
+		}
+
+		expanded, err := expandTemplate(block, tmpl)
+		if err != nil {
+			errs = append(errs, &TemplateError{Block: block.Name(), Err: err})
+			continue
+		}
+
+		result = append(result, expanded)
+	}
+
+	return result, stderrors.Join(errs...)
+}
+
+func expandTemplate(block, tmpl *CodeBlock) (*CodeBlock, error) {
+	t, err := template.New(tmpl.Name()).
+		Option("missingkey=error").
+		Parse(strings.Join(tmpl.Lines(), "\n"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid template %q", tmpl.Name())
+	}
+
+	attributes := make(Attributes, len(tmpl.attributes)+len(block.attributes))
+	for key, value := range tmpl.attributes {
+		if key == "name" || key == "template" {
+			continue
+		}
+		attributes[key] = value
+	}
+	for key, value := range block.attributes {
+		attributes[key] = value
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, attributes); err != nil {
+		return nil, errors.Wrapf(err, "failed to expand template %q in code block %q", tmpl.Name(), block.Name())
+	}
+
+	expanded := *block
+	expanded.attributes = attributes
+	expanded.lines = strings.Split(buf.String(), "\n")
+	expanded.value = replaceContent(block.value, buf.Bytes())
+	if expanded.language == "" {
+		expanded.language = tmpl.language
+	}
+
+	return &expanded, nil
+}
+
+// replaceContent returns the value of a code block
+// with the lines between its fences replaced by content.
+func replaceContent(value, content []byte) []byte {
+	lines := bytes.Split(bytes.Trim(value, "\n"), []byte{'\n'})
+	if len(lines) < 2 {
+		return value
+	}
+
+	var buf bytes.Buffer
+	_, _ = buf.Write(lines[0])
+	_ = buf.WriteByte('\n')
+	_, _ = buf.Write(content)
+	_ = buf.WriteByte('\n')
+	_, _ = buf.Write(lines[len(lines)-1])
+	_ = buf.WriteByte('\n')
+	return buf.Bytes()
+}
@@ -0,0 +1,67 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandTemplates(t *testing.T) {
+	data := []byte("```sh {\"name\":\"scale\",\"template\":\"true\",\"interactive\":\"false\"}\nkubectl scale deploy/{{ .deployment }} \\\n  --replicas={{ .replicas }}\n```\n\n" +
+		"```sh {\"name\":\"scale-prod\",\"use\":\"scale\",\"deployment\":\"api\",\"replicas\":\"3\"}\n```\n\n" +
+		"```sh {\"name\":\"scale-dev\",\"use\":\"scale\",\"deployment\":\"web\",\"replicas\":\"1\",\"interactive\":\"true\"}\n```\n\n" +
+		"```sh {\"name\":\"plain\"}\necho plain\n```\n")
+
+	node, err := New(data, identityResolver).Root()
+	require.NoError(t, err)
+
+	blocks, err := ExpandTemplates(CollectCodeBlocks(node))
+	require.NoError(t, err)
+	require.Equal(t, []string{"scale-prod", "scale-dev", "plain"}, blocks.Names())
+
+	assert.Equal(t, []string{"kubectl scale deploy/api \\", "  --replicas=3"}, blocks[0].Lines())
+	assert.Equal(t, "kubectl scale deploy/api \\\n  --replicas=3", string(blocks[0].Content()))
+	assert.Equal(t, "sh", blocks[0].Language())
+	assert.False(t, blocks[0].Interactive())
+	assert.NotContains(t, blocks[0].Attributes(), "template")
+
+	assert.Equal(t, []string{"kubectl scale deploy/web \\", "  --replicas=1"}, blocks[1].Lines())
+	assert.True(t, blocks[1].Interactive())
+
+	assert.Equal(t, []string{"echo plain"}, blocks[2].Lines())
+}
+
+func TestExpandTemplates_Errors(t *testing.T) {
+	data := []byte("```sh {\"name\":\"greet\",\"template\":\"true\"}\necho {{ .who }}\n```\n\n" +
+		"```sh {\"name\":\"missing-param\",\"use\":\"greet\"}\n```\n\n" +
+		"```sh {\"name\":\"unknown\",\"use\":\"nope\"}\n```\n\n" +
+		"```sh {\"name\":\"ok\",\"use\":\"greet\",\"who\":\"world\"}\n```\n")
+
+	node, err := New(data, identityResolver).Root()
+	require.NoError(t, err)
+
+	blocks, err := ExpandTemplates(CollectCodeBlocks(node))
+	require.ErrorContains(t, err, `failed to expand template "greet" in code block "missing-param"`)
+	require.ErrorContains(t, err, `code block "unknown" uses unknown template "nope"`)
+	var templateErr *TemplateError
+	require.ErrorAs(t, err, &templateErr)
+	assert.Equal(t, "missing-param", templateErr.Block)
+	require.Equal(t, []string{"ok"}, blocks.Names())
+	assert.Equal(t, []string{"echo world"}, blocks[0].Lines())
+}
+
+func TestExpandTemplates_Defaults(t *testing.T) {
+	data := []byte("```sh {\"name\":\"pods\",\"template\":\"true\",\"ns\":\"default\"}\nkubectl get pods -n {{ .ns }}\n```\n\n" +
+		"```sh {\"name\":\"pods-default\",\"use\":\"pods\"}\n```\n\n" +
+		"```sh {\"name\":\"pods-system\",\"use\":\"pods\",\"ns\":\"kube-system\"}\n```\n")
+
+	node, err := New(data, identityResolver).Root()
+	require.NoError(t, err)
+
+	blocks, err := ExpandTemplates(CollectCodeBlocks(node))
+	require.NoError(t, err)
+	require.Equal(t, []string{"pods-default", "pods-system"}, blocks.Names())
+	assert.Equal(t, "kubectl get pods -n default", string(blocks[0].Content()))
+	assert.Equal(t, "kubectl get pods -n kube-system", string(blocks[1].Content()))
+}
@@ -8,8 +8,15 @@ var supportedExecutables = []string{
 	"shell",
 	"zsh",
 	"go",
+	"rust",
+	"c",
+	"cpp",
+	"sql",
+	"http",
 }
 
+// IsSupported returns true if lang is supported out of the box.
+// Use Languages.IsSupported to include custom languages.
 func IsSupported(lang string) bool {
 	for _, item := range supportedExecutables {
 		if item == lang {
@@ -19,6 +26,8 @@ func IsSupported(lang string) bool {
 	return false
 }
 
+// IsShell returns true if lang is a shell supported out of the box.
+// Use Languages.IsShell to include custom languages.
 func IsShell(lang string) bool {
 	return lang == "sh" || lang == "shell" || lang == "sh-raw"
 }
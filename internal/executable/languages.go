@@ -0,0 +1,171 @@
+package executable
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// LanguagesFileName is the name of the file declaring custom languages.
+// It is looked up in the user's config directory and in the project root.
+const LanguagesFileName = "languages.yaml"
+
+// Language describes how to execute cells of a language which
+// is not supported out of the box or which should be executed differently.
+type Language struct {
+	// Interpreters is a list of candidate programs, optionally
+	// with arguments, for example "deno run". The first one
+	// found in PATH is used.
+	Interpreters []string `yaml:"interpreters"`
+	// Args are appended to the arguments of the interpreter.
+	Args []string `yaml:"args,omitempty"`
+	// FileExtension is an extension of the temporary file
+	// with the script, without the leading dot.
+	FileExtension string `yaml:"extension,omitempty"`
+	// Shell, when true, indicates that the interpreter is
+	// a POSIX-compatible shell. Scripts are executed inline
+	// and exported environment variables are collected.
+	Shell bool `yaml:"shell,omitempty"`
+}
+
+// LanguagesConfig is the format of the languages file.
+//
+//	languages:
+//	  r:
+//	    interpreters: [Rscript]
+//	    extension: R
+//	  nu:
+//	    interpreters: [nu]
+//	    shell: true
+type LanguagesConfig struct {
+	Languages map[string]Language `yaml:"languages"`
+}
+
+// Languages is a registry of custom languages. A nil *Languages
+// is valid and contains no languages.
+type Languages struct {
+	mu    sync.RWMutex
+	items map[string]Language
+}
+
+func NewLanguages() *Languages {
+	return &Languages{items: make(map[string]Language)}
+}
+
+// Clone returns a copy of the registry which
+// can be extended without affecting l.
+func (l *Languages) Clone() *Languages {
+	result := NewLanguages()
+	if l == nil {
+		return result
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for id, lang := range l.items {
+		result.items[id] = lang
+	}
+
+	return result
+}
+
+// Register registers or overrides the language with id.
+func (l *Languages) Register(id string, lang Language) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.items[strings.ToLower(id)] = lang
+}
+
+// Lookup returns the registered language with id.
+func (l *Languages) Lookup(id string) (Language, bool) {
+	if l == nil {
+		return Language{}, false
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	lang, ok := l.items[strings.ToLower(id)]
+	return lang, ok
+}
+
+// IsSupported returns true if lang is supported
+// out of the box or is registered.
+func (l *Languages) IsSupported(lang string) bool {
+	if IsSupported(lang) {
+		return true
+	}
+	_, ok := l.Lookup(lang)
+	return ok
+}
+
+// IsShell returns true if lang is a shell supported
+// out of the box or a registered language marked as a shell.
+func (l *Languages) IsShell(lang string) bool {
+	if IsShell(lang) {
+		return true
+	}
+	item, ok := l.Lookup(lang)
+	return ok && item.Shell
+}
+
+// IsShellInterpreter returns true if programName is an interpreter
+// of a registered language marked as a shell.
+func (l *Languages) IsShellInterpreter(programName string) bool {
+	if l == nil {
+		return false
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, lang := range l.items {
+		if !lang.Shell {
+			continue
+		}
+		for _, interpreter := range lang.Interpreters {
+			name, _, _ := strings.Cut(interpreter, " ")
+			if filepath.Base(name) == programName {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Load reads the languages file at path and registers
+// all declared languages. A missing file is not an error.
+func (l *Languages) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var cfg LanguagesConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return errors.Wrapf(err, "failed to parse %s", path)
+	}
+
+	for id, lang := range cfg.Languages {
+		if len(lang.Interpreters) == 0 {
+			return errors.Errorf("failed to parse %s: language %q has no interpreters", path, id)
+		}
+		lang.FileExtension = strings.TrimPrefix(lang.FileExtension, ".")
+		l.Register(id, lang)
+	}
+
+	return nil
+}
+
+// ProjectLanguagesPath returns the path of the languages
+// file declared in the project with root.
+func ProjectLanguagesPath(root string) string {
+	return filepath.Join(root, ".runme", LanguagesFileName)
+}
@@ -0,0 +1,64 @@
+package executable
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguages_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LanguagesFileName)
+
+	languages := NewLanguages()
+
+	require.NoError(t, languages.Load(path), "missing file should be ignored")
+
+	err := os.WriteFile(path, []byte(`languages:
+  r:
+    interpreters: [Rscript]
+    extension: .R
+  nu:
+    interpreters: ["nu --stdin"]
+    shell: true
+`), 0o600)
+	require.NoError(t, err)
+	require.NoError(t, languages.Load(path))
+
+	lang, ok := languages.Lookup("R")
+	require.True(t, ok)
+	assert.Equal(t, []string{"Rscript"}, lang.Interpreters)
+	assert.Equal(t, "R", lang.FileExtension)
+
+	assert.True(t, languages.IsSupported("nu"))
+	assert.True(t, languages.IsShell("nu"))
+	assert.False(t, languages.IsShell("r"))
+	assert.True(t, languages.IsShellInterpreter("nu"))
+	assert.False(t, languages.IsShellInterpreter("Rscript"))
+
+	assert.False(t, IsSupported("nu"), "built-in languages are not affected")
+
+	err = os.WriteFile(path, []byte("languages:\n  empty: {}\n"), 0o600)
+	require.NoError(t, err)
+	assert.ErrorContains(t, languages.Load(path), `language "empty" has no interpreters`)
+}
+
+func TestLanguages_Clone(t *testing.T) {
+	languages := NewLanguages()
+	languages.Register("r", Language{Interpreters: []string{"Rscript"}})
+
+	clone := languages.Clone()
+	clone.Register("nu", Language{Interpreters: []string{"nu"}})
+
+	_, ok := clone.Lookup("r")
+	assert.True(t, ok)
+	_, ok = languages.Lookup("nu")
+	assert.False(t, ok)
+
+	var empty *Languages
+	_, ok = empty.Lookup("r")
+	assert.False(t, ok)
+	assert.True(t, empty.IsSupported("sh"))
+}
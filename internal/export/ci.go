@@ -0,0 +1,320 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/executable"
+	"github.com/stateful/runme/internal/project"
+	"github.com/stateful/runme/internal/runner/client"
+)
+
+// CIOptions configures exporting tasks to a CI pipeline.
+type CIOptions struct {
+	// Name is a name of the pipeline.
+	Name string
+	// Source describes where the tasks come from.
+	Source string
+	// Dir is a root of the repository. Working directories
+	// of steps are relative to it.
+	Dir string
+	// Languages are custom languages used to run tasks.
+	Languages *executable.Languages
+}
+
+type ciStep struct {
+	Name string
+	Dir  string
+	// Shell is set for shell code blocks. Otherwise,
+	// Interpreter is set to a program running the script.
+	Shell       string
+	Interpreter string
+	Lines       []string
+	// Script runs the code block from a shell script, see shellLines.
+	Script []string
+}
+
+type ciInput struct {
+	Name string
+	document.FrontmatterInput
+}
+
+// collectCISteps converts named tasks into steps. Tasks excluded from
+// running all and tasks without a name are skipped. Skipped tasks are
+// returned as comments.
+func collectCISteps(tasks []project.Task, opts CIOptions) (steps []ciStep, inputs []ciInput, skipped []string) {
+	seenInputs := make(map[string]bool)
+
+	for _, task := range tasks {
+		block := task.CodeBlock
+
+		if block.IsUnnamed() {
+			continue
+		}
+		if block.ExcludeFromRunAll() {
+			skipped = append(skipped, fmt.Sprintf("Skipped %q which is excluded from running all cells.", block.Name()))
+			continue
+		}
+
+		fmtr, _ := block.Document().Frontmatter()
+		if fmtr == nil {
+			fmtr = &document.Frontmatter{}
+		}
+
+		for name, input := range fmtr.Inputs {
+			if !seenInputs[name] {
+				seenInputs[name] = true
+				inputs = append(inputs, ciInput{Name: name, FrontmatterInput: input})
+			}
+		}
+
+		script, ok := shellLines(block, withEnvDefaults, opts.Languages)
+		if !ok {
+			skipped = append(skipped, fmt.Sprintf("Skipped %q in %q which can't be run from a shell script.", block.Name(), block.Language()))
+			continue
+		}
+
+		step := ciStep{
+			Name:   block.Name(),
+			Script: script,
+		}
+
+		dir := client.ResolveDirectory(opts.Dir, task)
+		if rel, err := filepath.Rel(opts.Dir, dir); err == nil {
+			dir = rel
+		}
+		if dir != "." {
+			step.Dir = filepath.ToSlash(dir)
+		}
+
+		if isInlineShellBlock(block, opts.Languages) {
+			step.Shell = fmtr.Shell
+			if step.Shell == "" {
+				step.Shell = defaultShell
+			}
+			step.Lines = script
+		} else {
+			// It's known to succeed as shellLines succeeded.
+			step.Interpreter, _ = blockInterpreter(block, opts.Languages)
+			step.Lines = block.Lines()
+		}
+
+		steps = append(steps, step)
+	}
+
+	sort.Slice(inputs, func(i, j int) bool { return inputs[i].Name < inputs[j].Name })
+
+	return steps, inputs, skipped
+}
+
+// githubShells are shells which GitHub Actions supports by name.
+var githubShells = map[string]bool{
+	"bash":       true,
+	"sh":         true,
+	"pwsh":       true,
+	"powershell": true,
+	"cmd":        true,
+	"python":     true,
+}
+
+// GitHubActions writes tasks as a GitHub Actions workflow which is
+// triggered manually. Each task becomes a step of a single job and
+// inputs from the frontmatter become inputs of the workflow.
+func GitHubActions(w io.Writer, tasks []project.Task, opts CIOptions) error {
+	steps, inputs, skipped := collectCISteps(tasks, opts)
+
+	dispatch := yamlMapping()
+	if len(inputs) > 0 {
+		inputsNode := yamlMapping()
+		for _, input := range inputs {
+			node := yamlMapping()
+			if input.Description != "" {
+				node.addScalar("description", input.Description)
+			}
+			if input.Default != "" {
+				node.addScalar("default", input.Default)
+			}
+			node.add("required", yamlBool(input.Required))
+			node.addScalar("type", "string")
+			inputsNode.add(input.Name, node.Node)
+		}
+		dispatch.add("inputs", inputsNode.Node)
+	}
+
+	on := yamlMapping()
+	on.add("workflow_dispatch", dispatch.Node)
+
+	checkout := yamlMapping()
+	checkout.addScalar("uses", "actions/checkout@v4")
+
+	stepsNode := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{checkout.Node}}
+	for _, step := range steps {
+		node := yamlMapping()
+		node.addScalar("name", step.Name)
+
+		shell := step.Shell
+		switch {
+		case step.Interpreter != "":
+			shell = step.Interpreter + " {0}"
+		case !githubShells[shell]:
+			shell += " {0}"
+		}
+		node.addScalar("shell", shell)
+
+		if step.Dir != "" {
+			node.addScalar("working-directory", step.Dir)
+		}
+		lines := step.Lines
+		// Steps run in separate shells, so exported variables
+		// are passed to next steps like in a Runme session.
+		// The heredoc syntax allows multi-line values.
+		if step.Shell != "" {
+			for _, key := range exportedKeys(lines) {
+				lines = append(lines, fmt.Sprintf(
+					"{ echo '%[1]s<<%[2]s'; printf '%%s\\n' \"${%[1]s}\"; echo '%[2]s'; } >> \"$GITHUB_ENV\"",
+					key, heredocDelimiter,
+				))
+			}
+		}
+		node.add("run", yamlLiteral(strings.Join(lines, "\n")+"\n"))
+		stepsNode.Content = append(stepsNode.Content, node.Node)
+	}
+	if len(skipped) > 0 {
+		stepsNode.FootComment = strings.Join(skipped, "\n")
+	}
+
+	job := yamlMapping()
+	job.addScalar("runs-on", "ubuntu-latest")
+	if len(inputs) > 0 {
+		env := yamlMapping()
+		for _, input := range inputs {
+			env.addScalar(input.Name, fmt.Sprintf("${{ inputs.%s }}", input.Name))
+		}
+		job.add("env", env.Node)
+	}
+	job.add("steps", stepsNode)
+
+	jobs := yamlMapping()
+	jobs.add(targetName(opts.Name), job.Node)
+
+	root := yamlMapping()
+	root.addScalar("name", opts.Name)
+	root.add("on", on.Node)
+	root.add("jobs", jobs.Node)
+
+	return writeCIYAML(w, root, opts)
+}
+
+var exportKeyRegex = regexp.MustCompile(`(?:^|[;&|\s])export\s+(\w+)=`)
+
+func exportedKeys(lines []string) (result []string) {
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		for _, match := range exportKeyRegex.FindAllStringSubmatch(line, -1) {
+			if key := match[1]; !seen[key] {
+				seen[key] = true
+				result = append(result, key)
+			}
+		}
+	}
+	return result
+}
+
+// gitlabShells are shells which can run scripts of GitLab CI jobs
+// directly. Scripts run by bash or sh depending on the runner.
+var gitlabShells = map[string]bool{
+	"bash": true,
+	"sh":   true,
+}
+
+// GitLabCI writes tasks as a GitLab CI pipeline with a single job
+// which runs tasks in order. Inputs from the frontmatter become
+// variables which can be set when running the pipeline manually.
+// Shell tasks for other shells than bash and sh are passed to their
+// shell with a heredoc, hence, their exports are not visible to
+// following tasks.
+func GitLabCI(w io.Writer, tasks []project.Task, opts CIOptions) error {
+	steps, inputs, skipped := collectCISteps(tasks, opts)
+
+	root := yamlMapping()
+
+	if len(inputs) > 0 {
+		variables := yamlMapping()
+		for _, input := range inputs {
+			node := yamlMapping()
+			node.addScalar("value", input.Default)
+			if input.Description != "" {
+				node.addScalar("description", input.Description)
+			}
+			variables.add(input.Name, node.Node)
+		}
+		root.add("variables", variables.Node)
+	}
+
+	// Jobs don't share the environment, so all tasks
+	// run in a single job one by one.
+	script := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, step := range steps {
+		lines := make([]string, 0, len(step.Script)+2)
+		lines = append(lines, "# "+step.Name)
+		if step.Dir != "" {
+			lines = append(lines, fmt.Sprintf("cd \"$CI_PROJECT_DIR\"/%s", shellQuote(step.Dir)))
+		} else {
+			lines = append(lines, "cd \"$CI_PROJECT_DIR\"")
+		}
+		if step.Shell != "" && !gitlabShells[path.Base(step.Shell)] {
+			lines = append(lines, heredocLines(step.Shell, step.Script)...)
+		} else {
+			lines = append(lines, step.Script...)
+		}
+		script.Content = append(script.Content, yamlLiteral(strings.Join(lines, "\n")+"\n"))
+	}
+	if len(skipped) > 0 {
+		script.FootComment = strings.Join(skipped, "\n")
+	}
+
+	job := yamlMapping()
+	job.add("script", script)
+
+	root.add(targetName(opts.Name), job.Node)
+
+	return writeCIYAML(w, root, opts)
+}
+
+func writeCIYAML(w io.Writer, root yamlMap, opts CIOptions) error {
+	var buf bytes.Buffer
+
+	if opts.Source != "" {
+		_, _ = fmt.Fprintf(&buf, "# Generated by \"runme export\" from %s.\n\n", opts.Source)
+	}
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root.Node); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := encoder.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return errors.WithStack(err)
+}
+
+func yamlLiteral(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Style: yaml.LiteralStyle, Value: value}
+}
+
+func yamlBool(value bool) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(value)}
+}
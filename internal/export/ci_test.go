@@ -0,0 +1,165 @@
+package export
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/project"
+)
+
+func testCITasks(t *testing.T) ([]project.Task, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o755))
+
+	data := []byte(`---
+inputs:
+  NAME:
+    description: Who to greet
+    default: world
+---
+
+` + "```sh" + ` {"name":"greet"}
+export NAME="world"
+echo "hello $NAME"
+` + "```" + `
+
+` + "```python" + ` {"name":"py","cwd":"sub"}
+print("hello")
+` + "```" + `
+
+` + "```sh" + ` {"name":"manual","excludeFromRunAll":"true"}
+echo skipped
+` + "```" + `
+`)
+
+	node, err := document.New(data, identityResolver).Root()
+	require.NoError(t, err)
+
+	var tasks []project.Task
+	for _, block := range document.CollectCodeBlocks(node) {
+		tasks = append(tasks, project.Task{CodeBlock: block, DocumentPath: filepath.Join(dir, "README.md")})
+	}
+	return tasks, dir
+}
+
+func TestGitHubActions(t *testing.T) {
+	tasks, dir := testCITasks(t)
+
+	var buf bytes.Buffer
+	err := GitHubActions(&buf, tasks, CIOptions{Name: "README", Source: "README.md", Dir: dir})
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`# Generated by "runme export" from README.md.
+
+name: README
+on:
+  workflow_dispatch:
+    inputs:
+      NAME:
+        description: Who to greet
+        default: world
+        required: false
+        type: string
+jobs:
+  README:
+    runs-on: ubuntu-latest
+    env:
+      NAME: ${{ inputs.NAME }}
+    steps:
+      - uses: actions/checkout@v4
+      - name: greet
+        shell: bash
+        run: |
+          if [ -z "${NAME:-}" ]; then export NAME="world"; fi
+          echo "hello $NAME"
+          { echo 'NAME<<RUNME_EOF'; printf '%s\n' "${NAME}"; echo 'RUNME_EOF'; } >> "$GITHUB_ENV"
+      - name: py
+        shell: python3 {0}
+        working-directory: sub
+        run: |
+          print("hello")
+
+# Skipped "manual" which is excluded from running all cells.
+`,
+		buf.String(),
+	)
+}
+
+func TestGitLabCI(t *testing.T) {
+	tasks, dir := testCITasks(t)
+
+	var buf bytes.Buffer
+	err := GitLabCI(&buf, tasks, CIOptions{Name: "README", Dir: dir})
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`variables:
+  NAME:
+    value: world
+    description: Who to greet
+README:
+  script:
+    - |
+      # greet
+      cd "$CI_PROJECT_DIR"
+      if [ -z "${NAME:-}" ]; then export NAME="world"; fi
+      echo "hello $NAME"
+    - |
+      # py
+      cd "$CI_PROJECT_DIR"/'sub'
+      python3 <<'RUNME_EOF'
+      print("hello")
+      RUNME_EOF
+
+# Skipped "manual" which is excluded from running all cells.
+`,
+		buf.String(),
+	)
+}
+
+func TestGitLabCI_Shell(t *testing.T) {
+	data := []byte(`---
+shell: zsh
+---
+
+` + "```sh" + ` {"name":"greet"}
+echo "hello"
+` + "```" + `
+`)
+
+	node, err := document.New(data, identityResolver).Root()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+
+	var tasks []project.Task
+	for _, block := range document.CollectCodeBlocks(node) {
+		tasks = append(tasks, project.Task{CodeBlock: block, DocumentPath: filepath.Join(dir, "README.md")})
+	}
+
+	var buf bytes.Buffer
+	err = GitLabCI(&buf, tasks, CIOptions{Name: "README", Dir: dir})
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`README:
+  script:
+    - |
+      # greet
+      cd "$CI_PROJECT_DIR"
+      zsh <<'RUNME_EOF'
+      echo "hello"
+      RUNME_EOF
+`,
+		buf.String(),
+	)
+}
@@ -0,0 +1,302 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/yuin/goldmark/ast"
+
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/executable"
+	"github.com/stateful/runme/internal/runner"
+)
+
+const (
+	defaultShell = "bash"
+
+	// shellRootDirVar keeps the directory in which the script runs
+	// after applying the frontmatter "cwd". Cells with "cwd" attribute
+	// change the directory relatively to it.
+	shellRootDirVar = "RUNME_ROOT_DIR"
+
+	heredocDelimiter = "RUNME_EOF"
+)
+
+// ShellScript writes doc as a standalone shell script which can be run
+// without Runme. Code blocks are written in the document order with
+// section comments from headings. Shell code blocks are inlined while
+// code blocks in other languages are passed to their interpreters.
+// Unless the frontmatter disables them, prompts for exported variables
+// are translated to "read".
+func ShellScript(w io.Writer, doc *document.Document, opts ShellScriptOptions) error {
+	node, err := doc.Root()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	fmtr, err := doc.Frontmatter()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if fmtr == nil {
+		fmtr = &document.Frontmatter{}
+	}
+
+	shell := fmtr.Shell
+	if shell == "" {
+		shell = defaultShell
+	}
+
+	s := &shellScript{
+		w:           bufio.NewWriter(w),
+		skipPrompts: fmtr.SkipPrompts,
+		languages:   opts.Languages,
+	}
+
+	if strings.HasPrefix(shell, "/") {
+		s.printf("#!%s\n", shell)
+	} else {
+		s.printf("#!/usr/bin/env %s\n", shell)
+	}
+	if opts.Source != "" {
+		s.printf("# Generated by \"runme export\" from %s.\n", opts.Source)
+	}
+	s.printf("\nset -e\n")
+
+	if cwd := fmtr.Cwd; cwd != "" {
+		if opts.Dir != "" && !path.IsAbs(cwd) {
+			cwd = filepath.ToSlash(filepath.Join(opts.Dir, filepath.FromSlash(cwd)))
+		}
+		s.printf("\ncd %s\n", shellQuote(cwd))
+	}
+	if hasCodeBlockWithCwd(node) {
+		s.printf("%s=\"$(pwd)\"\n", shellRootDirVar)
+	}
+
+	s.writeNode(node)
+
+	return errors.WithStack(s.w.Flush())
+}
+
+// ShellScriptOptions configures exporting a document to a shell script.
+type ShellScriptOptions struct {
+	// Source describes where the document comes from.
+	Source string
+	// Dir is a directory of the document. A relative "cwd" from
+	// the frontmatter is resolved against it. If empty, it's relative
+	// to the directory in which the script runs.
+	Dir string
+	// Languages are custom languages used to run code blocks.
+	Languages *executable.Languages
+}
+
+type shellScript struct {
+	w           *bufio.Writer
+	skipPrompts bool
+	languages   *executable.Languages
+}
+
+func (s *shellScript) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(s.w, format, args...)
+}
+
+func (s *shellScript) writeNode(node *document.Node) {
+	for _, child := range node.Children() {
+		switch block := child.Item().(type) {
+		case *document.InnerBlock:
+			s.writeNode(child)
+		case *document.MarkdownBlock:
+			if heading, ok := block.Unwrap().(*ast.Heading); ok {
+				s.writeHeading(heading.Level, headingText(block))
+			}
+		case *document.CodeBlock:
+			s.writeCodeBlock(block)
+		}
+	}
+}
+
+func (s *shellScript) writeHeading(level int, text string) {
+	underline := "-"
+	if level == 1 {
+		underline = "="
+	}
+	s.printf("\n# %s\n# %s\n", text, strings.Repeat(underline, len(text)))
+}
+
+func (s *shellScript) writeCodeBlock(block *document.CodeBlock) {
+	s.printf("\n")
+
+	if block.ExcludeFromRunAll() {
+		s.printf("# Skipped %q which is excluded from running all cells.\n", block.Name())
+		return
+	}
+
+	if !block.IsUnnamed() {
+		s.printf("# %s\n", block.Name())
+	}
+
+	if cwd := block.Cwd(); cwd != "" {
+		if path.IsAbs(cwd) {
+			s.printf("cd %s\n", shellQuote(cwd))
+		} else {
+			s.printf("cd \"$%s\"/%s\n", shellRootDirVar, shellQuote(cwd))
+		}
+	}
+
+	var rewriteExports func([]string) []string
+	if block.PromptEnv() && !s.skipPrompts {
+		rewriteExports = withShellPrompts
+	}
+
+	lines, ok := shellLines(block, rewriteExports, s.languages)
+	if !ok {
+		s.printf("# Skipped a cell in %q which can't be run from a shell script.\n", block.Language())
+	}
+	for _, line := range lines {
+		s.printf("%s\n", line)
+	}
+
+	if block.Cwd() != "" {
+		s.printf("cd \"$%s\"\n", shellRootDirVar)
+	}
+}
+
+// shellLines returns lines which run block from a shell script. Shell
+// code blocks are inlined, with exports rewritten by rewriteExports
+// if it's not nil, and other code blocks are passed to their interpreters
+// with a heredoc. It returns false if there is no known interpreter
+// for the block's language.
+func shellLines(block *document.CodeBlock, rewriteExports func([]string) []string, languages *executable.Languages) ([]string, bool) {
+	if isInlineShellBlock(block, languages) {
+		if rewriteExports != nil {
+			return rewriteExports(block.Lines()), true
+		}
+		return block.Lines(), true
+	}
+
+	program, ok := blockInterpreter(block, languages)
+	if !ok {
+		return nil, false
+	}
+
+	return heredocLines(program, block.Lines()), true
+}
+
+// heredocLines returns lines which pass script to program with a heredoc.
+func heredocLines(program string, script []string) []string {
+	lines := make([]string, 0, len(script)+2)
+	lines = append(lines, fmt.Sprintf("%s <<'%s'", program, heredocDelimiter))
+	lines = append(lines, script...)
+	lines = append(lines, heredocDelimiter)
+	return lines
+}
+
+// blockInterpreter returns a program with arguments which runs
+// a script of the block from a file or stdin.
+func blockInterpreter(block *document.CodeBlock, languages *executable.Languages) (string, bool) {
+	if interpreter := block.Interpreter(); interpreter != "" {
+		return interpreter, true
+	}
+	name, args, ok := runner.LanguageInterpreter(block.Language(), languages)
+	if !ok {
+		return "", false
+	}
+	return strings.Join(append([]string{name}, args...), " "), true
+}
+
+func isInlineShellBlock(block *document.CodeBlock, languages *executable.Languages) bool {
+	return block.Interpreter() == "" && isInlineShell(block.Language(), languages)
+}
+
+// isInlineShell returns true if the code block can be pasted
+// into the script. Shell-like languages from the config file
+// have their own interpreters so they are not inlined.
+func isInlineShell(language string, languages *executable.Languages) bool {
+	if language == "" {
+		return true
+	}
+	if _, ok := languages.Lookup(language); ok {
+		return false
+	}
+	return runner.IsShellLanguage(language, languages)
+}
+
+func hasCodeBlockWithCwd(node *document.Node) bool {
+	for _, block := range document.CollectCodeBlocks(node) {
+		if block.Cwd() != "" && !block.ExcludeFromRunAll() {
+			return true
+		}
+	}
+	return false
+}
+
+// withShellPrompts replaces export statements with prompts
+// which are skipped if a variable is already set. The prompt
+// is written to stderr with printf followed by "read -r" instead
+// of "read -p". The "-p" option is not a part of POSIX, so dash
+// doesn't know it, and zsh uses it to read from a coprocess.
+// The generated script runs in the frontmatter's shell, which
+// can be any of them.
+func withShellPrompts(lines []string) []string {
+	result := make([]string, len(lines))
+	copy(result, lines)
+
+	for _, ev := range document.ExtractExports(lines) {
+		var b strings.Builder
+
+		label := fmt.Sprintf("Set Environment Variable %q", ev.Key)
+		if ev.HasStringValue {
+			label += fmt.Sprintf(" [%s]", ev.Value)
+		} else {
+			label += fmt.Sprintf(" (%s)", ev.Value)
+		}
+
+		_, _ = fmt.Fprintf(&b, "if [ -z \"${%[1]s+x}\" ]; then printf '%%s' %[2]s >&2; read -r %[1]s", ev.Key, shellQuote(label+": "))
+		if ev.HasStringValue {
+			_, _ = fmt.Fprintf(&b, "; %[1]s=\"${%[1]s:-%[2]s}\"", ev.Key, escapeDoubleQuoted(ev.Value))
+		}
+		_, _ = fmt.Fprintf(&b, "; fi; export %s", ev.Key)
+
+		result[ev.LineNumber] = b.String()
+	}
+
+	return result
+}
+
+// withEnvDefaults makes export statements conditional so that variables
+// which are already set are not overridden. It's used when there is
+// no one to prompt, for example, in CI.
+func withEnvDefaults(lines []string) []string {
+	result := make([]string, len(lines))
+	copy(result, lines)
+
+	for _, ev := range document.ExtractExports(lines) {
+		statement := strings.TrimLeft(ev.Match, "\n")
+		result[ev.LineNumber] = strings.Replace(
+			result[ev.LineNumber],
+			statement,
+			fmt.Sprintf("if [ -z \"${%s:-}\" ]; then %s; fi", ev.Key, statement),
+			1,
+		)
+	}
+
+	return result
+}
+
+func escapeDoubleQuoted(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`", "}", `\}`).Replace(s)
+}
+
+func headingText(block *document.MarkdownBlock) string {
+	line, _, _ := strings.Cut(string(block.Value()), "\n")
+	return strings.TrimSpace(strings.Trim(strings.TrimSpace(line), "#"))
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
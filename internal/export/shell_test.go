@@ -0,0 +1,137 @@
+package export
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/document/identity"
+)
+
+var identityResolver = identity.NewResolver(identity.UnspecifiedLifecycleIdentity)
+
+func TestShellScript(t *testing.T) {
+	data := []byte(`---
+shell: zsh
+cwd: ..
+---
+
+# Setup
+
+` + "```sh" + ` {"name":"install","cwd":"app"}
+export NAME="world"
+export TOKEN=Your token
+echo "hello $NAME"
+` + "```" + `
+
+## Python
+
+` + "```python" + ` {"name":"py"}
+print("hello")
+` + "```" + `
+
+` + "```sh" + ` {"name":"manual","excludeFromRunAll":"true"}
+echo skipped
+` + "```" + `
+`)
+
+	var buf bytes.Buffer
+	err := ShellScript(&buf, document.New(data, identityResolver), ShellScriptOptions{Source: "README.md"})
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`#!/usr/bin/env zsh
+# Generated by "runme export" from README.md.
+
+set -e
+
+cd '..'
+RUNME_ROOT_DIR="$(pwd)"
+
+# Setup
+# =====
+
+# install
+cd "$RUNME_ROOT_DIR"/'app'
+if [ -z "${NAME+x}" ]; then printf '%s' 'Set Environment Variable "NAME" [world]: ' >&2; read -r NAME; NAME="${NAME:-world}"; fi; export NAME
+if [ -z "${TOKEN+x}" ]; then printf '%s' 'Set Environment Variable "TOKEN" (Your token): ' >&2; read -r TOKEN; fi; export TOKEN
+echo "hello $NAME"
+cd "$RUNME_ROOT_DIR"
+
+# Python
+# ------
+
+# py
+python3 <<'RUNME_EOF'
+print("hello")
+RUNME_EOF
+
+# Skipped "manual" which is excluded from running all cells.
+`,
+		buf.String(),
+	)
+}
+
+func TestShellScript_Dir(t *testing.T) {
+	data := []byte(`---
+cwd: ../app
+---
+
+` + "```sh" + `
+make
+` + "```" + `
+`)
+
+	var buf bytes.Buffer
+	err := ShellScript(&buf, document.New(data, identityResolver), ShellScriptOptions{Dir: "/src/docs"})
+	require.NoError(t, err)
+	assert.Equal(t, "#!/usr/bin/env bash\n\nset -e\n\ncd '/src/app'\n\nmake\n", buf.String())
+}
+
+func TestShellScript_SkipPrompts(t *testing.T) {
+	data := []byte(`---
+skipPrompts: true
+---
+
+` + "```sh" + `
+export NAME="world"
+` + "```" + `
+`)
+
+	var buf bytes.Buffer
+	err := ShellScript(&buf, document.New(data, identityResolver), ShellScriptOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "#!/usr/bin/env bash\n\nset -e\n\nexport NAME=\"world\"\n", buf.String())
+}
+
+func Test_withShellPrompts(t *testing.T) {
+	lines := withShellPrompts([]string{`export NAME="world"`, "echo $NAME"})
+	assert.Equal(
+		t,
+		[]string{
+			`if [ -z "${NAME+x}" ]; then printf '%s' 'Set Environment Variable "NAME" [world]: ' >&2; read -r NAME; NAME="${NAME:-world}"; fi; export NAME`,
+			"echo $NAME",
+		},
+		lines,
+	)
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh is not available")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(sh, "-c", strings.Join(lines, "\n"))
+	cmd.Env = []string{}
+	cmd.Stdin = strings.NewReader("runme\n")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	require.NoError(t, cmd.Run(), stderr.String())
+	assert.Equal(t, "runme\n", stdout.String())
+	assert.Equal(t, `Set Environment Variable "NAME" [world]: `, stderr.String())
+}
@@ -0,0 +1,412 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/stateful/runme/internal/executable"
+	"github.com/stateful/runme/internal/project"
+	"github.com/stateful/runme/internal/runner/client"
+)
+
+const generatedFromTasksComment = "# Generated by \"runme export\" from project tasks."
+
+// TasksOptions configures exporting project tasks.
+type TasksOptions struct {
+	// Dir is a directory from which the result is used.
+	// Working directories of inlined tasks are relative to it.
+	Dir string
+	// Root is a root directory of the project. Tasks with the same
+	// name are run using paths of their documents relative to it.
+	Root string
+	// Inline writes scripts of shell tasks directly
+	// instead of calling "runme run".
+	Inline bool
+	// Languages are custom languages used to run tasks.
+	Languages *executable.Languages
+}
+
+// target is a task in the build tool's terms.
+type target struct {
+	Name        string
+	Description string
+	Deps        []string
+	// Command runs the task using Runme.
+	Command string
+	// Dir and Script are set only if the task is inlined.
+	Dir    string
+	Script []string
+}
+
+type category struct {
+	// Name is a name of the target which runs all tasks in the category.
+	Name    string
+	Group   string
+	Targets []string
+}
+
+var invalidTargetNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+func targetName(name string) string {
+	name = strings.Trim(invalidTargetNameChars.ReplaceAllString(name, "-"), "-")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "task-" + name
+	}
+	return name
+}
+
+// collectTargets converts named tasks into targets. Tasks without a name
+// are skipped as there is no stable way to refer to them.
+func collectTargets(tasks []project.Task, opts TasksOptions) ([]target, []category, error) {
+	var named []project.Task
+	for _, task := range tasks {
+		if !task.CodeBlock.IsUnnamed() {
+			named = append(named, task)
+		}
+	}
+
+	nameCount := make(map[string]int)
+	for _, task := range named {
+		nameCount[task.CodeBlock.Name()]++
+	}
+
+	var (
+		targets      = make([]target, 0, len(named))
+		targetByName = make(map[string]string)
+		usedNames    = make(map[string]int)
+		categories   []category
+		categoryIdx  = make(map[string]int)
+	)
+
+	for _, task := range named {
+		block := task.CodeBlock
+
+		name := targetName(block.Name())
+		if usedNames[name]++; usedNames[name] > 1 {
+			name += "-" + strconv.Itoa(usedNames[name])
+		}
+		if _, ok := targetByName[block.Name()]; !ok {
+			targetByName[block.Name()] = name
+		}
+
+		runName := block.Name()
+		// Tasks with the same name in different documents
+		// are disambiguated by a file query.
+		if nameCount[block.Name()] > 1 {
+			runName = documentQuery(opts.Root, task.DocumentPath) + "/" + runName
+		}
+
+		t := target{
+			Name:        name,
+			Description: firstLine(block.Intro()),
+			Command:     "runme run " + shellQuoteIfNeeded(runName),
+		}
+
+		if opts.Inline && isInlineShellBlock(block, opts.Languages) {
+			fmtr, _ := block.Document().Frontmatter()
+
+			lines := block.Lines()
+			if block.PromptEnv() && (fmtr == nil || !fmtr.SkipPrompts) {
+				lines = withShellPrompts(lines)
+			}
+			t.Script = lines
+
+			dir := client.ResolveDirectory(opts.Dir, task)
+			if rel, err := filepath.Rel(opts.Dir, dir); err == nil {
+				dir = rel
+			}
+			if dir != "." {
+				t.Dir = filepath.ToSlash(dir)
+			}
+		}
+
+		for _, c := range strings.Split(block.Category(), ",") {
+			if c = strings.TrimSpace(c); c == "" {
+				continue
+			}
+			idx, ok := categoryIdx[c]
+			if !ok {
+				idx = len(categories)
+				categoryIdx[c] = idx
+				categories = append(categories, category{Name: c, Group: c})
+			}
+			categories[idx].Targets = append(categories[idx].Targets, name)
+		}
+
+		targets = append(targets, t)
+	}
+
+	for i, task := range named {
+		for _, dep := range task.CodeBlock.DependsOn() {
+			name, ok := targetByName[dep]
+			if !ok {
+				return nil, nil, errors.Errorf("task %q depends on unknown task %q", task.CodeBlock.Name(), dep)
+			}
+			targets[i].Deps = append(targets[i].Deps, name)
+		}
+	}
+
+	// Category targets must not override tasks.
+	for i, c := range categories {
+		name := targetName(c.Name)
+		if usedNames[name] > 0 {
+			name = "category-" + name
+		}
+		categories[i].Name = name
+	}
+
+	return targets, categories, nil
+}
+
+// documentQuery returns a file query of "runme run" which matches
+// only the document at path. The query is a regular expression
+// matched against the absolute path, so the path relative to root
+// is escaped and anchored.
+func documentQuery(root, path string) string {
+	rel := filepath.Base(path)
+	if root != "" {
+		if r, err := filepath.Rel(root, path); err == nil {
+			rel = r
+		}
+	}
+	return "/" + regexp.QuoteMeta(filepath.ToSlash(rel)) + "$"
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+var shellSafeWord = regexp.MustCompile(`^[a-zA-Z0-9_./:=-]+$`)
+
+func shellQuoteIfNeeded(s string) string {
+	if shellSafeWord.MatchString(s) {
+		return s
+	}
+	return shellQuote(s)
+}
+
+// Makefile writes tasks as targets of a Makefile. Categories become
+// targets which depend on all tasks in the category.
+func Makefile(w io.Writer, tasks []project.Task, opts TasksOptions) error {
+	targets, categories, err := collectTargets(tasks, opts)
+	if err != nil {
+		return err
+	}
+
+	b := bufio.NewWriter(w)
+
+	_, _ = fmt.Fprintf(b, "%s\n\n", generatedFromTasksComment)
+	if opts.Inline {
+		_, _ = fmt.Fprint(b, "SHELL := bash\n.SHELLFLAGS := -ec\n.ONESHELL:\n\n")
+	}
+	_, _ = fmt.Fprint(b, "RUNME ?= runme\n")
+
+	phony := make([]string, 0, len(targets)+len(categories))
+	for _, t := range targets {
+		phony = append(phony, t.Name)
+	}
+	for _, c := range categories {
+		phony = append(phony, c.Name)
+	}
+	_, _ = fmt.Fprintf(b, "\n.PHONY: %s\n", strings.Join(phony, " "))
+
+	escape := strings.NewReplacer("$", "$$").Replace
+
+	for _, t := range targets {
+		_, _ = fmt.Fprint(b, "\n")
+		if t.Description != "" {
+			_, _ = fmt.Fprintf(b, "# %s\n", t.Description)
+		}
+		_, _ = fmt.Fprintf(b, "%s:%s\n", t.Name, prefixEach(" ", t.Deps))
+
+		if t.Script == nil {
+			_, _ = fmt.Fprintf(b, "\t$(RUNME) %s\n", escape(strings.TrimPrefix(t.Command, "runme ")))
+			continue
+		}
+
+		if t.Dir != "" {
+			_, _ = fmt.Fprintf(b, "\tcd %s\n", shellQuote(t.Dir))
+		}
+		for _, line := range t.Script {
+			_, _ = fmt.Fprintf(b, "\t%s\n", escape(line))
+		}
+	}
+
+	for _, c := range categories {
+		_, _ = fmt.Fprintf(b, "\n%s:%s\n", c.Name, prefixEach(" ", c.Targets))
+	}
+
+	return errors.WithStack(b.Flush())
+}
+
+// Justfile writes tasks as recipes of a Justfile. Categories
+// are preserved as recipe groups and become recipes which depend
+// on all tasks in the category.
+func Justfile(w io.Writer, tasks []project.Task, opts TasksOptions) error {
+	targets, categories, err := collectTargets(tasks, opts)
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[string][]string)
+	for _, c := range categories {
+		for _, name := range c.Targets {
+			groups[name] = append(groups[name], c.Group)
+		}
+	}
+
+	b := bufio.NewWriter(w)
+
+	_, _ = fmt.Fprintf(b, "%s\n", generatedFromTasksComment)
+
+	escape := strings.NewReplacer("{{", "{{{{").Replace
+
+	for _, t := range targets {
+		_, _ = fmt.Fprint(b, "\n")
+		if t.Description != "" {
+			_, _ = fmt.Fprintf(b, "# %s\n", t.Description)
+		}
+		for _, group := range groups[t.Name] {
+			_, _ = fmt.Fprintf(b, "[group(%s)]\n", strconv.Quote(group))
+		}
+		_, _ = fmt.Fprintf(b, "%s:%s\n", t.Name, prefixEach(" ", t.Deps))
+
+		if t.Script == nil {
+			_, _ = fmt.Fprintf(b, "    %s\n", t.Command)
+			continue
+		}
+
+		_, _ = fmt.Fprint(b, "    #!/usr/bin/env bash\n    set -e\n")
+		if t.Dir != "" {
+			_, _ = fmt.Fprintf(b, "    cd %s\n", shellQuote(t.Dir))
+		}
+		for _, line := range t.Script {
+			_, _ = fmt.Fprintf(b, "    %s\n", escape(line))
+		}
+	}
+
+	for _, c := range categories {
+		_, _ = fmt.Fprintf(b, "\n# Run all tasks in the %q category.\n", c.Group)
+		_, _ = fmt.Fprintf(b, "%s:%s\n", c.Name, prefixEach(" ", c.Targets))
+	}
+
+	return errors.WithStack(b.Flush())
+}
+
+// Taskfile writes tasks as a Taskfile (https://taskfile.dev).
+// Categories become tasks which run all tasks in the category.
+func Taskfile(w io.Writer, tasks []project.Task, opts TasksOptions) error {
+	targets, categories, err := collectTargets(tasks, opts)
+	if err != nil {
+		return err
+	}
+
+	escape := strings.NewReplacer("{{", `{{"{{"}}`).Replace
+
+	tasksNode := yamlMapping()
+
+	for _, t := range targets {
+		task := yamlMapping()
+
+		if t.Description != "" {
+			task.addScalar("desc", t.Description)
+		}
+		if len(t.Deps) > 0 {
+			task.add("deps", yamlSequence(t.Deps...))
+		}
+
+		if t.Script == nil {
+			task.add("cmds", yamlSequence(t.Command))
+		} else {
+			if t.Dir != "" {
+				task.addScalar("dir", t.Dir)
+			}
+			script := yamlLiteral(escape(strings.Join(t.Script, "\n")) + "\n")
+			task.add("cmds", &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{script}})
+		}
+
+		tasksNode.add(t.Name, task.Node)
+	}
+
+	for _, c := range categories {
+		cmds := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, name := range c.Targets {
+			cmd := yamlMapping()
+			cmd.addScalar("task", name)
+			cmds.Content = append(cmds.Content, cmd.Node)
+		}
+
+		task := yamlMapping()
+		task.addScalar("desc", fmt.Sprintf("Run all tasks in the %q category.", c.Group))
+		task.add("cmds", cmds)
+
+		tasksNode.add(c.Name, task.Node)
+	}
+
+	root := yamlMapping()
+	root.add("version", &yaml.Node{Kind: yaml.ScalarNode, Style: yaml.DoubleQuotedStyle, Value: "3"})
+	root.add("tasks", tasksNode.Node)
+
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "%s\n\n", generatedFromTasksComment)
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root.Node); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := encoder.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return errors.WithStack(err)
+}
+
+func prefixEach(prefix string, items []string) string {
+	var b strings.Builder
+	for _, item := range items {
+		b.WriteString(prefix)
+		b.WriteString(item)
+	}
+	return b.String()
+}
+
+// yamlMap builds a YAML mapping preserving the order of keys.
+type yamlMap struct {
+	*yaml.Node
+}
+
+func yamlMapping() yamlMap {
+	return yamlMap{&yaml.Node{Kind: yaml.MappingNode}}
+}
+
+func (m yamlMap) add(key string, value *yaml.Node) {
+	m.Content = append(m.Content, yamlString(key), value)
+}
+
+func (m yamlMap) addScalar(key, value string) {
+	m.add(key, yamlString(value))
+}
+
+func yamlSequence(values ...string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, v := range values {
+		node.Content = append(node.Content, yamlString(v))
+	}
+	return node
+}
+
+func yamlString(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
@@ -0,0 +1,164 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/project"
+)
+
+func testTasks(t *testing.T) []project.Task {
+	t.Helper()
+
+	data := []byte(`# Project
+
+Install dependencies.
+
+` + "```sh" + ` {"name":"install","category":"setup"}
+echo "installing $HOME"
+` + "```" + `
+
+Build the app.
+
+` + "```sh" + ` {"name":"build","category":"ci,setup","dependsOn":"install"}
+echo build
+` + "```" + `
+
+` + "```sh" + `
+echo unnamed
+` + "```" + `
+`)
+
+	node, err := document.New(data, identityResolver).Root()
+	require.NoError(t, err)
+
+	var tasks []project.Task
+	for _, block := range document.CollectCodeBlocks(node) {
+		tasks = append(tasks, project.Task{CodeBlock: block, DocumentPath: "/project/README.md"})
+	}
+	return tasks
+}
+
+func TestMakefile(t *testing.T) {
+	var buf bytes.Buffer
+	err := Makefile(&buf, testTasks(t), TasksOptions{Dir: "/project"})
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`# Generated by "runme export" from project tasks.
+
+RUNME ?= runme
+
+.PHONY: install build setup ci
+
+# Install dependencies.
+install:
+	$(RUNME) run install
+
+# Build the app.
+build: install
+	$(RUNME) run build
+
+setup: install build
+
+ci: build
+`,
+		buf.String(),
+	)
+
+	buf.Reset()
+	err = Makefile(&buf, testTasks(t), TasksOptions{Dir: "/project", Inline: true})
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), ".ONESHELL:\n")
+	assert.Contains(t, buf.String(), "install:\n\techo \"installing $$HOME\"\n")
+}
+
+func TestJustfile(t *testing.T) {
+	var buf bytes.Buffer
+	err := Justfile(&buf, testTasks(t), TasksOptions{Dir: "/project"})
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`# Generated by "runme export" from project tasks.
+
+# Install dependencies.
+[group("setup")]
+install:
+    runme run install
+
+# Build the app.
+[group("setup")]
+[group("ci")]
+build: install
+    runme run build
+
+# Run all tasks in the "setup" category.
+setup: install build
+
+# Run all tasks in the "ci" category.
+ci: build
+`,
+		buf.String(),
+	)
+}
+
+func TestTaskfile(t *testing.T) {
+	var buf bytes.Buffer
+	err := Taskfile(&buf, testTasks(t), TasksOptions{Dir: "/project", Inline: true})
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`# Generated by "runme export" from project tasks.
+
+version: "3"
+tasks:
+  install:
+    desc: Install dependencies.
+    cmds:
+      - |
+        echo "installing $HOME"
+  build:
+    desc: Build the app.
+    deps:
+      - install
+    cmds:
+      - |
+        echo build
+  setup:
+    desc: Run all tasks in the "setup" category.
+    cmds:
+      - task: install
+      - task: build
+  ci:
+    desc: Run all tasks in the "ci" category.
+    cmds:
+      - task: build
+`,
+		buf.String(),
+	)
+}
+
+func TestTasks_DuplicateNames(t *testing.T) {
+	tasks := testTasks(t)[:1]
+	other := testTasks(t)[0]
+	other.DocumentPath = "/project/docs/v1.0/README.md"
+	tasks = append(tasks, other)
+
+	var buf bytes.Buffer
+	err := Makefile(&buf, tasks, TasksOptions{Dir: "/project", Root: "/project"})
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "install:\n\t$(RUNME) run '/README\\.md$$/install'\n")
+	assert.Contains(t, buf.String(), "install-2:\n\t$(RUNME) run '/docs/v1\\.0/README\\.md$$/install'\n")
+}
+
+func TestTasks_UnknownDependency(t *testing.T) {
+	tasks := testTasks(t)
+	tasks[0].CodeBlock.Attributes()["dependsOn"] = "missing"
+
+	err := Makefile(new(bytes.Buffer), tasks, TasksOptions{})
+	assert.ErrorContains(t, err, `task "install" depends on unknown task "missing"`)
+}
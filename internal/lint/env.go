@@ -0,0 +1,142 @@
+package lint
+
+import (
+	"regexp"
+	"strings"
+)
+
+// wellKnownVars are set by the shell or the system,
+// so they don't need to be declared.
+var wellKnownVars = map[string]bool{
+	"HOME":            true,
+	"PATH":            true,
+	"USER":            true,
+	"LOGNAME":         true,
+	"PWD":             true,
+	"OLDPWD":          true,
+	"SHELL":           true,
+	"TERM":            true,
+	"LANG":            true,
+	"TMPDIR":          true,
+	"HOSTNAME":        true,
+	"EDITOR":          true,
+	"RANDOM":          true,
+	"SECONDS":         true,
+	"LINENO":          true,
+	"UID":             true,
+	"EUID":            true,
+	"PPID":            true,
+	"IFS":             true,
+	"OSTYPE":          true,
+	"BASH_SOURCE":     true,
+	"BASH_VERSION":    true,
+	"FUNCNAME":        true,
+	"PIPESTATUS":      true,
+	"REPLY":           true,
+	"OPTARG":          true,
+	"OPTIND":          true,
+	"XDG_CONFIG_HOME": true,
+	"XDG_DATA_HOME":   true,
+	"XDG_CACHE_HOME":  true,
+}
+
+var (
+	varUsageRe = regexp.MustCompile(`\$(?:\{[#!]?([A-Za-z_][A-Za-z0-9_]*)|([A-Za-z_][A-Za-z0-9_]*))`)
+
+	varAssignmentRe  = regexp.MustCompile(`(?:^|[\s;&|(])(?:export\s+|local\s+|declare\s+(?:-\w+\s+)*|readonly\s+)?([A-Za-z_][A-Za-z0-9_]*)(?:\[[^\]]*\])?\+?=`)
+	varDeclarationRe = regexp.MustCompile(`(?:^|[\s;&|(])(?:export|local|declare|readonly|typeset|unset)((?:\s+-\w+)*(?:\s+[A-Za-z_][A-Za-z0-9_]*)+)`)
+	varReadRe        = regexp.MustCompile(`(?:^|[\s;&|(])read((?:\s+-[A-Za-z]+(?:\s+[^-\s]\S*)?)*(?:\s+[A-Za-z_][A-Za-z0-9_]*)+)`)
+	varForRe         = regexp.MustCompile(`(?:^|[\s;&|(])(?:for|select)\s+([A-Za-z_][A-Za-z0-9_]*)\s+in\b`)
+
+	identifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// assignedVars returns names of variables assigned, exported
+// or read in shell code, for example, "export FOO=bar".
+func assignedVars(lines []string) (result []string) {
+	for _, line := range lines {
+		line = stripShellComment(line)
+
+		for _, m := range varAssignmentRe.FindAllStringSubmatch(line, -1) {
+			result = append(result, m[1])
+		}
+		for _, re := range []*regexp.Regexp{varDeclarationRe, varReadRe} {
+			for _, m := range re.FindAllStringSubmatch(line, -1) {
+				for _, field := range strings.Fields(m[1]) {
+					if identifierRe.MatchString(field) {
+						result = append(result, field)
+					}
+				}
+			}
+		}
+		for _, m := range varForRe.FindAllStringSubmatch(line, -1) {
+			result = append(result, m[1])
+		}
+	}
+	return result
+}
+
+// usedVars returns names of variables expanded in shell code,
+// for example, "$FOO" or "${FOO:-bar}". Expansions in comments
+// and single-quoted strings are skipped.
+func usedVars(lines []string) (result []string) {
+	for _, line := range lines {
+		line = stripSingleQuoted(stripShellComment(line))
+
+		for _, m := range varUsageRe.FindAllStringSubmatch(line, -1) {
+			name := m[1]
+			if name == "" {
+				name = m[2]
+			}
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// stripShellComment removes a comment from a line of shell code.
+// Unlike shell.StripComments, it keeps "#" which doesn't start a word,
+// for example, in "${#ARR[@]}" or "$#".
+func stripShellComment(line string) string {
+	inSingle, inDouble := false, false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && !inSingle:
+			i++
+		case c == '\'' && !inDouble:
+			inSingle = !inSingle
+		case c == '"' && !inSingle:
+			inDouble = !inDouble
+		case c == '#' && !inSingle && !inDouble:
+			if i == 0 || line[i-1] == ' ' || line[i-1] == '\t' || line[i-1] == ';' {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+// stripSingleQuoted removes strings in single quotes
+// which are not expanded by the shell.
+func stripSingleQuoted(line string) string {
+	var b strings.Builder
+	inSingle, inDouble := false, false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && !inSingle:
+			// Escaped characters, like "\$", are not expanded.
+			i++
+			continue
+		case c == '\'' && !inDouble:
+			inSingle = !inSingle
+			continue
+		case c == '"' && !inSingle:
+			inDouble = !inDouble
+		}
+		if !inSingle {
+			_ = b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,467 @@
+// Package lint checks runbooks for problems which make
+// code blocks hard to address or cause them to fail when run.
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/document/identity"
+	"github.com/stateful/runme/internal/executable"
+	"github.com/stateful/runme/internal/ulid"
+)
+
+// Severity is a level of an issue. The values
+// are the same as levels of results in SARIF.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityNote    Severity = "note"
+)
+
+// Rule is a check performed by the linter.
+type Rule struct {
+	ID          string
+	Description string
+	Severity    Severity
+}
+
+var (
+	RuleUnnamedBlock = Rule{
+		ID:          "unnamed-block",
+		Description: "Code blocks should have a name, so they can be run by name.",
+		Severity:    SeverityWarning,
+	}
+	RuleDuplicateName = Rule{
+		ID:          "duplicate-name",
+		Description: "Names of code blocks must be unique within a document.",
+		Severity:    SeverityError,
+	}
+	RuleUnknownLanguage = Rule{
+		ID:          "unknown-language",
+		Description: "Code blocks should have a language which can be run.",
+		Severity:    SeverityWarning,
+	}
+	RuleMalformedAttributes = Rule{
+		ID:          "malformed-attributes",
+		Description: "Attributes of code blocks must be valid JSON or key=value pairs.",
+		Severity:    SeverityError,
+	}
+	RuleInvalidAttribute = Rule{
+		ID:          "invalid-attribute",
+		Description: "Attributes of code blocks should be known, have valid values and not be deprecated.",
+		Severity:    SeverityWarning,
+	}
+	RuleMissingFrontmatterID = Rule{
+		ID:          "missing-frontmatter-id",
+		Description: "Documents should have an ID in the frontmatter, so they can be tracked across changes.",
+		Severity:    SeverityNote,
+	}
+	RuleUndeclaredEnv = Rule{
+		ID:          "undeclared-env",
+		Description: "Environment variables used in code blocks should be declared as inputs in the frontmatter, exported by a code block or set in an env file.",
+		Severity:    SeverityWarning,
+	}
+	RuleMissingCwd = Rule{
+		ID:          "missing-cwd",
+		Description: "The working directory of code blocks must exist.",
+		Severity:    SeverityError,
+	}
+	RuleFailedImport = Rule{
+		ID:          "failed-import",
+		Description: "Imported documents and sections must exist and must not import each other in a cycle.",
+		Severity:    SeverityError,
+	}
+	RuleFailedTemplate = Rule{
+		ID:          "failed-template",
+		Description: "Code blocks using a template must name an existing template and set all its parameters.",
+		Severity:    SeverityError,
+	}
+)
+
+// Rules returns all rules in the order they are checked.
+func Rules() []Rule {
+	return []Rule{
+		RuleUnnamedBlock,
+		RuleDuplicateName,
+		RuleUnknownLanguage,
+		RuleMalformedAttributes,
+		RuleInvalidAttribute,
+		RuleMissingFrontmatterID,
+		RuleUndeclaredEnv,
+		RuleMissingCwd,
+		RuleFailedImport,
+		RuleFailedTemplate,
+	}
+}
+
+// Issue is a problem found in a document.
+type Issue struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Path     string   `json:"path"`
+	// Line is a 1-based line number. It's 0 if the line is unknown,
+	// for example, for documents converted from other formats.
+	Line int `json:"line,omitempty"`
+	// Block is a name of the code block the issue is about.
+	Block string `json:"block,omitempty"`
+}
+
+func (i Issue) String() string {
+	location := i.Path
+	if i.Line > 0 {
+		location = fmt.Sprintf("%s:%d", i.Path, i.Line)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", location, i.Severity, i.Message, i.Rule)
+}
+
+// Options configure the linter.
+type Options struct {
+	// Dir is a directory against which working directories of code
+	// blocks are resolved. It defaults to the directory of the path.
+	Dir string
+	// Env are names of environment variables which are declared
+	// outside of documents, for example, in project env files.
+	Env []string
+	// NamingStrategy is used to name code blocks without the "name" attribute.
+	NamingStrategy document.NamingStrategy
+	// Converted is true when the data was converted from another
+	// format, like reStructuredText. Line numbers would be misleading
+	// in such a case and the frontmatter is not checked.
+	Converted bool
+	// Languages are custom languages which are supported
+	// in addition to the built-in ones.
+	Languages *executable.Languages
+}
+
+// Lint checks a Markdown document and returns found issues
+// sorted by line. Path is used in issues and to resolve
+// working directories of code blocks.
+//
+// Code blocks imported from other documents are checked too
+// and their issues are reported with the path of the document
+// they come from. Code blocks using templates are checked
+// after they are expanded.
+func Lint(path string, data []byte, opts Options) ([]Issue, error) {
+	doc := document.New(data, identity.NewResolver(identity.UnspecifiedLifecycleIdentity))
+	doc.SetNamingStrategy(opts.NamingStrategy)
+	doc.SetMDX(document.IsMDX(path))
+
+	if _, err := doc.Root(); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+
+	fmtr, err := doc.Frontmatter()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse frontmatter of %s", path)
+	}
+
+	dir := opts.Dir
+	if dir == "" {
+		dir = filepath.Dir(path)
+	}
+
+	l := &linter{
+		path:    path,
+		dir:     dir,
+		source:  data,
+		doc:     doc,
+		opts:    opts,
+		sources: make(map[string][]byte),
+	}
+
+	blocks, err := document.CollectCodeBlocksWithImports(doc, filepath.Join(dir, filepath.Base(path)))
+	var importErr *document.ImportError
+	if errors.As(err, &importErr) {
+		for _, err := range importErr.Errs {
+			l.report(RuleFailedImport, nil, "%s", err)
+		}
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+
+	expanded, err := document.ExpandTemplates(blocks)
+	l.reportTemplateErrors(blocks, err)
+
+	l.checkNames(blocks)
+	l.checkLanguages(expanded)
+	l.checkAttributes(blocks)
+	l.checkFrontmatter(fmtr)
+	l.checkEnv(expanded, fmtr)
+	l.checkCwd(expanded, fmtr)
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		return l.issues[i].Line < l.issues[j].Line
+	})
+
+	return l.issues, nil
+}
+
+type linter struct {
+	path   string
+	dir    string
+	source []byte
+	doc    *document.Document
+	opts   Options
+	issues []Issue
+	// sources are contents of imported documents by their paths.
+	sources map[string][]byte
+}
+
+func (l *linter) report(rule Rule, block *document.CodeBlock, format string, args ...any) {
+	issue := Issue{
+		Rule:     rule.ID,
+		Severity: rule.Severity,
+		Message:  fmt.Sprintf(format, args...),
+		Path:     l.path,
+	}
+	if block != nil {
+		issue.Block = block.Name()
+		if path := block.Document().Path(); path != "" {
+			issue.Path = l.importedPath(path)
+		}
+		issue.Line = l.line(block)
+	}
+	l.issues = append(l.issues, issue)
+}
+
+// importedPath returns the path of an imported document
+// relative to the directory of the linted document.
+func (l *linter) importedPath(path string) string {
+	dir, err := filepath.Abs(l.dir)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.Join(filepath.Dir(l.path), rel)
+}
+
+// line returns a line number of the opening fence of the code block.
+func (l *linter) line(block *document.CodeBlock) int {
+	if len(block.Lines()) == 0 {
+		return 0
+	}
+
+	doc, source := l.doc, l.source
+	if path := block.Document().Path(); path != "" {
+		doc, source = block.Document(), l.importedSource(path)
+	} else if l.opts.Converted {
+		return 0
+	}
+
+	offset := block.TextRange().Start + doc.ContentOffset()
+	if offset < 0 || offset > len(source) {
+		return 0
+	}
+	// The first line of code follows the opening fence.
+	return bytes.Count(source[:offset], []byte{'\n'})
+}
+
+func (l *linter) importedSource(path string) []byte {
+	source, ok := l.sources[path]
+	if !ok {
+		// The document was read when it was imported.
+		source, _ = os.ReadFile(path)
+		l.sources[path] = source
+	}
+	return source
+}
+
+// reportTemplateErrors reports errors returned by document.ExpandTemplates
+// for the code blocks which couldn't be expanded.
+func (l *linter) reportTemplateErrors(blocks document.CodeBlocks, err error) {
+	if err == nil {
+		return
+	}
+
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	for _, err := range errs {
+		var templateErr *document.TemplateError
+		if !errors.As(err, &templateErr) {
+			l.report(RuleFailedTemplate, nil, "%s", err)
+			continue
+		}
+		l.report(RuleFailedTemplate, blocks.Lookup(templateErr.Block), "%s", templateErr)
+	}
+}
+
+func (l *linter) checkNames(blocks document.CodeBlocks) {
+	// Names are unique within a document.
+	type documentName struct {
+		doc  *document.Document
+		name string
+	}
+	seen := make(map[documentName]bool)
+
+	for _, block := range blocks {
+		if block.IsUnnamed() {
+			switch {
+			case block.IsTemplate() || block.Use() != "":
+			case block.Name() == "":
+				l.report(RuleUnnamedBlock, block, "code block has no name")
+			default:
+				l.report(RuleUnnamedBlock, block, "code block has no name; it's named %q", block.Name())
+			}
+			continue
+		}
+
+		name := block.Attributes().Get("name")
+		key := documentName{doc: block.Document(), name: name}
+		if seen[key] {
+			l.report(RuleDuplicateName, block, "name %q is already used by another code block; it's renamed to %q", name, block.Name())
+		}
+		seen[key] = true
+	}
+}
+
+func (l *linter) checkLanguages(blocks document.CodeBlocks) {
+	for _, block := range blocks {
+		if !block.IsUnknown() || l.opts.Languages.IsSupported(block.Language()) {
+			continue
+		}
+		if block.Language() == "" {
+			l.report(RuleUnknownLanguage, block, "code block has no language")
+		} else {
+			l.report(RuleUnknownLanguage, block, "language %q is not supported", block.Language())
+		}
+	}
+}
+
+func (l *linter) checkAttributes(blocks document.CodeBlocks) {
+	for _, block := range blocks {
+		for _, warning := range block.AttributeWarnings() {
+			rule := RuleInvalidAttribute
+			if warning.Kind == document.AttributeWarningMalformed {
+				rule = RuleMalformedAttributes
+			}
+			l.report(rule, block, "%s", warning)
+		}
+	}
+}
+
+func (l *linter) checkFrontmatter(fmtr *document.Frontmatter) {
+	if l.opts.Converted {
+		return
+	}
+	if fmtr == nil || !ulid.ValidID(fmtr.Runme.ID) {
+		l.report(RuleMissingFrontmatterID, nil, "document has no ID in the frontmatter; run \"runme fmt --write\" to add it")
+	}
+}
+
+func (l *linter) checkEnv(blocks document.CodeBlocks, fmtr *document.Frontmatter) {
+	declared := make(map[string]bool)
+	for _, name := range l.opts.Env {
+		declared[name] = true
+	}
+	if fmtr != nil {
+		for name := range fmtr.Inputs {
+			declared[name] = true
+		}
+	}
+	// Inputs of imported documents are declared too.
+	for _, block := range blocks {
+		if block.Document().Path() == "" {
+			continue
+		}
+		if fmtr, err := block.Document().Frontmatter(); err == nil && fmtr != nil {
+			for name := range fmtr.Inputs {
+				declared[name] = true
+			}
+		}
+	}
+
+	// Variables can be set by any code block
+	// as code blocks can be run in any order.
+	for _, block := range blocks {
+		if isShellBlock(block, l.opts.Languages) {
+			for _, name := range assignedVars(block.Lines()) {
+				declared[name] = true
+			}
+		}
+	}
+
+	for _, block := range blocks {
+		if !isShellBlock(block, l.opts.Languages) {
+			continue
+		}
+		reported := make(map[string]bool)
+		for _, name := range usedVars(block.Lines()) {
+			if declared[name] || wellKnownVars[name] || reported[name] {
+				continue
+			}
+			reported[name] = true
+			l.report(RuleUndeclaredEnv, block, "environment variable %q is not declared", name)
+		}
+	}
+}
+
+func (l *linter) checkCwd(blocks document.CodeBlocks, fmtr *document.Frontmatter) {
+	dir := l.dir
+
+	if fmtr != nil && fmtr.Cwd != "" {
+		dir = resolveDir(dir, fmtr.Cwd)
+		if !isDir(dir) {
+			l.report(RuleMissingCwd, nil, "directory %q from the frontmatter does not exist", fmtr.Cwd)
+			return
+		}
+	}
+
+	for _, block := range blocks {
+		cwd := block.Cwd()
+		if cwd == "" {
+			continue
+		}
+		if !isDir(resolveDir(blockDir(block, dir), cwd)) {
+			l.report(RuleMissingCwd, block, "directory %q does not exist", cwd)
+		}
+	}
+}
+
+// blockDir returns the directory against which the working directory
+// of the code block is resolved. It's dir unless the code block
+// is imported from another document.
+func blockDir(block *document.CodeBlock, dir string) string {
+	path := block.Document().Path()
+	if path == "" {
+		return dir
+	}
+	dir = filepath.Dir(path)
+	if fmtr, err := block.Document().Frontmatter(); err == nil && fmtr != nil && fmtr.Cwd != "" {
+		dir = resolveDir(dir, fmtr.Cwd)
+	}
+	return dir
+}
+
+func isShellBlock(block *document.CodeBlock, languages *executable.Languages) bool {
+	lang := block.Language()
+	return lang == "" || lang == "bash" || lang == "zsh" || languages.IsShell(lang)
+}
+
+func resolveDir(parent, dir string) string {
+	dir = filepath.FromSlash(dir)
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(parent, dir)
+}
+
+func isDir(path string) bool {
+	stat, err := os.Stat(path)
+	return err == nil && stat.IsDir()
+}
@@ -0,0 +1,223 @@
+package lint
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lintString(t *testing.T, data string, opts Options) []Issue {
+	t.Helper()
+	issues, err := Lint(filepath.Join(t.TempDir(), "README.md"), []byte(data), opts)
+	require.NoError(t, err)
+	return issues
+}
+
+func issuesByRule(issues []Issue, rule Rule) (result []Issue) {
+	for _, issue := range issues {
+		if issue.Rule == rule.ID {
+			result = append(result, issue)
+		}
+	}
+	return result
+}
+
+func TestLint_Names(t *testing.T) {
+	data := "# Names\n\n```sh\necho hello\n```\n\n```sh {name=build}\nmake\n```\n\n```sh {\"name\":\"build\"}\nmake all\n```\n"
+
+	issues := lintString(t, data, Options{})
+
+	unnamed := issuesByRule(issues, RuleUnnamedBlock)
+	require.Len(t, unnamed, 1)
+	assert.Equal(t, 3, unnamed[0].Line)
+	assert.Equal(t, "echo-hello", unnamed[0].Block)
+	assert.Equal(t, SeverityWarning, unnamed[0].Severity)
+
+	duplicates := issuesByRule(issues, RuleDuplicateName)
+	require.Len(t, duplicates, 1)
+	assert.Equal(t, 11, duplicates[0].Line)
+	assert.Equal(t, SeverityError, duplicates[0].Severity)
+	assert.Contains(t, duplicates[0].Message, `"build"`)
+}
+
+func TestLint_UnknownLanguage(t *testing.T) {
+	data := "# Languages\n\n```\nls\n```\n\n```brainfuck {name=bf}\n+++\n```\n\n```go {name=go}\nfmt.Println()\n```\n"
+
+	issues := issuesByRule(lintString(t, data, Options{}), RuleUnknownLanguage)
+	require.Len(t, issues, 2)
+	assert.Equal(t, "ls", issues[0].Block)
+	assert.Equal(t, "code block has no language", issues[0].Message)
+	assert.Equal(t, "bf", issues[1].Block)
+	assert.Equal(t, `language "brainfuck" is not supported`, issues[1].Message)
+}
+
+func TestLint_MalformedAttributes(t *testing.T) {
+	data := "# Attributes\n\n```sh {name=ok interactive}\nls\n```\n\n```sh {\"name\": \"broken\"\nls\n```\n"
+
+	issues := issuesByRule(lintString(t, data, Options{}), RuleMalformedAttributes)
+	require.Len(t, issues, 1)
+	assert.Equal(t, 3, issues[0].Line)
+	assert.Equal(t, `"interactive" is ignored; expected key=value`, issues[0].Message)
+}
+
+func TestLint_KeyValueWithColon(t *testing.T) {
+	data := "# Attributes\n\n```sh { name=pull image=nginx:latest }\ndocker pull $image\n```\n"
+
+	issues := issuesByRule(lintString(t, data, Options{}), RuleMalformedAttributes)
+	assert.Empty(t, issues)
+}
+
+func TestLint_InvalidAttribute(t *testing.T) {
+	data := "# Attributes\n\n```sh {name=ok interative=false}\nls\n```\n"
+
+	issues := issuesByRule(lintString(t, data, Options{}), RuleInvalidAttribute)
+	require.Len(t, issues, 1)
+	assert.Equal(t, 3, issues[0].Line)
+	assert.Equal(t, SeverityWarning, issues[0].Severity)
+	assert.Equal(t, `attribute "interative": unknown attribute; did you mean "interactive"?`, issues[0].Message)
+}
+
+func TestLint_FrontmatterID(t *testing.T) {
+	t.Run("Missing", func(t *testing.T) {
+		issues := issuesByRule(lintString(t, "# Doc\n", Options{}), RuleMissingFrontmatterID)
+		require.Len(t, issues, 1)
+		assert.Equal(t, 0, issues[0].Line)
+	})
+
+	t.Run("Present", func(t *testing.T) {
+		data := "---\nrunme:\n  id: 01HF7B0KJPF469EG9ZWDNKKACQ\n  version: v2.0\n---\n\n# Doc\n"
+		issues := issuesByRule(lintString(t, data, Options{}), RuleMissingFrontmatterID)
+		assert.Empty(t, issues)
+	})
+
+	t.Run("Converted", func(t *testing.T) {
+		issues := issuesByRule(lintString(t, "# Doc\n", Options{Converted: true}), RuleMissingFrontmatterID)
+		assert.Empty(t, issues)
+	})
+}
+
+func TestLint_UndeclaredEnv(t *testing.T) {
+	data := `---
+shell: bash
+runme:
+  id: 01HF7B0KJPF469EG9ZWDNKKACQ
+  version: v2.0
+---
+
+# Env
+
+` + "```sh {name=setup}\nexport TOKEN=secret\nread -r NAME\n```\n\n" +
+		"```sh {name=use}\n" +
+		"echo $TOKEN ${NAME} $HOME $1 ${#FROM_ENV_FILE}\n" +
+		"echo '$SINGLE' \\$ESCAPED \"$MISSING\" # $COMMENTED\n" +
+		"echo ${MISSING:-default} $OTHER\n" +
+		"```\n\n" +
+		"```js {name=js}\nconsole.log(`${IGNORED}`)\n```\n"
+
+	issues := issuesByRule(lintString(t, data, Options{Env: []string{"FROM_ENV_FILE"}}), RuleUndeclaredEnv)
+	require.Len(t, issues, 2)
+	assert.Equal(t, `environment variable "MISSING" is not declared`, issues[0].Message)
+	assert.Equal(t, `environment variable "OTHER" is not declared`, issues[1].Message)
+	assert.Equal(t, "use", issues[0].Block)
+	assert.Equal(t, 15, issues[0].Line)
+}
+
+func TestLint_Cwd(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "exists"), 0o700))
+
+	data := "# Cwd\n\n```sh {name=ok cwd=exists}\nls\n```\n\n```sh {name=missing cwd=missing}\nls\n```\n"
+
+	issues, err := Lint(filepath.Join(dir, "README.md"), []byte(data), Options{})
+	require.NoError(t, err)
+
+	cwdIssues := issuesByRule(issues, RuleMissingCwd)
+	require.Len(t, cwdIssues, 1)
+	assert.Equal(t, "missing", cwdIssues[0].Block)
+	assert.Equal(t, `directory "missing" does not exist`, cwdIssues[0].Message)
+}
+
+func TestLint_Imports(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0o700))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(dir, "lib", "shared.md"),
+		[]byte("# Shared\n\n```sh {name=login interative=true}\necho $TOKEN\n```\n"),
+		0o600,
+	))
+
+	data := "# Imports\n\n<!-- runme:include lib/shared.md -->\n\n<!-- runme:include missing.md -->\n\n```sh {name=login}\necho login\n```\n"
+
+	issues, err := Lint("README.md", []byte(data), Options{Dir: dir})
+	require.NoError(t, err)
+
+	imports := issuesByRule(issues, RuleFailedImport)
+	require.Len(t, imports, 1)
+	assert.Equal(t, "README.md", imports[0].Path)
+	assert.Contains(t, imports[0].Message, `failed to import "missing.md"`)
+
+	attributes := issuesByRule(issues, RuleInvalidAttribute)
+	require.Len(t, attributes, 1)
+	assert.Equal(t, filepath.Join("lib", "shared.md"), attributes[0].Path)
+	assert.Equal(t, 3, attributes[0].Line)
+	assert.Equal(t, "shared.md:login", attributes[0].Block)
+
+	env := issuesByRule(issues, RuleUndeclaredEnv)
+	require.Len(t, env, 1)
+	assert.Equal(t, filepath.Join("lib", "shared.md"), env[0].Path)
+
+	// The imported code block is named differently.
+	assert.Empty(t, issuesByRule(issues, RuleDuplicateName))
+}
+
+func TestLint_Templates(t *testing.T) {
+	data := "# Templates\n\n" +
+		"```sh {name=greet template=true}\necho {{ .who }} $GREETING\n```\n\n" +
+		"```sh {name=greet-world use=greet who=world}\n```\n\n" +
+		"```sh {name=greet-nobody use=greet}\n```\n\n" +
+		"```sh {name=unknown use=missing}\n```\n"
+
+	issues := lintString(t, data, Options{})
+
+	templates := issuesByRule(issues, RuleFailedTemplate)
+	require.Len(t, templates, 2)
+	assert.Equal(t, "greet-nobody", templates[0].Block)
+	assert.Equal(t, "unknown", templates[1].Block)
+
+	// Expanded code blocks are checked.
+	env := issuesByRule(issues, RuleUndeclaredEnv)
+	require.Len(t, env, 1)
+	assert.Equal(t, "greet-world", env[0].Block)
+	assert.Empty(t, issuesByRule(issues, RuleUnknownLanguage))
+}
+
+func TestSARIF(t *testing.T) {
+	issues := []Issue{
+		{Rule: RuleMissingCwd.ID, Severity: SeverityError, Message: "directory \"x\" does not exist", Path: "docs/README.md", Line: 3},
+		{Rule: RuleMissingFrontmatterID.ID, Severity: SeverityNote, Message: "no ID", Path: "README.md"},
+	}
+
+	data, err := SARIF(issues, "1.2.3")
+	require.NoError(t, err)
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(data, &log))
+
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	assert.Equal(t, "runme", log.Runs[0].Tool.Driver.Name)
+	assert.Equal(t, "1.2.3", log.Runs[0].Tool.Driver.Version)
+	assert.Len(t, log.Runs[0].Tool.Driver.Rules, len(Rules()))
+
+	results := log.Runs[0].Results
+	require.Len(t, results, 2)
+	assert.Equal(t, "missing-cwd", results[0].RuleID)
+	assert.Equal(t, SeverityError, results[0].Level)
+	assert.Equal(t, "docs/README.md", results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, 3, results[0].Locations[0].PhysicalLocation.Region.StartLine)
+	assert.Nil(t, results[1].Locations[0].PhysicalLocation.Region)
+}
@@ -118,6 +118,15 @@ func WithEnvFilesReadOrder(order []string) ProjectOption {
 	}
 }
 
+// WithNamingStrategy sets a strategy for naming code blocks
+// without the "name" attribute in documents which don't set it
+// in the frontmatter.
+func WithNamingStrategy(strategy document.NamingStrategy) ProjectOption {
+	return func(p *Project) {
+		p.namingStrategy = strategy
+	}
+}
+
 func WithLogger(logger *zap.Logger) ProjectOption {
 	return func(p *Project) {
 		p.logger = logger
@@ -126,6 +135,7 @@ func WithLogger(logger *zap.Logger) ProjectOption {
 
 type Project struct {
 	identityResolver *identity.IdentityResolver
+	namingStrategy   document.NamingStrategy
 
 	// filePath is used for file-based projects.
 	filePath string
@@ -394,7 +404,7 @@ func (p *Project) loadFromDirectory(
 					Type: LoadEventFoundDir,
 					Data: LoadEventFoundDirData{Path: absPath},
 				})
-			} else if isMarkdown(path) {
+			} else if isDocument(path) {
 				p.send(ctx, eventc, LoadEvent{
 					Type: LoadEventFoundFile,
 					Data: LoadEventFoundFileData{Path: absPath},
@@ -460,13 +470,17 @@ func (p *Project) extractTasksFromFile(
 		Data: LoadEventStartedParsingDocumentData{Path: path},
 	})
 
-	codeBlocks, err := getCodeBlocksFromFile(path)
+	codeBlocks, err := getCodeBlocksFromFile(path, p.namingStrategy)
 
 	p.send(ctx, eventc, LoadEvent{
 		Type: LoadEventFinishedParsingDocument,
 		Data: LoadEventFinishedParsingDocumentData{Path: path},
 	})
 
+	var importErr *document.ImportError
+	if errors.As(err, &importErr) {
+		err = errors.Wrapf(err, "failed to load imports in %s", path)
+	}
 	if err != nil {
 		p.send(ctx, eventc, LoadEvent{
 			Type: LoadEventError,
@@ -474,40 +488,70 @@ func (p *Project) extractTasksFromFile(
 		})
 	}
 
+	codeBlocks, err = document.ExpandTemplates(codeBlocks)
+	if err != nil {
+		p.send(ctx, eventc, LoadEvent{
+			Type: LoadEventError,
+			Data: LoadEventErrorData{Err: errors.Wrapf(err, "failed to expand templates in %s", path)},
+		})
+	}
+
 	for _, b := range codeBlocks {
 		p.send(ctx, eventc, LoadEvent{
 			Type: LoadEventFoundTask,
 			Data: LoadEventFoundTaskData{
 				Task: Task{
 					CodeBlock:    b,
-					DocumentPath: path,
+					DocumentPath: taskDocumentPath(b, path),
 				},
 			},
 		})
 	}
 }
 
-func getCodeBlocksFromFile(path string) (document.CodeBlocks, error) {
+func getCodeBlocksFromFile(path string, namingStrategy document.NamingStrategy) (document.CodeBlocks, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	return getCodeBlocks(data)
+	if load := document.LoaderFor(path); load != nil {
+		data, err = load(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load %s", path)
+		}
+	}
+	return getCodeBlocks(data, path, namingStrategy)
 }
 
-func getCodeBlocks(data []byte) (document.CodeBlocks, error) {
+// getCodeBlocks returns code blocks of a document located at path
+// including imported ones.
+func getCodeBlocks(data []byte, path string, namingStrategy document.NamingStrategy) (document.CodeBlocks, error) {
 	identityResolver := identity.NewResolver(identity.DefaultLifecycleIdentity)
 	d := document.New(data, identityResolver)
+	d.SetNamingStrategy(namingStrategy)
+	d.SetMDX(document.IsMDX(path))
 
 	if f, err := d.Frontmatter(); err == nil && f != nil && f.Runme.Session.ID != "" {
 		return nil, nil
 	}
 
-	node, err := d.Root()
-	if err != nil {
-		return nil, err
+	return document.CollectCodeBlocksWithImports(d, path)
+}
+
+// taskDocumentPath returns the path of the document the code block
+// comes from, so imported code blocks resolve their directory
+// against the imported document.
+func taskDocumentPath(block *document.CodeBlock, path string) string {
+	if p := block.Document().Path(); p != "" {
+		return p
 	}
-	return document.CollectCodeBlocks(node), nil
+	return path
+}
+
+// isDocument returns true for Markdown files and
+// files in other formats which can be loaded as Markdown.
+func isDocument(filePath string) bool {
+	return isMarkdown(filePath) || document.LoaderFor(filePath) != nil
 }
 
 func isMarkdown(filePath string) bool {
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/stateful/runme/internal/document"
+	"github.com/yuin/goldmark/ast"
 )
 
 // Task is a project-specific struct.
@@ -181,6 +183,80 @@ func FilterTasksByFileAndTaskName(tasks []Task, queryFile string, queryName stri
 	return results, nil
 }
 
+// FilterTasksByHeadingPath returns tasks from documents matching queryFile
+// which are in a section identified by a heading path, for example,
+// "Deploy" and "Staging". Sections are resolved as described in
+// document.(*Document).CodeBlocksInSection.
+//
+// If the path doesn't identify a section, the last element is matched
+// exactly against names of tasks in the section identified by the rest
+// of the path. For example, "Deploy" and "migrate" return the task named
+// "migrate" in the "Deploy" section.
+func FilterTasksByHeadingPath(tasks []Task, queryFile string, headingPath []string) ([]Task, error) {
+	fileMatcher, err := compileQuery(queryFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Task
+
+	foundFile := false
+
+	// Sections are resolved once per document.
+	sections := make(map[*document.Document]map[ast.Node]bool)
+	inSection := func(task Task, path []string) bool {
+		doc := task.CodeBlock.Document()
+		if doc == nil {
+			return false
+		}
+		blocks, ok := sections[doc]
+		if !ok {
+			blocks = make(map[ast.Node]bool)
+			codeBlocks, _ := doc.CodeBlocksInSection(path...)
+			for _, block := range codeBlocks {
+				blocks[block.Unwrap()] = true
+			}
+			sections[doc] = blocks
+		}
+		return blocks[task.CodeBlock.Unwrap()]
+	}
+
+	for _, task := range tasks {
+		if !fileMatcher.MatchString(task.DocumentPath) {
+			continue
+		}
+
+		foundFile = true
+
+		if inSection(task, headingPath) {
+			results = append(results, task)
+		}
+	}
+
+	if len(results) == 0 && len(headingPath) > 1 {
+		queryName := headingPath[len(headingPath)-1]
+		clear(sections)
+
+		for _, task := range tasks {
+			if !fileMatcher.MatchString(task.DocumentPath) || task.CodeBlock.Name() != queryName {
+				continue
+			}
+			if inSection(task, headingPath[:len(headingPath)-1]) {
+				results = append(results, task)
+			}
+		}
+	}
+
+	if len(results) == 0 {
+		if !foundFile {
+			return nil, &ErrTaskWithFilenameNotFound{queryFile: queryFile}
+		}
+		return nil, &ErrTaskWithHeadingPathNotFound{queryPath: strings.Join(headingPath, document.HeadingPathSeparator)}
+	}
+
+	return results, nil
+}
+
 type ErrTaskWithFilenameNotFound struct {
 	queryFile string
 }
@@ -197,6 +273,17 @@ func (e ErrTaskWithNameNotFound) Error() string {
 	return fmt.Sprintf("unable to find any script named %q", e.queryName)
 }
 
+type ErrTaskWithHeadingPathNotFound struct {
+	queryPath string
+}
+
+func (e ErrTaskWithHeadingPathNotFound) Error() string {
+	return fmt.Sprintf("unable to find any script in section %q", e.queryPath)
+}
+
 func IsTaskNotFoundError(err error) bool {
-	return errors.As(err, &ErrTaskWithFilenameNotFound{}) || errors.As(err, &ErrTaskWithNameNotFound{})
+	// The errors are returned as pointers.
+	return errors.As(err, new(*ErrTaskWithFilenameNotFound)) ||
+		errors.As(err, new(*ErrTaskWithNameNotFound)) ||
+		errors.As(err, new(*ErrTaskWithHeadingPathNotFound))
 }
@@ -1,3 +1,84 @@
 package project
 
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/document/identity"
+)
+
 // TODO(adamb): add missing unit tests
+
+func TestFilterTasksByHeadingPath(t *testing.T) {
+	data := []byte(`# Deploy
+
+## Staging
+
+` + "```" + `sh {"name":"apply"}
+kubectl apply -f staging.yaml
+` + "```" + `
+
+` + "```" + `sh {"name":"status"}
+kubectl rollout status deploy/app
+` + "```" + `
+
+## Production
+
+` + "```" + `sh {"name":"apply"}
+kubectl apply -f production.yaml
+` + "```" + `
+`)
+
+	doc := document.New(data, identity.NewResolver(identity.UnspecifiedLifecycleIdentity))
+	node, err := doc.Root()
+	require.NoError(t, err)
+
+	var tasks []Task
+	for _, block := range document.CollectCodeBlocks(node) {
+		tasks = append(tasks, Task{CodeBlock: block, DocumentPath: "README.md"})
+	}
+
+	taskLines := func(tasks []Task) (result []string) {
+		for _, task := range tasks {
+			result = append(result, task.CodeBlock.Lines()[0])
+		}
+		return
+	}
+
+	t.Run("Section", func(t *testing.T) {
+		result, err := FilterTasksByHeadingPath(tasks, "README.md", []string{"Deploy", "Staging"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"kubectl apply -f staging.yaml", "kubectl rollout status deploy/app"}, taskLines(result))
+	})
+
+	t.Run("TaskName", func(t *testing.T) {
+		result, err := FilterTasksByHeadingPath(tasks, "", []string{"deploy", "staging", "status"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"kubectl rollout status deploy/app"}, taskLines(result))
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := FilterTasksByHeadingPath(tasks, "README.md", []string{"Deploy", "Testing"})
+		assert.EqualError(t, err, `unable to find any script in section "Deploy/Testing"`)
+
+		_, err = FilterTasksByHeadingPath(tasks, "docs.md", []string{"Deploy"})
+		assert.EqualError(t, err, `unable to find file in project matching regex "docs.md"`)
+	})
+}
+
+func TestIsTaskNotFoundError(t *testing.T) {
+	tasks := []Task{{DocumentPath: "README.md"}}
+
+	_, err := FilterTasksByFileAndTaskName(tasks, "docs.md", "build")
+	assert.True(t, IsTaskNotFoundError(err))
+
+	_, err = FilterTasksByHeadingPath(tasks, "docs.md", []string{"Deploy"})
+	assert.True(t, IsTaskNotFoundError(err))
+
+	assert.True(t, IsTaskNotFoundError(errors.Wrap(&ErrTaskWithNameNotFound{queryName: "build"}, "failed")))
+	assert.False(t, IsTaskNotFoundError(errors.New("other")))
+}
@@ -102,6 +102,24 @@ func (b *RingBuffer) read(p []byte) (n int, err error) {
 	return n, err
 }
 
+// Bytes returns a copy of the unread data without consuming it.
+func (b *RingBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.w == b.r && !b.isFull {
+		return nil
+	}
+
+	if b.w > b.r {
+		return append([]byte(nil), b.buf[b.r:b.w]...)
+	}
+
+	result := make([]byte, 0, b.size-b.r+b.w)
+	result = append(result, b.buf[b.r:]...)
+	return append(result, b.buf[:b.w]...)
+}
+
 func (b *RingBuffer) Write(p []byte) (n int, err error) {
 	if b.closed.Load() {
 		return 0, ErrClosed
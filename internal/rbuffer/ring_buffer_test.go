@@ -96,6 +96,22 @@ func TestRingBuffer(t *testing.T) {
 	})
 }
 
+func TestRingBuffer_Bytes(t *testing.T) {
+	buf := NewRingBuffer(10)
+	assert.Nil(t, buf.Bytes())
+
+	assertWrite(t, buf, []byte("hello"))
+	assert.Equal(t, []byte("hello"), buf.Bytes())
+	// Bytes() does not consume data.
+	assertRead(t, buf, []byte("hello"))
+	assert.Nil(t, buf.Bytes())
+
+	// Wrap around the end of the buffer.
+	assertWrite(t, buf, []byte("abcdefg"))
+	assert.Equal(t, []byte("abcdefg"), buf.Bytes())
+	assertRead(t, buf, []byte("abcdefg"))
+}
+
 func TestRingBuffer_Close(t *testing.T) {
 	buf := NewRingBuffer(512)
 	assert.NoError(t, buf.Close())
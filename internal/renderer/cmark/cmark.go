@@ -394,8 +394,12 @@ func (r *renderer) Render(doc ast.Node, source []byte) ([]byte, error) {
 
 		case ast.KindRawHTML:
 			if entering {
-				if err := r.write(node.Text(source)); err != nil {
-					return ast.WalkStop, err
+				segments := node.(*ast.RawHTML).Segments
+				for i := 0; i < segments.Len(); i++ {
+					segment := segments.At(i)
+					if err := r.write(segment.Value(source)); err != nil {
+						return ast.WalkStop, err
+					}
 				}
 				return ast.WalkSkipChildren, nil
 			}
@@ -422,6 +426,21 @@ func (r *renderer) Render(doc ast.Node, source []byte) ([]byte, error) {
 					return ast.WalkStop, err
 				}
 			}
+
+		default:
+			// Raw blocks from extensions, for example, MDX,
+			// are written as they are.
+			if entering && node.Type() == ast.TypeBlock && node.IsRaw() {
+				r.blankline()
+				for i := 0; i < node.Lines().Len(); i++ {
+					line := node.Lines().At(i)
+					if err := r.write(line.Value(source)); err != nil {
+						return ast.WalkStop, err
+					}
+				}
+				r.blankline()
+				return ast.WalkSkipChildren, nil
+			}
 		}
 
 		return status, nil
@@ -30,6 +30,15 @@ func TestRender_HTMLBlock(t *testing.T) {
 	testEquality(t, data)
 }
 
+func TestRender_RawHTML(t *testing.T) {
+	data := []byte(`Press <kbd>Ctrl</kbd>+<kbd>C</kbd> to stop<br/>or wait.
+
+Badge: <img src="badge.svg"
+alt="badge" />
+`)
+	testEquality(t, data)
+}
+
 func TestRender_TightList(t *testing.T) {
 	data := []byte(`List example:
 
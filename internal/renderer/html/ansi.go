@@ -0,0 +1,247 @@
+package html
+
+import (
+	"fmt"
+	gohtml "html"
+	"strconv"
+	"strings"
+)
+
+// ansiColors is a palette of 16 basic colors as used by VS Code.
+var ansiColors = [16]string{
+	"#000000", "#cd3131", "#0dbc79", "#e5e510", "#2472c8", "#bc3fbc", "#11a8cd", "#e5e5e5",
+	"#666666", "#f14c4c", "#23d18b", "#f5f543", "#3b8eea", "#d670d6", "#29b8db", "#ffffff",
+}
+
+type ansiStyle struct {
+	bold      bool
+	dim       bool
+	italic    bool
+	underline bool
+	fg        string
+	bg        string
+}
+
+func (s ansiStyle) css() string {
+	var props []string
+	if s.fg != "" {
+		props = append(props, "color:"+s.fg)
+	}
+	if s.bg != "" {
+		props = append(props, "background-color:"+s.bg)
+	}
+	if s.bold {
+		props = append(props, "font-weight:bold")
+	}
+	if s.dim {
+		props = append(props, "opacity:0.7")
+	}
+	if s.italic {
+		props = append(props, "font-style:italic")
+	}
+	if s.underline {
+		props = append(props, "text-decoration:underline")
+	}
+	return strings.Join(props, ";")
+}
+
+// apply updates the style with SGR parameters, for example, "1;31".
+func (s *ansiStyle) apply(params string) {
+	codes := strings.Split(params, ";")
+
+	for i := 0; i < len(codes); i++ {
+		code, err := strconv.Atoi(codes[i])
+		if err != nil {
+			// An empty parameter is the same as 0.
+			code = 0
+		}
+
+		switch {
+		case code == 0:
+			*s = ansiStyle{}
+		case code == 1:
+			s.bold = true
+		case code == 2:
+			s.dim = true
+		case code == 3:
+			s.italic = true
+		case code == 4:
+			s.underline = true
+		case code == 22:
+			s.bold, s.dim = false, false
+		case code == 23:
+			s.italic = false
+		case code == 24:
+			s.underline = false
+		case code >= 30 && code <= 37:
+			s.fg = ansiColors[code-30]
+		case code == 39:
+			s.fg = ""
+		case code >= 40 && code <= 47:
+			s.bg = ansiColors[code-40]
+		case code == 49:
+			s.bg = ""
+		case code >= 90 && code <= 97:
+			s.fg = ansiColors[code-90+8]
+		case code >= 100 && code <= 107:
+			s.bg = ansiColors[code-100+8]
+		case code == 38 || code == 48:
+			color, n := extendedColor(codes[i+1:])
+			i += n
+			if code == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+		}
+	}
+}
+
+// extendedColor parses a 256 color ("5;n") or a true color ("2;r;g;b").
+// It returns the color and the number of consumed parameters.
+func extendedColor(params []string) (string, int) {
+	if len(params) == 0 {
+		return "", 0
+	}
+
+	values := make([]int, 0, 4)
+	for _, p := range params {
+		v, err := strconv.Atoi(p)
+		if err != nil || v < 0 || v > 255 {
+			v = 0
+		}
+		values = append(values, v)
+	}
+
+	switch values[0] {
+	case 5:
+		if len(values) < 2 {
+			return "", len(values)
+		}
+		return color256(values[1]), 2
+	case 2:
+		if len(values) < 4 {
+			return "", len(values)
+		}
+		return fmt.Sprintf("#%02x%02x%02x", values[1], values[2], values[3]), 4
+	}
+
+	return "", 1
+}
+
+func color256(n int) string {
+	switch {
+	case n < 16:
+		return ansiColors[n]
+	case n < 232:
+		n -= 16
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[n/6%6], levels[n%6])
+	default:
+		gray := 8 + (n-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+	}
+}
+
+// ansiToHTML converts text with ANSI escape codes into HTML.
+// Colors and text attributes become inline styles and other
+// escape sequences, like moving the cursor, are removed.
+func ansiToHTML(text string) string {
+	text = collapseCarriageReturns(text)
+
+	var (
+		buf     strings.Builder
+		style   ansiStyle
+		current ansiStyle
+		open    bool
+	)
+
+	write := func(s string) {
+		if s == "" {
+			return
+		}
+		if !open || style != current {
+			if open {
+				buf.WriteString("</span>")
+				open = false
+			}
+			if css := style.css(); css != "" {
+				buf.WriteString(`<span style="`)
+				buf.WriteString(css)
+				buf.WriteString(`">`)
+				open = true
+			}
+			current = style
+		}
+		buf.WriteString(gohtml.EscapeString(s))
+	}
+
+	for len(text) > 0 {
+		idx := strings.IndexByte(text, '\x1b')
+		if idx == -1 {
+			write(text)
+			break
+		}
+
+		write(text[:idx])
+		text = text[idx+1:]
+
+		if len(text) == 0 {
+			break
+		}
+
+		switch text[0] {
+		case '[':
+			// CSI sequence ends with a byte in the range 0x40–0x7E.
+			end := 1
+			for end < len(text) && (text[end] < 0x40 || text[end] > 0x7e) {
+				end++
+			}
+			if end == len(text) {
+				text = ""
+				continue
+			}
+			if text[end] == 'm' {
+				style.apply(text[1:end])
+			}
+			text = text[end+1:]
+		case ']':
+			// OSC sequence ends with BEL or ST.
+			end := strings.IndexAny(text, "\x07\x1b")
+			switch {
+			case end == -1:
+				text = ""
+			case text[end] == '\x1b' && end+1 < len(text) && text[end+1] == '\\':
+				text = text[end+2:]
+			default:
+				text = text[end+1:]
+			}
+		default:
+			text = text[1:]
+		}
+	}
+
+	if open {
+		buf.WriteString("</span>")
+	}
+
+	return buf.String()
+}
+
+// collapseCarriageReturns keeps only the text after the last carriage
+// return in each line, like a terminal does for progress bars.
+func collapseCarriageReturns(text string) string {
+	if !strings.Contains(text, "\r") {
+		return text
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if idx := strings.LastIndexByte(line, '\r'); idx != -1 {
+			line = line[idx+1:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
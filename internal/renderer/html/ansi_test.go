@@ -0,0 +1,67 @@
+package html
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnsiToHTML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Plain",
+			input:    "hello <world> & co",
+			expected: "hello &lt;world&gt; &amp; co",
+		},
+		{
+			name:     "BoldRed",
+			input:    "\x1b[1;31mfail\x1b[0m ok",
+			expected: `<span style="color:#cd3131;font-weight:bold">fail</span> ok`,
+		},
+		{
+			name:     "ResetByEmptyParameter",
+			input:    "\x1b[32mgreen\x1b[m plain",
+			expected: `<span style="color:#0dbc79">green</span> plain`,
+		},
+		{
+			name:     "ChangedStyle",
+			input:    "\x1b[31mred\x1b[4munderline\x1b[24m",
+			expected: `<span style="color:#cd3131">red</span><span style="color:#cd3131;text-decoration:underline">underline</span>`,
+		},
+		{
+			name:     "Color256AndTrueColor",
+			input:    "\x1b[38;5;196ma\x1b[48;2;0;128;255mb",
+			expected: `<span style="color:#ff0000">a</span><span style="color:#ff0000;background-color:#0080ff">b</span>`,
+		},
+		{
+			name:     "BrightColors",
+			input:    "\x1b[94;103mx",
+			expected: `<span style="color:#3b8eea;background-color:#f5f543">x</span>`,
+		},
+		{
+			name:     "OtherSequencesRemoved",
+			input:    "\x1b[2K\x1b[1Gdone\x1b]0;title\x07!\x1b]8;;https://runme.dev\x1b\\",
+			expected: "done!",
+		},
+		{
+			name:     "CarriageReturns",
+			input:    "10%\r50%\r100%\r\nnext",
+			expected: "100%\nnext",
+		},
+		{
+			name:     "Unterminated",
+			input:    "text\x1b[31",
+			expected: "text",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ansiToHTML(tc.input))
+		})
+	}
+}
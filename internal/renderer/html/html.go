@@ -0,0 +1,251 @@
+// Package html renders notebooks as self-contained HTML pages
+// with outputs of executed cells.
+package html
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+
+	"github.com/stateful/runme/internal/document/editor"
+	"github.com/stateful/runme/internal/runner/client"
+)
+
+// Options configures rendering.
+type Options struct {
+	// Title is a title of the page.
+	Title string
+	// Report provides outputs of cells which don't have them
+	// in the notebook. Cells are matched by name.
+	Report *client.Report
+	// Document is an absolute path of the document used
+	// to find cells in Report.
+	Document string
+}
+
+type page struct {
+	Title string
+	Cells []cellView
+}
+
+type cellView struct {
+	IsMarkup bool
+	Markup   template.HTML
+	Name     string
+	Language string
+	Code     string
+	// Status is nil if the cell wasn't executed.
+	Status  *cellStatus
+	Outputs []outputView
+}
+
+type cellStatus struct {
+	ExitCode  int
+	StartTime string
+	Duration  string
+}
+
+type outputView struct {
+	// Text is set for text outputs. Otherwise, it's an image.
+	Text  template.HTML
+	Image template.URL
+	Alt   string
+	// Stderr is set for outputs of the standard error.
+	Stderr bool
+}
+
+// Render writes the notebook as an HTML page. Markup cells are rendered
+// by goldmark and code cells are followed by their outputs.
+func Render(w io.Writer, notebook *editor.Notebook, opts Options) error {
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(goldmarkhtml.WithUnsafe()),
+	)
+
+	p := page{Title: opts.Title}
+	if p.Title == "" {
+		p.Title = "Runme"
+	}
+
+	for _, cell := range notebook.Cells {
+		switch cell.Kind {
+		case editor.MarkupKind:
+			var buf bytes.Buffer
+			if err := md.Convert([]byte(cell.Value), &buf); err != nil {
+				return errors.Wrap(err, "failed to render markdown")
+			}
+			// #nosec G203; the document is trusted the same way as its code.
+			p.Cells = append(p.Cells, cellView{IsMarkup: true, Markup: template.HTML(buf.String())})
+		case editor.CodeKind:
+			p.Cells = append(p.Cells, newCodeCellView(cell, opts))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pageTemplate.Execute(&buf, p); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err := w.Write(buf.Bytes())
+	return errors.WithStack(err)
+}
+
+func newCodeCellView(cell *editor.Cell, opts Options) cellView {
+	view := cellView{
+		Name:     cell.Metadata[editor.PrefixAttributeName(editor.InternalAttributePrefix, "name")],
+		Language: cell.LanguageID,
+		Code:     cell.Value,
+	}
+
+	if len(cell.Outputs) > 0 {
+		view.Outputs, view.Status = notebookOutputs(cell)
+		return view
+	}
+
+	if opts.Report == nil || view.Name == "" {
+		return view
+	}
+
+	task := opts.Report.Lookup(opts.Document, view.Name)
+	if task == nil {
+		// The report might come from a different location.
+		task = opts.Report.Lookup("", view.Name)
+	}
+	if task == nil {
+		return view
+	}
+
+	view.Status = &cellStatus{
+		ExitCode:  task.ExitCode,
+		StartTime: formatTime(task.StartTime),
+		Duration:  formatDuration(task.Duration()),
+	}
+	if task.Output != "" {
+		view.Outputs = append(view.Outputs, outputView{Text: textOutput(task.Output)})
+	}
+
+	return view
+}
+
+func notebookOutputs(cell *editor.Cell) (outputs []outputView, status *cellStatus) {
+	if summary := cell.ExecutionSummary; summary != nil && summary.Timing != nil {
+		start := time.UnixMilli(summary.Timing.StartTime)
+		status = &cellStatus{
+			StartTime: formatTime(start),
+			Duration:  formatDuration(time.UnixMilli(summary.Timing.EndTime).Sub(start)),
+		}
+		if !summary.Success {
+			status.ExitCode = 1
+		}
+	}
+
+	for _, output := range cell.Outputs {
+		if info := output.ProcessInfo; info != nil && info.ExitReason != nil && info.ExitReason.Type == "exit" {
+			if status == nil {
+				status = &cellStatus{}
+			}
+			status.ExitCode = int(info.ExitReason.Code)
+		}
+
+		for _, item := range output.Items {
+			if strings.HasPrefix(item.Mime, "image/") {
+				outputs = append(outputs, outputView{
+					// #nosec G203; the data is base64 encoded.
+					Image: template.URL(fmt.Sprintf("data:%s;base64,%s", item.Mime, item.Data)),
+					Alt:   item.Mime,
+				})
+				continue
+			}
+			if item.Value == "" {
+				continue
+			}
+			outputs = append(outputs, outputView{
+				Text:   textOutput(item.Value),
+				Stderr: strings.Contains(item.Mime, "stderr"),
+			})
+		}
+	}
+
+	return outputs, status
+}
+
+func textOutput(value string) template.HTML {
+	// #nosec G203; ansiToHTML escapes the text.
+	return template.HTML(ansiToHTML(strings.TrimSuffix(value, "\n")))
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Truncate(time.Millisecond).String()
+	}
+	return d.Truncate(10 * time.Millisecond).String()
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="Runme">
+<title>{{ .Title }}</title>
+<style>
+body { max-width: 960px; margin: 2em auto; padding: 0 1em; font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; line-height: 1.5; color: #1f2328; }
+pre, code { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: 0.9em; }
+pre { padding: 0.75em 1em; overflow-x: auto; border-radius: 6px; margin: 0; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #d0d7de; padding: 0.25em 0.75em; }
+.cell { margin: 1em 0; border: 1px solid #d0d7de; border-radius: 6px; }
+.cell-header { display: flex; justify-content: space-between; padding: 0.25em 1em; background: #f6f8fa; border-bottom: 1px solid #d0d7de; border-radius: 6px 6px 0 0; font-size: 0.85em; }
+.cell-name { font-weight: 600; }
+.cell-code { background: #f6f8fa; border-radius: 0; }
+.cell-status { padding: 0.25em 1em; font-size: 0.85em; border-top: 1px solid #d0d7de; }
+.cell-status.success { color: #1a7f37; }
+.cell-status.failure { color: #cf222e; }
+.cell-output { background: #1e1e1e; color: #cccccc; border-radius: 0; border-top: 1px solid #d0d7de; white-space: pre-wrap; }
+.cell-output.stderr { color: #f48771; }
+.cell-image { padding: 0.5em 1em; border-top: 1px solid #d0d7de; }
+.cell-image img { max-width: 100%; }
+</style>
+</head>
+<body>
+{{- range .Cells }}
+{{- if .IsMarkup }}
+{{ .Markup }}
+{{- else }}
+<div class="cell">
+<div class="cell-header"><span class="cell-name">{{ .Name }}</span><span class="cell-language">{{ .Language }}</span></div>
+<pre class="cell-code"><code{{ if .Language }} class="language-{{ .Language }}"{{ end }}>{{ .Code }}</code></pre>
+{{- range .Outputs }}
+{{- if .Image }}
+<div class="cell-image"><img src="{{ .Image }}" alt="{{ .Alt }}"></div>
+{{- else }}
+<pre class="cell-output{{ if .Stderr }} stderr{{ end }}">{{ .Text }}</pre>
+{{- end }}
+{{- end }}
+{{- with .Status }}
+<div class="cell-status {{ if eq .ExitCode 0 }}success{{ else }}failure{{ end }}">
+{{- if lt .ExitCode 0 }}Did not finish{{ else }}Exit code {{ .ExitCode }}{{ end }}
+{{- if .Duration }} in {{ .Duration }}{{ end }}
+{{- if .StartTime }} (started at {{ .StartTime }}){{ end -}}
+</div>
+{{- end }}
+</div>
+{{- end }}
+{{- end }}
+</body>
+</html>
+`))
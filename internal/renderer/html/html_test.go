@@ -0,0 +1,85 @@
+package html
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stateful/runme/internal/document/editor"
+	"github.com/stateful/runme/internal/document/identity"
+	"github.com/stateful/runme/internal/runner/client"
+)
+
+var identityResolver = identity.NewResolver(identity.UnspecifiedLifecycleIdentity)
+
+func TestRender(t *testing.T) {
+	data := []byte("# Incident\n\n```sh {\"name\":\"check\"}\necho <ok>\n```\n\n```sh {\"name\":\"skipped\"}\necho skipped\n```\n")
+
+	notebook, err := editor.Deserialize(data, identityResolver)
+	require.NoError(t, err)
+
+	report := &client.Report{
+		Tasks: []*client.TaskReport{
+			{
+				Name:       "check",
+				Document:   "/other/README.md",
+				ExitCode:   2,
+				StartTime:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				DurationMs: 1500,
+				Output:     "\x1b[31m<ok>\x1b[0m\n",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	err = Render(&buf, notebook, Options{Title: "Postmortem", Report: report, Document: "/docs/README.md"})
+	require.NoError(t, err)
+
+	result := buf.String()
+	assert.Contains(t, result, "<title>Postmortem</title>")
+	assert.Contains(t, result, "<h1>Incident</h1>")
+	assert.Contains(t, result, `<code class="language-sh">echo &lt;ok&gt;</code>`)
+	assert.Contains(t, result, `<pre class="cell-output"><span style="color:#cd3131">&lt;ok&gt;</span></pre>`)
+	assert.Contains(t, result, `<div class="cell-status failure">Exit code 2 in 1.5s (started at 2024-01-02T03:04:05Z)</div>`)
+	assert.NotContains(t, result, "echo skipped</code></pre>\n<pre")
+	assert.NotContains(t, result, "<script")
+}
+
+func TestRender_NotebookOutputs(t *testing.T) {
+	notebook := &editor.Notebook{
+		Cells: []*editor.Cell{
+			{
+				Kind:       editor.CodeKind,
+				LanguageID: "python",
+				Value:      "plot()",
+				Outputs: []*editor.CellOutput{
+					{
+						Items: []*editor.CellOutputItem{
+							{Value: "warning", Mime: "application/vnd.code.notebook.stderr"},
+							{Data: "iVBORw0KGgo=", Mime: "image/png"},
+						},
+						ProcessInfo: &editor.CellOutputProcessInfo{
+							ExitReason: &editor.ProcessInfoExitReason{Type: "exit", Code: 0},
+						},
+					},
+				},
+				ExecutionSummary: &editor.CellExecutionSummary{
+					Success: true,
+					Timing:  &editor.ExecutionSummaryTiming{StartTime: 1000, EndTime: 1250},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, notebook, Options{}))
+
+	result := buf.String()
+	assert.Contains(t, result, "<title>Runme</title>")
+	assert.Contains(t, result, `<pre class="cell-output stderr">warning</pre>`)
+	assert.Contains(t, result, `<img src="data:image/png;base64,iVBORw0KGgo=" alt="image/png">`)
+	assert.Contains(t, result, `<div class="cell-status success">Exit code 0 in 250ms`)
+}
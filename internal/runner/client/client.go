@@ -8,10 +8,13 @@ import (
 
 	"github.com/go-git/go-billy/v5/osfs"
 	"github.com/muesli/cancelreader"
+	"github.com/pkg/errors"
 	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/executable"
 	runnerv1 "github.com/stateful/runme/internal/gen/proto/go/runme/runner/v1"
 	"github.com/stateful/runme/internal/project"
 	"github.com/stateful/runme/internal/runner"
+	"github.com/stateful/runme/internal/sandbox"
 	"go.uber.org/zap"
 )
 
@@ -42,6 +45,12 @@ type RunnerSettings struct {
 	tlsDir   string
 
 	envs []string
+
+	resourceUsageHandler func(task project.Task, usage *runner.ResourceUsage)
+
+	sandboxLevel sandbox.Level
+
+	languages *executable.Languages
 }
 
 func (rs *RunnerSettings) Clone() *RunnerSettings {
@@ -195,6 +204,60 @@ func WithCustomShell(customShell string) RunnerOption {
 	})
 }
 
+// WithResourceUsageHandler sets a callback invoked with resources
+// consumed by a task after it finishes.
+func WithResourceUsageHandler(handler func(task project.Task, usage *runner.ResourceUsage)) RunnerOption {
+	return withSettings(func(rs *RunnerSettings) {
+		rs.resourceUsageHandler = handler
+	})
+}
+
+func (rs *RunnerSettings) handleResourceUsage(task project.Task, usage *runner.ResourceUsage) {
+	if rs.resourceUsageHandler == nil || usage == nil {
+		return
+	}
+	rs.resourceUsageHandler(task, usage)
+}
+
+// WithSandboxLevel sets the minimal sandbox level for all tasks.
+// A task can request a stricter level using the "sandbox" attribute,
+// but it can't loosen it.
+func WithSandboxLevel(level sandbox.Level) RunnerOption {
+	return withSettings(func(rs *RunnerSettings) {
+		rs.sandboxLevel = level
+	})
+}
+
+// sandboxConfig returns the sandbox config for the task or nil
+// if the task should not run in a sandbox.
+func (rs *RunnerSettings) sandboxConfig(task project.Task) (*sandbox.Config, error) {
+	level, err := sandbox.ParseLevel(task.CodeBlock.Attributes().Get("sandbox"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid sandbox attribute of %q", task.CodeBlock.Name())
+	}
+
+	if rs.sandboxLevel > level {
+		level = rs.sandboxLevel
+	}
+
+	if level == sandbox.LevelOff {
+		return nil, nil
+	}
+
+	return &sandbox.Config{
+		DisableNetwork: level == sandbox.LevelOffline,
+	}, nil
+}
+
+// WithLanguages sets custom languages used to run tasks.
+// A remote runner resolves programs using languages known
+// to the server.
+func WithLanguages(languages *executable.Languages) RunnerOption {
+	return withSettings(func(rs *RunnerSettings) {
+		rs.languages = languages
+	})
+}
+
 func WithTempSettings(rc Runner, opts []RunnerOption, cb func() error) error {
 	oldSettings := rc.getSettings().Clone()
 
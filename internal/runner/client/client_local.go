@@ -81,7 +81,7 @@ func (r *LocalRunner) newExecutable(task project.Task) (runner.Executable, error
 		customShell = fmtr.Shell
 	}
 
-	programName, _ := runner.GetCellProgram(block.Language(), customShell, block)
+	programName, _ := runner.GetCellProgram(block.Language(), customShell, block, r.languages)
 
 	r.session.AddEnvs(r.envs)
 
@@ -93,6 +93,8 @@ func (r *LocalRunner) newExecutable(task project.Task) (runner.Executable, error
 		Stderr:  r.stderr,
 		Session: r.session,
 		Logger:  r.logger,
+
+		Languages: r.languages,
 	}
 
 	// TODO(adamb): what about `r.envs`?
@@ -103,6 +105,19 @@ func (r *LocalRunner) newExecutable(task project.Task) (runner.Executable, error
 
 	cfg.Dir = ResolveDirectory(cfg.Dir, task)
 
+	cfg.ResourceLimits, err = runner.CellResourceLimits(block)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Sandbox, err = r.sandboxConfig(task)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Sandbox != nil && r.project != nil {
+		cfg.Sandbox.WritablePaths = append(cfg.Sandbox.WritablePaths, r.project.Root())
+	}
+
 	if block.Interactive() {
 		cfg.Stdin = r.stdin
 	}
@@ -122,10 +137,31 @@ func (r *LocalRunner) newExecutable(task project.Task) (runner.Executable, error
 				Cmds:             block.Lines(),
 			},
 		}, nil
-	case "go":
-		return &runner.Go{
+	case "http":
+		return &runner.HTTP{
+			ExecutableConfig: cfg,
+			Script:           string(block.Content()),
+			Capture:          block.Attributes().Get("capture"),
+		}, nil
+	case "sql":
+		database := block.Attributes().Get("db")
+		if database == "" && fmtr != nil {
+			database = fmtr.DB
+		}
+		return &runner.SQL{
+			ExecutableConfig: cfg,
+			Script:           string(block.Content()),
+			Database:         database,
+			Format:           block.Attributes().Get("format"),
+		}, nil
+	case "go", "rust", "c", "cpp":
+		return &runner.Compiled{
 			ExecutableConfig: cfg,
 			Source:           string(block.Content()),
+			LanguageID:       block.Language(),
+			GoVersion:        block.Attributes().Get("goVersion"),
+			Toolchain:        block.Attributes().Get("toolchain"),
+			WrapMain:         block.Attributes().Get("wrapMain") == "true",
 		}, nil
 	default:
 		return &runner.TempFile{
@@ -171,7 +207,11 @@ func (r *LocalRunner) RunTask(ctx context.Context, task project.Task) error {
 		}()
 	}
 
-	return errors.WithStack(executable.Run(ctx))
+	err = executable.Run(ctx)
+
+	r.handleResourceUsage(task, executable.ResourceUsage())
+
+	return errors.WithStack(err)
 }
 
 func (r *LocalRunner) runBlockInShell(ctx context.Context, block *document.CodeBlock) error {
@@ -0,0 +1,86 @@
+//go:build !windows
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/muesli/cancelreader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stateful/runme/internal/project"
+)
+
+func TestMain(m *testing.M) {
+	// Shell cells dump the environment by running "<executable> env dump",
+	// which is the test binary here.
+	if len(os.Args) > 2 && os.Args[1] == "env" && os.Args[2] == "dump" {
+		_, _ = os.Stdout.WriteString(strings.Join(os.Environ(), "\x00"))
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestLocalRunner_RunTaskCancelableStdin(t *testing.T) {
+	run := func(t *testing.T, data string) string {
+		t.Helper()
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "README.md")
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+		proj, err := project.NewFileProject(path)
+		require.NoError(t, err)
+
+		tasks, err := project.LoadTasks(context.Background(), proj)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+
+		// The pipe is never written to, so the stdin copy keeps
+		// reading until the runner cancels it after the cell exits.
+		pr, pw, err := os.Pipe()
+		require.NoError(t, err)
+		defer func() { _ = pw.Close() }()
+
+		stdin, err := cancelreader.NewReader(pr)
+		require.NoError(t, err)
+
+		stdout := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+
+		localRunner, err := NewLocalRunner(
+			WithDir(dir),
+			WithProject(proj),
+			WithStdin(stdin),
+			WithStdout(stdout),
+			WithStderr(stderr),
+		)
+		require.NoError(t, err)
+
+		require.NoError(t, localRunner.RunTask(context.Background(), tasks[0]), stderr.String())
+
+		return stdout.String()
+	}
+
+	t.Run("Shell", func(t *testing.T) {
+		out := run(t, "```sh {\"name\":\"hello\"}\necho hello\n```\n")
+		assert.Contains(t, out, "hello")
+	})
+
+	t.Run("Compiled", func(t *testing.T) {
+		if _, err := exec.LookPath("go"); err != nil {
+			t.Skip("go is not available")
+		}
+
+		out := run(t, "```go {\"name\":\"hello\"}\npackage main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n```\n")
+		assert.Contains(t, out, "hello")
+	})
+}
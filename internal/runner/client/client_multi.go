@@ -26,6 +26,11 @@ type MultiRunner struct {
 	PostRunMsg func(task project.Task, exitCode uint) string
 
 	PreRunOpts []RunnerOption
+	// PreRunTaskOpts returns options applied
+	// to a runner of a particular task.
+	PreRunTaskOpts func(task project.Task) []RunnerOption
+	// PostRun is invoked after each task finishes.
+	PostRun func(task project.Task, err error)
 }
 
 type prefixWriter struct {
@@ -90,6 +95,12 @@ func (m MultiRunner) RunBlocks(ctx context.Context, tasks []project.Task, parall
 			return err
 		}
 
+		if m.PreRunTaskOpts != nil {
+			if err := ApplyOptions(runnerClient, m.PreRunTaskOpts(task)...); err != nil {
+				return err
+			}
+		}
+
 		if m.PreRunMsg != nil && !parallel {
 			_, _ = m.Runner.getSettings().stdout.Write([]byte(
 				m.PreRunMsg([]project.Task{task}, parallel),
@@ -131,6 +142,10 @@ func (m MultiRunner) RunBlocks(ctx context.Context, tasks []project.Task, parall
 				code = exitErr.Code
 			}
 
+			if m.PostRun != nil {
+				m.PostRun(task, err)
+			}
+
 			if m.PostRunMsg != nil {
 				_, _ = m.Runner.getSettings().stdout.Write([]byte(
 					m.PostRunMsg(task, code),
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/muesli/cancelreader"
 	"github.com/pkg/errors"
@@ -124,6 +125,13 @@ func (r *RemoteRunner) RunTask(ctx context.Context, task project.Task) error {
 		return err
 	}
 
+	// Statements of SQL cells and requests of HTTP cells are
+	// executed by the local runner itself. The server has no equivalent.
+	switch block.Language() {
+	case "sql", "http":
+		return errors.Errorf("%s cells are not supported by the remote runner", block.Language())
+	}
+
 	stream, err := r.client.Execute(ctx)
 	if err != nil {
 		return err
@@ -136,7 +144,7 @@ func (r *RemoteRunner) RunTask(ctx context.Context, task project.Task) error {
 		customShell = fmtr.Shell
 	}
 
-	programName, commandMode := runner.GetCellProgram(block.Language(), customShell, block)
+	programName, commandMode := runner.GetCellProgram(block.Language(), customShell, block, r.languages)
 
 	var commandModeGrpc runnerv1.CommandMode
 
@@ -165,6 +173,23 @@ func (r *RemoteRunner) RunTask(ctx context.Context, task project.Task) error {
 
 	req.Project = ConvertToRunnerProject(r.project)
 
+	limits, err := runner.CellResourceLimits(block)
+	if err != nil {
+		return err
+	}
+	req.ResourceLimits = convertToRunnerv1ResourceLimits(limits)
+
+	sandboxCfg, err := r.sandboxConfig(task)
+	if err != nil {
+		return err
+	}
+	if sandboxCfg != nil {
+		// The project root is added by the server.
+		req.Sandbox = &runnerv1.Sandbox{
+			DisableNetwork: sandboxCfg.DisableNetwork,
+		}
+	}
+
 	req.Directory = ResolveDirectory(req.Directory, task)
 
 	if r.sessionStrategy == runnerv1.SessionStrategy_SESSION_STRATEGY_MOST_RECENT {
@@ -193,7 +218,7 @@ func (r *RemoteRunner) RunTask(ctx context.Context, task project.Task) error {
 				_ = canceler.Cancel()
 			}
 		}()
-		return r.recvLoop(stream, background, req.LanguageId)
+		return r.recvLoop(stream, task, background)
 	})
 
 	return g.Wait()
@@ -234,7 +259,9 @@ func (r *RemoteRunner) sendLoop(stream runnerv1.RunnerService_ExecuteClient, std
 	}
 }
 
-func (r *RemoteRunner) recvLoop(stream runnerv1.RunnerService_ExecuteClient, background bool, languageID string) error {
+func (r *RemoteRunner) recvLoop(stream runnerv1.RunnerService_ExecuteClient, task project.Task, background bool) error {
+	languageID := task.CodeBlock.Language()
+
 	for {
 		msg, err := stream.Recv()
 		if err != nil {
@@ -271,6 +298,8 @@ func (r *RemoteRunner) recvLoop(stream runnerv1.RunnerService_ExecuteClient, bac
 			}
 		}
 		if msg.ExitCode != nil {
+			r.handleResourceUsage(task, convertRunnerv1ResourceUsage(msg.ResourceUsage))
+
 			if msg.ExitCode.Value > 0 {
 				return &runner.ExitError{Code: uint(msg.ExitCode.Value)}
 			}
@@ -283,6 +312,19 @@ func (r *RemoteRunner) recvLoop(stream runnerv1.RunnerService_ExecuteClient, bac
 	}
 }
 
+func convertRunnerv1ResourceUsage(usage *runnerv1.ResourceUsage) *runner.ResourceUsage {
+	if usage == nil {
+		return nil
+	}
+
+	return &runner.ResourceUsage{
+		WallTime:   time.Duration(usage.WallTimeMs) * time.Millisecond,
+		UserTime:   time.Duration(usage.UserTimeMs) * time.Millisecond,
+		SystemTime: time.Duration(usage.SystemTimeMs) * time.Millisecond,
+		MaxRSS:     usage.MaxRssBytes,
+	}
+}
+
 func (r *RemoteRunner) GetEnvs(ctx context.Context) ([]string, error) {
 	if r.sessionID == "" {
 		return nil, nil
@@ -297,3 +339,16 @@ func (r *RemoteRunner) GetEnvs(ctx context.Context) ([]string, error) {
 
 	return resp.Session.Envs, nil
 }
+
+func convertToRunnerv1ResourceLimits(limits *runner.ResourceLimits) *runnerv1.ResourceLimits {
+	if limits == nil {
+		return nil
+	}
+
+	return &runnerv1.ResourceLimits{
+		CpuTimeSec:  limits.CPUTimeSeconds(),
+		MemoryBytes: limits.Memory,
+		OpenFiles:   limits.OpenFiles,
+		Processes:   limits.Processes,
+	}
+}
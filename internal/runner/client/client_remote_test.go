@@ -0,0 +1,32 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/document/identity"
+	"github.com/stateful/runme/internal/project"
+)
+
+func TestRemoteRunner_RunTaskUnsupportedLanguages(t *testing.T) {
+	data := []byte("```sql {\"name\":\"query\"}\nSELECT 1;\n```\n\n```http {\"name\":\"request\"}\nGET https://example.com\n```\n")
+
+	resolver := identity.NewResolver(identity.UnspecifiedLifecycleIdentity)
+	node, err := document.New(data, resolver).Root()
+	require.NoError(t, err)
+
+	blocks := document.CollectCodeBlocks(node)
+	require.Len(t, blocks, 2)
+
+	remoteRunner := &RemoteRunner{RunnerSettings: &RunnerSettings{}}
+
+	err = remoteRunner.RunTask(context.Background(), project.Task{CodeBlock: blocks[0], DocumentPath: "README.md"})
+	assert.EqualError(t, err, "sql cells are not supported by the remote runner")
+
+	err = remoteRunner.RunTask(context.Background(), project.Task{CodeBlock: blocks[1], DocumentPath: "README.md"})
+	assert.EqualError(t, err, "http cells are not supported by the remote runner")
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/stateful/runme/internal/project"
+	"github.com/stateful/runme/internal/runner"
+)
+
+// Report describes results of running tasks.
+type Report struct {
+	Tasks []*TaskReport `json:"tasks"`
+}
+
+// TaskReport is a result of running a single task.
+// Output contains both stdout and stderr, including
+// ANSI escape codes, in the order they were written.
+type TaskReport struct {
+	Name       string    `json:"name"`
+	Document   string    `json:"document"`
+	ExitCode   int       `json:"exitCode"`
+	StartTime  time.Time `json:"startTime"`
+	DurationMs int64     `json:"durationMs"`
+	Output     string    `json:"output"`
+
+	output syncBuffer
+}
+
+func (r *TaskReport) Duration() time.Duration {
+	return time.Duration(r.DurationMs) * time.Millisecond
+}
+
+// Lookup returns the last report of a task
+// with the name from the document.
+func (r *Report) Lookup(document, name string) *TaskReport {
+	for i := len(r.Tasks) - 1; i >= 0; i-- {
+		task := r.Tasks[i]
+		if task.Name == name && (document == "" || task.Document == document) {
+			return task
+		}
+	}
+	return nil
+}
+
+func ReadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse report %s", path)
+	}
+	return &report, nil
+}
+
+// ReportRecorder records outputs, exit codes and durations of tasks.
+type ReportRecorder struct {
+	mu     sync.Mutex
+	report Report
+}
+
+func NewReportRecorder() *ReportRecorder {
+	return &ReportRecorder{}
+}
+
+// Options returns options which capture output of the task.
+// They must be applied to a runner right before running it.
+func (r *ReportRecorder) Options(task project.Task) []RunnerOption {
+	taskReport := &TaskReport{
+		Name:      task.CodeBlock.Name(),
+		Document:  task.DocumentPath,
+		ExitCode:  -1,
+		StartTime: time.Now(),
+	}
+
+	r.mu.Lock()
+	r.report.Tasks = append(r.report.Tasks, taskReport)
+	r.mu.Unlock()
+
+	return []RunnerOption{
+		WithStdoutTransform(func(w io.Writer) io.Writer {
+			return io.MultiWriter(w, &taskReport.output)
+		}),
+		WithStderrTransform(func(w io.Writer) io.Writer {
+			return io.MultiWriter(w, &taskReport.output)
+		}),
+	}
+}
+
+// Finish records the result of the task.
+func (r *ReportRecorder) Finish(task project.Task, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := len(r.report.Tasks) - 1; i >= 0; i-- {
+		taskReport := r.report.Tasks[i]
+		if taskReport.Name != task.CodeBlock.Name() || taskReport.Document != task.DocumentPath || taskReport.ExitCode != -1 {
+			continue
+		}
+
+		taskReport.DurationMs = time.Since(taskReport.StartTime).Milliseconds()
+		taskReport.ExitCode = 0
+
+		if exitErr := (*runner.ExitError)(nil); errors.As(err, &exitErr) {
+			taskReport.ExitCode = int(exitErr.Code)
+		} else if err != nil {
+			taskReport.ExitCode = 1
+		}
+		return
+	}
+}
+
+// Write writes the report as JSON to path.
+func (r *ReportRecorder) Write(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, task := range r.report.Tasks {
+		task.Output = task.output.String()
+	}
+
+	data, err := json.MarshalIndent(&r.report, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(path, append(data, '\n'), 0o644))
+}
+
+// syncBuffer is a buffer safe to write from stdout and stderr concurrently.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/document/identity"
+	"github.com/stateful/runme/internal/project"
+	"github.com/stateful/runme/internal/runner"
+)
+
+func TestReportRecorder(t *testing.T) {
+	data := []byte("```sh {\"name\":\"ok\"}\necho ok\n```\n\n```sh {\"name\":\"fail\"}\nexit 2\n```\n")
+
+	resolver := identity.NewResolver(identity.UnspecifiedLifecycleIdentity)
+	node, err := document.New(data, resolver).Root()
+	require.NoError(t, err)
+
+	blocks := document.CollectCodeBlocks(node)
+	require.Len(t, blocks, 2)
+
+	okTask := project.Task{CodeBlock: blocks[0], DocumentPath: "/docs/README.md"}
+	failTask := project.Task{CodeBlock: blocks[1], DocumentPath: "/docs/README.md"}
+
+	recorder := NewReportRecorder()
+
+	localRunner := &LocalRunner{RunnerSettings: &RunnerSettings{}}
+	require.NoError(t, ApplyOptions(localRunner, WithStdout(io.Discard)))
+	require.NoError(t, ApplyOptions(localRunner, recorder.Options(okTask)...))
+	_, err = localRunner.stdout.Write([]byte("\x1b[32mok\x1b[0m\n"))
+	require.NoError(t, err)
+	recorder.Finish(okTask, nil)
+
+	_ = recorder.Options(failTask)
+	recorder.Finish(failTask, &runner.ExitError{Code: 2})
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, recorder.Write(path))
+
+	report, err := ReadReport(path)
+	require.NoError(t, err)
+	require.Len(t, report.Tasks, 2)
+
+	ok := report.Lookup("/docs/README.md", "ok")
+	require.NotNil(t, ok)
+	assert.Equal(t, 0, ok.ExitCode)
+	assert.Equal(t, "\x1b[32mok\x1b[0m\n", ok.Output)
+
+	fail := report.Lookup("", "fail")
+	require.NotNil(t, fail)
+	assert.Equal(t, 2, fail.ExitCode)
+
+	assert.Nil(t, report.Lookup("/other.md", "ok"))
+}
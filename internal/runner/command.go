@@ -12,9 +12,12 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/creack/pty"
 	"github.com/pkg/errors"
+	"github.com/stateful/runme/internal/executable"
+	"github.com/stateful/runme/internal/sandbox"
 	ulid "github.com/stateful/runme/internal/ulid"
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
@@ -27,6 +30,10 @@ const (
 
 var dumpCmd = getDumpCmd()
 
+var sandboxExecCmd = getSandboxExecCmd()
+
+var limitsExecCmd = getLimitsExecCmd()
+
 type command struct {
 	ProgramPath string
 	Args        []string
@@ -39,8 +46,15 @@ type command struct {
 	PreEnv  []string
 	PostEnv []string
 
+	ResourceLimits *ResourceLimits
+
+	// Sandbox, if not nil, restricts the program.
+	Sandbox *sandbox.Config
+
 	cmd *exec.Cmd
 
+	limiter *resourceLimiter
+
 	// pty and tty as pseud-terminal primary and secondary.
 	// Might be nil if not allocating a pseudo-terminal.
 	pty *os.File
@@ -50,6 +64,9 @@ type command struct {
 
 	tempScriptFile string
 
+	startedAt  time.Time
+	finishedAt time.Time
+
 	wg  sync.WaitGroup
 	mu  sync.Mutex
 	err error
@@ -102,6 +119,13 @@ type commandConfig struct {
 	LanguageID    string
 	FileExtension string
 
+	ResourceLimits *ResourceLimits
+	Sandbox        *sandbox.Config
+
+	// Languages are custom languages used to
+	// resolve the program and the file extension.
+	Languages *executable.Languages
+
 	Logger *zap.Logger
 }
 
@@ -109,7 +133,7 @@ func newCommand(cfg *commandConfig) (*command, error) {
 	programName, initialArgs := parseFileProgram(cfg.ProgramName)
 	args := initialArgs
 
-	programPath, initialArgs, err := inferFileProgram(programName, cfg.LanguageID)
+	programPath, initialArgs, err := inferFileProgram(programName, cfg.LanguageID, cfg.Languages)
 	args = append(args, initialArgs...)
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -129,27 +153,17 @@ func newCommand(cfg *commandConfig) (*command, error) {
 		envStorePath string
 	)
 
-	isShell := false
+	isShell := isShellProgram(programPath, cfg.Languages)
 
 	var tempScriptFile string
 
 	fileExtension := cfg.FileExtension
 	if fileExtension == "" {
-		fileExtension = inferFileExtension(cfg.LanguageID)
+		fileExtension = inferFileExtension(cfg.LanguageID, cfg.Languages)
 	}
 
 	var tmpEnvDir string
 
-	for _, candidate := range []string{
-		"bash", "sh", "ksh", "zsh", "fish", "powershell", "pwsh", "cmd",
-	} {
-		cmdName := filepath.Base(programPath)
-
-		if cmdName == candidate {
-			isShell = true
-		}
-	}
-
 	if cfg.CommandMode != CommandModeNone && (len(cfg.Commands) > 0 || cfg.Script != "") {
 		var err error
 		envStorePath, err = os.MkdirTemp("", "")
@@ -243,6 +257,8 @@ func newCommand(cfg *commandConfig) (*command, error) {
 		Stderr:         cfg.Stderr,
 		PreEnv:         cfg.PreEnv,
 		PostEnv:        cfg.PostEnv,
+		ResourceLimits: cfg.ResourceLimits,
+		Sandbox:        cfg.Sandbox,
 		logger:         cfg.Logger,
 		tempScriptFile: tempScriptFile,
 		tmpEnvDir:      tmpEnvDir,
@@ -263,6 +279,20 @@ func newCommand(cfg *commandConfig) (*command, error) {
 	return cmd, nil
 }
 
+func isShellProgram(programPath string, languages *executable.Languages) bool {
+	cmdName := filepath.Base(programPath)
+
+	for _, candidate := range []string{
+		"bash", "sh", "ksh", "zsh", "fish", "powershell", "pwsh", "cmd",
+	} {
+		if cmdName == candidate {
+			return true
+		}
+	}
+
+	return languages.IsShellInterpreter(cmdName)
+}
+
 func (c *command) cleanup() {
 	var err error
 
@@ -292,6 +322,13 @@ func (c *command) cleanup() {
 		}
 	}
 
+	if c.limiter != nil {
+		// It's not fatal, hence, the error is only logged.
+		if e := c.limiter.Cleanup(); e != nil {
+			c.logger.Info("failed to clean up resource limits", zap.Error(e))
+		}
+	}
+
 	c.seterr(err)
 }
 
@@ -332,11 +369,35 @@ func (c *command) StartWithOpts(ctx context.Context, opts *startOpts) error {
 		setSysProcAttrPgid(c.cmd)
 	}
 
+	// Resource limits are applied right before executing the program,
+	// hence, they are set up first and wrapped by the sandbox.
+	if !c.ResourceLimits.IsZero() {
+		limiter, err := newResourceLimiter(c.cmd, c.ResourceLimits)
+		if err != nil {
+			c.cleanup()
+			return err
+		}
+		c.limiter = limiter
+	}
+
+	if c.Sandbox != nil {
+		if err := c.setSandbox(); err != nil {
+			c.cleanup()
+			return err
+		}
+	}
+
 	if err := c.cmd.Start(); err != nil {
 		c.cleanup()
 		return errors.WithStack(err)
 	}
 
+	c.startedAt = time.Now()
+
+	if c.limiter != nil {
+		c.limiter.Started()
+	}
+
 	if c.tty != nil {
 		if opts.DisableEcho {
 			// Disable echoing. This solves the problem of duplicating entered line in the output.
@@ -398,6 +459,37 @@ func (c *command) StartWithOpts(ctx context.Context, opts *startOpts) error {
 	return nil
 }
 
+// setSandbox makes the command start the program through
+// "runme sandbox exec" which restricts writes to the file system
+// before executing it. The working directory, the temporary directory,
+// and devices are always writable.
+func (c *command) setSandbox() error {
+	if err := sandbox.Supported(); err != nil {
+		return err
+	}
+
+	writablePaths := append(
+		[]string{c.Directory, os.TempDir(), "/dev"},
+		c.Sandbox.WritablePaths...,
+	)
+
+	args := append([]string{}, sandboxExecCmd...)
+	for _, path := range writablePaths {
+		args = append(args, "--writable", path)
+	}
+	args = append(args, "--", c.cmd.Path)
+	args = append(args, c.cmd.Args[1:]...)
+
+	c.cmd.Path = args[0]
+	c.cmd.Args = args
+
+	if c.Sandbox.DisableNetwork {
+		sandbox.IsolateNetwork(c.cmd)
+	}
+
+	return nil
+}
+
 func (c *command) Kill() error {
 	return c.stop(os.Kill)
 }
@@ -495,7 +587,18 @@ func (c *command) collectEnvs() {
 // ProcessWait waits only for the process to exit.
 // You rather want to use Wait().
 func (c *command) ProcessWait() error {
-	return errors.WithStack(c.cmd.Wait())
+	err := c.cmd.Wait()
+	c.finishedAt = time.Now()
+	return errors.WithStack(err)
+}
+
+// ResourceUsage returns resources consumed by the process.
+// It is nil until the process exits.
+func (c *command) ResourceUsage() *ResourceUsage {
+	if c.cmd == nil || c.cmd.ProcessState == nil {
+		return nil
+	}
+	return newResourceUsage(c.cmd.ProcessState, c.finishedAt.Sub(c.startedAt))
 }
 
 // Finalize performs necessary actions and cleanups after the process exits.
@@ -561,6 +664,16 @@ func getDumpCmd() string {
 	return strings.Join([]string{path, "env", "dump", "--insecure"}, " ")
 }
 
+func getSandboxExecCmd() []string {
+	path, _ := os.Executable()
+	return []string{path, "sandbox", "exec"}
+}
+
+func getLimitsExecCmd() []string {
+	path, _ := os.Executable()
+	return []string{path, "limits", "exec"}
+}
+
 var fileExtensionByLanguageID = map[string]string{
 	"js":              "js",
 	"javascript":      "js",
@@ -584,7 +697,10 @@ var fileExtensionByLanguageID = map[string]string{
 	"rb":              "rb",
 }
 
-func inferFileExtension(languageID string) string {
+func inferFileExtension(languageID string, languages *executable.Languages) string {
+	if lang, ok := languages.Lookup(languageID); ok && lang.FileExtension != "" {
+		return lang.FileExtension
+	}
 	return fileExtensionByLanguageID[languageID]
 }
 
@@ -616,6 +732,25 @@ var programByLanguageID = map[string][]string{
 	"rb":         {"ruby"},
 }
 
+// LanguageInterpreter returns the preferred interpreter and its arguments
+// for languageID without checking if it is installed.
+func LanguageInterpreter(languageID string, languages *executable.Languages) (program string, args []string, ok bool) {
+	candidates := programByLanguageID[languageID]
+	var extraArgs []string
+
+	if lang, found := languages.Lookup(languageID); found {
+		candidates = lang.Interpreters
+		extraArgs = lang.Args
+	}
+
+	if len(candidates) == 0 {
+		return "", nil, false
+	}
+
+	program, args = parseFileProgram(candidates[0])
+	return program, append(args, extraArgs...), true
+}
+
 type ErrInvalidLanguage struct {
 	LanguageID string
 }
@@ -652,7 +787,7 @@ func parseFileProgram(programPath string) (program string, args []string) {
 	return
 }
 
-func inferFileProgram(programPath string, languageID string) (interpreter string, args []string, err error) {
+func inferFileProgram(programPath string, languageID string, languages *executable.Languages) (interpreter string, args []string, err error) {
 	if programPath != "" {
 		res, err := exec.LookPath(programPath)
 		if err != nil {
@@ -664,11 +799,20 @@ func inferFileProgram(programPath string, languageID string) (interpreter string
 		return res, []string{}, nil
 	}
 
-	for _, candidate := range programByLanguageID[languageID] {
+	candidates := programByLanguageID[languageID]
+	var extraArgs []string
+
+	// Languages from the config file take precedence over the built-in ones.
+	if lang, ok := languages.Lookup(languageID); ok {
+		candidates = lang.Interpreters
+		extraArgs = lang.Args
+	}
+
+	for _, candidate := range candidates {
 		program, args := parseFileProgram(candidate)
 		res, err := exec.LookPath(program)
 		if err == nil {
-			return res, args, nil
+			return res, append(args, extraArgs...), nil
 		}
 	}
 
@@ -5,9 +5,14 @@ package runner
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
 	"testing"
 	"time"
@@ -16,10 +21,71 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/exp/slices"
+
+	"github.com/stateful/runme/internal/sandbox"
+)
+
+const (
+	// sandboxExecTestArg makes the test binary act as "runme sandbox exec".
+	sandboxExecTestArg = "sandbox-exec"
+	// limitsExecTestArg makes the test binary act as "runme limits exec".
+	limitsExecTestArg = "limits-exec"
 )
 
 func init() {
 	dumpCmd = "env -0"
+	sandboxExecCmd = []string{os.Args[0], sandboxExecTestArg}
+	limitsExecCmd = []string{os.Args[0], limitsExecTestArg}
+}
+
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == sandboxExecTestArg {
+		var writablePaths []string
+
+		args := os.Args[2:]
+		for len(args) > 1 && args[0] == "--writable" {
+			writablePaths = append(writablePaths, args[1])
+			args = args[2:]
+		}
+		if len(args) > 0 && args[0] == "--" {
+			args = args[1:]
+		}
+
+		err := sandbox.Exec(writablePaths, args)
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == limitsExecTestArg {
+		var rlimits Rlimits
+
+		flags := map[string]*uint64{
+			"--cpu":    &rlimits.CPU,
+			"--nofile": &rlimits.NoFile,
+			"--as":     &rlimits.AS,
+			"--nproc":  &rlimits.NProc,
+		}
+
+		args := os.Args[2:]
+		for len(args) > 1 && flags[args[0]] != nil {
+			value, err := strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				_, _ = fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			*flags[args[0]] = value
+			args = args[2:]
+		}
+		if len(args) > 0 && args[0] == "--" {
+			args = args[1:]
+		}
+
+		err := ExecWithRlimits(rlimits, args)
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
 }
 
 func Test_command(t *testing.T) {
@@ -52,6 +118,108 @@ func Test_command(t *testing.T) {
 		assert.Equal(t, "", string(data))
 	})
 
+	t.Run("ResourceUsage", func(t *testing.T) {
+		t.Parallel()
+
+		cmd, err := newCommand(
+			&commandConfig{
+				ProgramName: "bash",
+				Stdout:      io.Discard,
+				Stderr:      io.Discard,
+				CommandMode: CommandModeInlineShell,
+				Commands:    []string{"sleep 0.5"},
+				Logger:      testCreateLogger(t),
+			},
+		)
+		require.NoError(t, err)
+		assert.Nil(t, cmd.ResourceUsage())
+		require.NoError(t, cmd.Start(context.Background()))
+		require.NoError(t, cmd.Wait())
+
+		usage := cmd.ResourceUsage()
+		require.NotNil(t, usage)
+		assert.GreaterOrEqual(t, usage.WallTime, 500*time.Millisecond)
+		assert.Greater(t, usage.MaxRSS, int64(0))
+	})
+
+	t.Run("ResourceLimits", func(t *testing.T) {
+		t.Parallel()
+
+		if runtime.GOOS != "linux" {
+			t.Skip("resource limits are supported only on Linux")
+		}
+
+		stdout := new(bytes.Buffer)
+
+		cmd, err := newCommand(
+			&commandConfig{
+				ProgramName: "bash",
+				Stdout:      stdout,
+				Stderr:      io.Discard,
+				CommandMode: CommandModeInlineShell,
+				Commands:    []string{"ulimit -n", "ulimit -t"},
+				Logger:      testCreateLogger(t),
+
+				ResourceLimits: &ResourceLimits{
+					CPUTime:   1500 * time.Millisecond,
+					OpenFiles: 64,
+				},
+			},
+		)
+		require.NoError(t, err)
+		require.NoError(t, cmd.Start(context.Background()))
+		require.NoError(t, cmd.Wait())
+		assert.Equal(t, "64\n2\n", stdout.String())
+	})
+
+	t.Run("Sandbox", func(t *testing.T) {
+		t.Parallel()
+
+		if err := sandbox.Supported(); err != nil {
+			t.Skip(err)
+		}
+
+		dir := t.TempDir()
+		// Temporary directories are writable within the sandbox.
+		// Use the current directory to test writing outside,
+		// unless it is a temporary directory itself.
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+		if isSubpath(t, os.TempDir(), wd) {
+			t.Skipf("working directory %q is within %q", wd, os.TempDir())
+		}
+
+		outsideDir, err := os.MkdirTemp(wd, "sandbox-outside-")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = os.RemoveAll(outsideDir) })
+
+		stdout := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+
+		cmd, err := newCommand(
+			&commandConfig{
+				ProgramName: "bash",
+				Directory:   dir,
+				Stdout:      stdout,
+				Stderr:      stderr,
+				CommandMode: CommandModeInlineShell,
+				Commands: []string{
+					"echo inside > inside.txt",
+					"cat inside.txt",
+					"echo outside > " + filepath.Join(outsideDir, "outside.txt"),
+				},
+				Logger:  testCreateLogger(t),
+				Sandbox: &sandbox.Config{},
+			},
+		)
+		require.NoError(t, err)
+		require.NoError(t, cmd.Start(context.Background()))
+		require.Error(t, cmd.Wait())
+		assert.Equal(t, "inside\n", stdout.String())
+		assert.Contains(t, stderr.String(), "Permission denied")
+		assert.NoFileExists(t, filepath.Join(outsideDir, "outside.txt"))
+	})
+
 	t.Run("BasicTempfile", func(t *testing.T) {
 		t.Parallel()
 
@@ -485,3 +653,20 @@ func Test_exitCodeFromErr(t *testing.T) {
 	assert.Error(t, exiterr)
 	assert.Equal(t, 99, exitCodeFromErr(exiterr))
 }
+
+// isSubpath reports whether path is parent or a path within parent
+// after resolving symbolic links in both of them.
+func isSubpath(t *testing.T, parent, path string) bool {
+	t.Helper()
+
+	parent, err := filepath.EvalSymlinks(parent)
+	require.NoError(t, err)
+	path, err = filepath.EvalSymlinks(path)
+	require.NoError(t, err)
+
+	rel, err := filepath.Rel(parent, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -5,6 +5,7 @@ package runner
 import (
 	"os"
 	"os/exec"
+	"runtime"
 	"syscall"
 
 	"github.com/pkg/errors"
@@ -55,3 +56,17 @@ func signalPgid(pid int, sig os.Signal) error {
 	}
 	return nil
 }
+
+func maxRSSFromProcessState(state *os.ProcessState) int64 {
+	rusage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || rusage == nil {
+		return 0
+	}
+
+	// ru_maxrss is in bytes on macOS and in kilobytes elsewhere.
+	if runtime.GOOS == "darwin" {
+		return rusage.Maxrss
+	}
+
+	return rusage.Maxrss * 1024
+}
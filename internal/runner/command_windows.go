@@ -21,3 +21,5 @@ func disableEcho(fd uintptr) error {
 }
 
 func signalPgid(pid int, sig os.Signal) error { return errors.New("unsupported") }
+
+func maxRSSFromProcessState(state *os.ProcessState) int64 { return 0 }
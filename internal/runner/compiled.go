@@ -0,0 +1,429 @@
+package runner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Compiled is an executable which builds a program from the source
+// of a cell and runs the resulting binary. Binaries are cached
+// by a hash of the source and the build options, so running
+// the same cell again does not rebuild it.
+type Compiled struct {
+	*ExecutableConfig
+	Source     string
+	LanguageID string
+	// GoVersion is a version of the Go language used in the generated
+	// go.mod, for example "1.21". Only Go cells support it.
+	GoVersion string
+	// Toolchain selects the compiler. For Go, it's a value of GOTOOLCHAIN,
+	// for example "go1.21.5". For Rust, it's a rustup toolchain, for example
+	// "nightly". For C and C++, it's the compiler program, for example "clang".
+	Toolchain string
+	// WrapMain wraps C and C++ source in the main function.
+	// It allows to write a snippet of statements instead
+	// of a complete program.
+	WrapMain bool
+	// CacheDir is a directory where built binaries are stored.
+	// If empty, DefaultBuildCacheDir is used.
+	CacheDir string
+	command  *command
+}
+
+var _ Executable = (*Compiled)(nil)
+
+// buildCacheMaxAge is how long a build is kept in the cache
+// since it was last used.
+const buildCacheMaxAge = 30 * 24 * time.Hour
+
+// DefaultBuildCacheDir returns a directory in the user's
+// cache directory where built binaries are stored.
+func DefaultBuildCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "runme", "build")
+}
+
+type compiler struct {
+	// program is the default compiler.
+	program string
+	// sourceFile is a name of the file with the source.
+	sourceFile string
+	// wrap turns a snippet into a complete program.
+	wrap func(c *Compiled) string
+	// args returns arguments of the compiler which builds
+	// sourceFile located in the current directory into output.
+	args func(c *Compiled, output string) []string
+}
+
+var compilers = map[string]compiler{
+	"go": {
+		program:    "go",
+		sourceFile: "main.go",
+		wrap: func(c *Compiled) string {
+			return wrapGoSource(c.Source)
+		},
+		args: func(c *Compiled, output string) []string {
+			if c.GoVersion != "" {
+				return []string{"build", "-o", output, "."}
+			}
+			return []string{"build", "-o", output, "main.go"}
+		},
+	},
+	"rust": {
+		program:    "rustc",
+		sourceFile: "main.rs",
+		wrap: func(c *Compiled) string {
+			return wrapRustSource(c.Source)
+		},
+		args: func(c *Compiled, output string) []string {
+			var args []string
+			if c.Toolchain != "" {
+				args = append(args, "+"+c.Toolchain)
+			}
+			return append(args, "--edition=2021", "-o", output, "main.rs")
+		},
+	},
+	"c": {
+		program:    "cc",
+		sourceFile: "main.c",
+		wrap: func(c *Compiled) string {
+			if !c.WrapMain {
+				return c.Source
+			}
+			return wrapCSource(c.Source, "int main(void) {")
+		},
+		args: func(c *Compiled, output string) []string {
+			return []string{"-o", output, "main.c"}
+		},
+	},
+	"cpp": {
+		program:    "c++",
+		sourceFile: "main.cpp",
+		wrap: func(c *Compiled) string {
+			if !c.WrapMain {
+				return c.Source
+			}
+			return wrapCSource(c.Source, "int main() {")
+		},
+		args: func(c *Compiled, output string) []string {
+			return []string{"-o", output, "main.cpp"}
+		},
+	},
+}
+
+func (c Compiled) compiler() (compiler, string, error) {
+	comp, ok := compilers[c.LanguageID]
+	if !ok {
+		return compiler{}, "", ErrInvalidLanguage{LanguageID: c.LanguageID}
+	}
+
+	program := comp.program
+	// For C and C++, the toolchain is the compiler itself.
+	if c.Toolchain != "" && (c.LanguageID == "c" || c.LanguageID == "cpp") {
+		program = c.Toolchain
+	}
+
+	path, err := exec.LookPath(program)
+	if err != nil {
+		return compiler{}, "", ErrInvalidProgram{Program: program, inner: err}
+	}
+
+	return comp, path, nil
+}
+
+func (c Compiled) files(comp compiler) map[string]string {
+	files := map[string]string{
+		comp.sourceFile: comp.wrap(&c),
+	}
+	if c.LanguageID == "go" && c.GoVersion != "" {
+		files["go.mod"] = fmt.Sprintf("module runme/cell\n\ngo %s\n", c.GoVersion)
+	}
+	return files
+}
+
+func (c Compiled) env() []string {
+	env := os.Environ()
+	if c.LanguageID == "go" && c.Toolchain != "" {
+		env = append(env, "GOTOOLCHAIN="+c.Toolchain)
+	}
+	return env
+}
+
+func (c Compiled) cacheDir() string {
+	if c.CacheDir != "" {
+		return c.CacheDir
+	}
+	return DefaultBuildCacheDir()
+}
+
+// cacheKey identifies a build. The compiler's modification time
+// is included so that upgrading the compiler invalidates the cache.
+func (c Compiled) cacheKey(compilerPath string, files map[string]string) string {
+	h := sha256.New()
+
+	_, _ = fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00", c.LanguageID, c.Toolchain, c.GoVersion, compilerPath)
+	if info, err := os.Stat(compilerPath); err == nil {
+		_, _ = fmt.Fprintf(h, "%d\x00", info.ModTime().UnixNano())
+	}
+
+	for _, name := range []string{"go.mod", compilers[c.LanguageID].sourceFile} {
+		if content, ok := files[name]; ok {
+			_, _ = fmt.Fprintf(h, "%s\x00%s\x00", name, content)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func binaryName() string {
+	if os.PathSeparator == '\\' {
+		return "main.exe"
+	}
+	return "main"
+}
+
+// build returns a path to the binary built from the source.
+// If the binary is in the cache, it's returned without building.
+func (c Compiled) build(ctx context.Context) (string, error) {
+	comp, compilerPath, err := c.compiler()
+	if err != nil {
+		return "", err
+	}
+
+	files := c.files(comp)
+	cacheDir := c.cacheDir()
+	buildDir := filepath.Join(cacheDir, c.cacheKey(compilerPath, files))
+	binary := filepath.Join(buildDir, binaryName())
+
+	if _, err := os.Stat(binary); err == nil {
+		// Mark the build as used so that it's not pruned.
+		now := time.Now()
+		_ = os.Chtimes(buildDir, now, now)
+		return binary, nil
+	}
+
+	pruneBuildCache(cacheDir, buildCacheMaxAge)
+
+	if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+		return "", errors.Wrap(err, "failed to create build cache dir")
+	}
+
+	tmpDir, err := os.MkdirTemp(cacheDir, "tmp-*")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create a temp dir")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o600); err != nil {
+			return "", errors.Wrapf(err, "failed to write %s", name)
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, compilerPath, comp.args(&c, binaryName())...)
+	cmd.Dir = tmpDir
+	cmd.Env = c.env()
+	cmd.Stdout = c.Stderr
+	cmd.Stderr = c.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "failed to build %s", comp.sourceFile)
+	}
+
+	// Another process might have built the same source in the meantime.
+	if err := os.Rename(tmpDir, buildDir); err != nil {
+		if _, statErr := os.Stat(binary); statErr != nil {
+			return "", errors.Wrap(err, "failed to store the binary in the build cache")
+		}
+	}
+
+	return binary, nil
+}
+
+// pruneBuildCache removes builds which were not used for longer
+// than maxAge. Errors are ignored as pruning is best effort.
+func pruneBuildCache(cacheDir string, maxAge time.Duration) {
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return
+	}
+
+	deadline := time.Now().Add(-maxAge)
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(deadline) {
+			continue
+		}
+		_ = os.RemoveAll(filepath.Join(cacheDir, entry.Name()))
+	}
+}
+
+func (c Compiled) DryRun(ctx context.Context, w io.Writer) {
+	comp, compilerPath, err := c.compiler()
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "// failed to resolve compiler: %s\n", err)
+		return
+	}
+
+	files := c.files(comp)
+	binary := filepath.Join(c.cacheDir(), c.cacheKey(compilerPath, files), binaryName())
+
+	_, _ = fmt.Fprintf(w, "#!%s %s\n\n", compilerPath, strings.Join(comp.args(&c, binaryName()), " "))
+	if c.LanguageID == "go" && c.Toolchain != "" {
+		_, _ = fmt.Fprintf(w, "// set GOTOOLCHAIN=%q\n", c.Toolchain)
+	}
+	if _, err := os.Stat(binary); err == nil {
+		_, _ = fmt.Fprintf(w, "// use cached binary %q\n", binary)
+	} else {
+		_, _ = fmt.Fprintf(w, "// build binary %q\n", binary)
+	}
+	_, _ = fmt.Fprintf(w, "// run in %q\n\n", c.Dir)
+	_, _ = fmt.Fprintf(w, "%s\n", strings.TrimRight(files[comp.sourceFile], "\n"))
+}
+
+func (c *Compiled) Run(ctx context.Context) error {
+	binary, err := c.build(ctx)
+	if err != nil {
+		return err
+	}
+
+	cmd, err := newCommand(&commandConfig{
+		ProgramName: binary,
+		Directory:   c.Dir,
+		Session:     c.Session,
+		Tty:         c.Tty,
+		Stdin:       c.Stdin,
+		Stdout:      c.Stdout,
+		Stderr:      c.Stderr,
+		PreEnv:      c.PreEnv,
+		PostEnv:     c.PostEnv,
+		CommandMode: CommandModeNone,
+		Logger:      c.Logger,
+
+		ResourceLimits: c.ResourceLimits,
+		Sandbox:        c.Sandbox,
+		Languages:      c.Languages,
+	})
+	if err != nil {
+		return err
+	}
+	c.command = cmd
+	defer func() { _ = c.command.Finalize() }()
+
+	return runCommand(ctx, cmd, c.Name, c.Tty)
+}
+
+func (c Compiled) ExitCode() int {
+	if c.command == nil || c.command.cmd == nil {
+		return -1
+	}
+
+	return c.command.cmd.ProcessState.ExitCode()
+}
+
+func (c Compiled) ResourceUsage() *ResourceUsage {
+	if c.command == nil {
+		return nil
+	}
+
+	return c.command.ResourceUsage()
+}
+
+var (
+	goPackageRe = regexp.MustCompile(`(?m)^\s*package\s+\w+`)
+	rustMainRe  = regexp.MustCompile(`(?m)^\s*(pub\s+)?fn\s+main\s*\(`)
+)
+
+// wrapGoSource wraps statements in a main function unless
+// the source is already a complete file. Leading imports
+// are moved in front of the function.
+func wrapGoSource(source string) string {
+	if goPackageRe.MatchString(source) {
+		return source
+	}
+
+	inBlock := false
+	header, body := splitPreamble(source, func(line string) bool {
+		switch {
+		case inBlock:
+			inBlock = line != ")"
+			return true
+		case strings.HasPrefix(line, "import ("):
+			inBlock = true
+			return true
+		case strings.HasPrefix(line, "import "):
+			return true
+		}
+		return false
+	})
+
+	return fmt.Sprintf("package main\n\n%sfunc main() {\n%s\n}\n", header, body)
+}
+
+// wrapRustSource wraps statements in a main function unless
+// it's already defined. Leading "use" declarations are moved
+// in front of the function.
+func wrapRustSource(source string) string {
+	if rustMainRe.MatchString(source) {
+		return source
+	}
+
+	header, body := splitPreamble(source, func(line string) bool {
+		return strings.HasPrefix(line, "use ") || strings.HasPrefix(line, "extern crate ")
+	})
+
+	return fmt.Sprintf("%sfn main() {\n%s\n}\n", header, body)
+}
+
+// wrapCSource wraps statements in the main function. Leading
+// preprocessor directives are moved in front of the function.
+func wrapCSource(source string, mainDecl string) string {
+	header, body := splitPreamble(source, func(line string) bool {
+		return strings.HasPrefix(line, "#")
+	})
+
+	return fmt.Sprintf("%s%s\n%s\nreturn 0;\n}\n", header, mainDecl, body)
+}
+
+// splitPreamble splits source into leading lines for which
+// isPreamble returns true, and the rest. Empty lines and
+// comments are part of the preamble.
+func splitPreamble(source string, isPreamble func(line string) bool) (header, body string) {
+	lines := strings.Split(source, "\n")
+
+	i := 0
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "//") || isPreamble(line) {
+			continue
+		}
+		break
+	}
+
+	// The header is separated from the rest by an empty line.
+	header = strings.TrimRight(strings.Join(lines[:i], "\n"), "\n")
+	if header != "" {
+		header += "\n\n"
+	}
+	body = strings.TrimRight(strings.Join(lines[i:], "\n"), "\n")
+
+	return header, body
+}
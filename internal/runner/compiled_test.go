@@ -0,0 +1,166 @@
+//go:build !windows
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_wrapGoSource(t *testing.T) {
+	assert.Equal(
+		t,
+		"package main\n\nimport \"fmt\"\nimport (\n\t\"os\"\n)\n\nfunc main() {\nfmt.Println(os.Args)\n}\n",
+		wrapGoSource("import \"fmt\"\nimport (\n\t\"os\"\n)\n\nfmt.Println(os.Args)\n"),
+	)
+
+	complete := "package main\n\nfunc main() {}\n"
+	assert.Equal(t, complete, wrapGoSource(complete))
+}
+
+func Test_wrapRustSource(t *testing.T) {
+	assert.Equal(
+		t,
+		"use std::env;\n\nfn main() {\nprintln!(\"{:?}\", env::args());\n}\n",
+		wrapRustSource("use std::env;\nprintln!(\"{:?}\", env::args());"),
+	)
+}
+
+func Test_wrapCSource(t *testing.T) {
+	assert.Equal(
+		t,
+		"#include <stdio.h>\n\nint main(void) {\nputs(\"hi\");\nreturn 0;\n}\n",
+		wrapCSource("#include <stdio.h>\nputs(\"hi\");\n", "int main(void) {"),
+	)
+}
+
+func TestCompiled(t *testing.T) {
+	t.Parallel()
+
+	run := func(t *testing.T, compiled *Compiled) string {
+		t.Helper()
+
+		stdout := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+
+		session, err := NewSession(os.Environ(), zap.NewNop())
+		require.NoError(t, err)
+
+		compiled.ExecutableConfig = &ExecutableConfig{
+			Dir:     t.TempDir(),
+			Stdout:  stdout,
+			Stderr:  stderr,
+			Session: session,
+			Logger:  zap.NewNop(),
+		}
+
+		err = compiled.Run(context.Background())
+		require.NoError(t, err, stderr.String())
+		assert.Equal(t, 0, compiled.ExitCode())
+
+		return stdout.String()
+	}
+
+	t.Run("Go", func(t *testing.T) {
+		if _, err := exec.LookPath("go"); err != nil {
+			t.Skip("go is not available")
+		}
+
+		cacheDir := t.TempDir()
+		source := "import \"fmt\"\n\nfmt.Println(\"hello\")"
+
+		output := run(t, &Compiled{Source: source, LanguageID: "go", CacheDir: cacheDir})
+		assert.Equal(t, "hello\n", output)
+
+		binaries, err := filepath.Glob(filepath.Join(cacheDir, "*", "main"))
+		require.NoError(t, err)
+		require.Len(t, binaries, 1)
+
+		info, err := os.Stat(binaries[0])
+		require.NoError(t, err)
+
+		// The second run uses the cached binary.
+		output = run(t, &Compiled{Source: source, LanguageID: "go", CacheDir: cacheDir})
+		assert.Equal(t, "hello\n", output)
+
+		cachedInfo, err := os.Stat(binaries[0])
+		require.NoError(t, err)
+		assert.Equal(t, info.ModTime(), cachedInfo.ModTime())
+
+		// A different Go version results in a new build.
+		output = run(t, &Compiled{Source: source, LanguageID: "go", GoVersion: "1.21", CacheDir: cacheDir})
+		assert.Equal(t, "hello\n", output)
+
+		binaries, err = filepath.Glob(filepath.Join(cacheDir, "*", "main"))
+		require.NoError(t, err)
+		assert.Len(t, binaries, 2)
+	})
+
+	t.Run("C", func(t *testing.T) {
+		if _, err := exec.LookPath("cc"); err != nil {
+			t.Skip("cc is not available")
+		}
+
+		output := run(t, &Compiled{
+			Source:     "#include <stdio.h>\nputs(\"hello\");",
+			LanguageID: "c",
+			WrapMain:   true,
+			CacheDir:   t.TempDir(),
+		})
+		assert.Equal(t, "hello\n", output)
+	})
+
+	t.Run("CFailure", func(t *testing.T) {
+		if _, err := exec.LookPath("cc"); err != nil {
+			t.Skip("cc is not available")
+		}
+
+		session, err := NewSession(os.Environ(), zap.NewNop())
+		require.NoError(t, err)
+
+		compiled := &Compiled{
+			ExecutableConfig: &ExecutableConfig{
+				Dir:     t.TempDir(),
+				Stdout:  io.Discard,
+				Stderr:  io.Discard,
+				Session: session,
+				Logger:  zap.NewNop(),
+			},
+			Source:     "int main(void) { return 3; }",
+			LanguageID: "c",
+			CacheDir:   t.TempDir(),
+		}
+
+		err = compiled.Run(context.Background())
+		var exitErr *ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 3, compiled.ExitCode())
+	})
+}
+
+func Test_pruneBuildCache(t *testing.T) {
+	cacheDir := t.TempDir()
+
+	stale := filepath.Join(cacheDir, "stale")
+	fresh := filepath.Join(cacheDir, "fresh")
+	require.NoError(t, os.Mkdir(stale, 0o700))
+	require.NoError(t, os.Mkdir(fresh, 0o700))
+
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(stale, old, old))
+
+	pruneBuildCache(cacheDir, time.Hour)
+
+	assert.NoDirExists(t, stale)
+	assert.DirExists(t, fresh)
+}
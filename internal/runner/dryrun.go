@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// dryRun writes what would be executed for cfg without starting
+// anything: the resolved program, the working directory, changes
+// to the environment of the current process, and the final script.
+func dryRun(w io.Writer, cfg *commandConfig) error {
+	var b bytes.Buffer
+
+	programName, args := parseFileProgram(cfg.ProgramName)
+	programPath, initialArgs, err := inferFileProgram(programName, cfg.LanguageID, cfg.Languages)
+	if err != nil {
+		_, _ = fmt.Fprintf(&b, "// failed to resolve program: %s\n\n", err)
+		programPath = programName
+	}
+	args = append(args, initialArgs...)
+	args = append(args, cfg.Args...)
+
+	isShell := isShellProgram(programPath, cfg.Languages)
+
+	_, _ = b.WriteString(fmt.Sprintf("#!%s\n\n", strings.Join(append([]string{programPath}, args...), " ")))
+	_, _ = b.WriteString(fmt.Sprintf("// run in %q\n", cfg.Directory))
+
+	if cfg.Session != nil {
+		set, unset := dryRunEnvDiff(os.Environ(), dryRunEnvs(cfg))
+		for _, env := range set {
+			k, v := splitEnv(env)
+			_, _ = b.WriteString(fmt.Sprintf("// set %s=%q\n", k, v))
+		}
+		for _, k := range unset {
+			_, _ = b.WriteString(fmt.Sprintf("// unset %s\n", k))
+		}
+	}
+
+	if cfg.CommandMode == CommandModeTempFile {
+		fileExtension := cfg.FileExtension
+		if fileExtension == "" {
+			fileExtension = inferFileExtension(cfg.LanguageID, cfg.Languages)
+		}
+		if fileExtension != "" {
+			_, _ = b.WriteString(fmt.Sprintf("// script saved to a temporary .%s file\n", fileExtension))
+		} else {
+			_, _ = b.WriteString("// script saved to a temporary file\n")
+		}
+	}
+
+	_, _ = b.WriteString("\n")
+
+	var script string
+
+	switch {
+	case isShell && len(cfg.Commands) > 0:
+		script = prepareScriptFromCommands(cfg.Commands, ShellFromShellPath(programPath))
+	case isShell:
+		script = prepareScript(cfg.Script, ShellFromShellPath(programPath))
+	case len(cfg.Commands) > 0:
+		script = strings.Join(cfg.Commands, "\n")
+	default:
+		script = cfg.Script
+	}
+
+	_, _ = b.WriteString(strings.TrimRight(script, "\n") + "\n")
+
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+// dryRunEnvs returns variables reported by dry-run in the order
+// of precedence: the project's dotenv files in PreEnv, the session,
+// and PostEnv.
+func dryRunEnvs(cfg *commandConfig) []string {
+	var envs []string
+	envs = append(envs, cfg.PreEnv...)
+	envs = append(envs, cfg.Session.Envs()...)
+	return append(envs, cfg.PostEnv...)
+}
+
+// dryRunEnvDiff returns sorted variables which are new or updated
+// in the session, and names of variables missing in the session.
+func dryRunEnvDiff(current, session []string) (set, unset []string) {
+	set, _, unset = diffEnvStores(newEnvStore(current...), newEnvStore(session...))
+	sort.Strings(set)
+	sort.Strings(unset)
+	return
+}
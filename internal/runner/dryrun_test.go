@@ -0,0 +1,91 @@
+//go:build !windows
+
+package runner
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_dryRun(t *testing.T) {
+	bashPath, err := exec.LookPath("bash")
+	require.NoError(t, err)
+
+	t.Run("InlineShell", func(t *testing.T) {
+		session, err := NewSession(append(os.Environ(), "RUNME_DRY_RUN_TEST=1"), zap.NewNop())
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		err = dryRun(&b, &commandConfig{
+			ProgramName: "bash",
+			Directory:   "/tmp",
+			Session:     session,
+			CommandMode: CommandModeInlineShell,
+			Commands:    []string{"echo 1", "echo 2"},
+		})
+		require.NoError(t, err)
+		assert.Equal(
+			t,
+			"#!"+bashPath+"\n\n// run in \"/tmp\"\n// set RUNME_DRY_RUN_TEST=\"1\"\n\nset -e -o pipefail\necho 1\necho 2\n",
+			b.String(),
+		)
+	})
+
+	t.Run("PreAndPostEnv", func(t *testing.T) {
+		session, err := NewSession(append(os.Environ(), "RUNME_DRY_RUN_SESSION=session"), zap.NewNop())
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		err = dryRun(&b, &commandConfig{
+			ProgramName: "bash",
+			Directory:   "/tmp",
+			Session:     session,
+			PreEnv:      []string{"RUNME_DRY_RUN_DOTENV=dotenv", "RUNME_DRY_RUN_SESSION=dotenv"},
+			PostEnv:     []string{"RUNME_DRY_RUN_POST=post"},
+			CommandMode: CommandModeInlineShell,
+			Commands:    []string{"echo 1"},
+		})
+		require.NoError(t, err)
+		assert.Contains(
+			t,
+			b.String(),
+			"// set RUNME_DRY_RUN_DOTENV=\"dotenv\"\n// set RUNME_DRY_RUN_POST=\"post\"\n// set RUNME_DRY_RUN_SESSION=\"session\"\n",
+		)
+	})
+
+	t.Run("TempFile", func(t *testing.T) {
+		var b bytes.Buffer
+		err := dryRun(&b, &commandConfig{
+			ProgramName: "bash",
+			Directory:   "/tmp",
+			CommandMode: CommandModeTempFile,
+			LanguageID:  "sh",
+			Script:      "echo 1",
+		})
+		require.NoError(t, err)
+		assert.Equal(
+			t,
+			"#!"+bashPath+"\n\n// run in \"/tmp\"\n// script saved to a temporary .sh file\n\nset -e -o pipefail\necho 1\n",
+			b.String(),
+		)
+	})
+
+	t.Run("UnknownProgram", func(t *testing.T) {
+		var b bytes.Buffer
+		err := dryRun(&b, &commandConfig{
+			Directory:   "/tmp",
+			CommandMode: CommandModeTempFile,
+			LanguageID:  "unknown-lang",
+			Script:      "print 1",
+		})
+		require.NoError(t, err)
+		assert.Contains(t, b.String(), "// failed to resolve program: unsupported language unknown-lang")
+		assert.Contains(t, b.String(), "\nprint 1\n")
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 
 	"github.com/stateful/runme/internal/executable"
+	"github.com/stateful/runme/internal/sandbox"
 	"go.uber.org/zap"
 )
 
@@ -12,6 +13,9 @@ type Executable interface {
 	DryRun(context.Context, io.Writer)
 	Run(context.Context) error
 	ExitCode() int
+	// ResourceUsage returns resources consumed by the process.
+	// It is nil if the process has not finished.
+	ResourceUsage() *ResourceUsage
 }
 
 type ExecutableConfig struct {
@@ -25,6 +29,10 @@ type ExecutableConfig struct {
 	PostEnv []string
 	Session *Session
 	Logger  *zap.Logger
+
+	ResourceLimits *ResourceLimits
+	Sandbox        *sandbox.Config
+	Languages      *executable.Languages
 }
 
 func IsSupported(lang string) bool {
@@ -0,0 +1,264 @@
+package runner
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/stateful/runme/internal/rbuffer"
+	ulid "github.com/stateful/runme/internal/ulid"
+)
+
+// executionHistorySize limits the size of the output
+// kept by a background execution in order to replay it
+// to clients attaching later.
+const executionHistorySize = 1024 << 10 // 1 MiB
+
+const (
+	// executionRetention is how long a finished execution
+	// is kept around so that clients can attach to it.
+	executionRetention = 10 * time.Minute
+
+	// maxFinishedExecutions limits the number of finished
+	// executions kept in a single session.
+	maxFinishedExecutions = 16
+)
+
+// Execution is a background program started in a Session.
+// It keeps track of the most recent output so that clients
+// can attach to it after the initial stream is closed.
+type Execution struct {
+	ID        string
+	SessionID string
+	PID       int
+
+	cmd *command
+
+	mu         sync.Mutex
+	stdout     *rbuffer.RingBuffer
+	stderr     *rbuffer.RingBuffer
+	listeners  map[*executionListener]struct{}
+	exitCode   int
+	usage      *ResourceUsage
+	finishedAt time.Time
+	done       chan struct{}
+}
+
+// executionListener receives output of an Execution
+// from the moment it's attached.
+type executionListener struct {
+	Stdout *rbuffer.RingBuffer
+	Stderr *rbuffer.RingBuffer
+}
+
+func (l *executionListener) close() {
+	_ = l.Stdout.Close()
+	_ = l.Stderr.Close()
+}
+
+func newExecution(sessionID string, cmd *command) *Execution {
+	return &Execution{
+		ID:        ulid.GenerateID(),
+		SessionID: sessionID,
+		PID:       cmd.cmd.Process.Pid,
+		cmd:       cmd,
+		stdout:    rbuffer.NewRingBuffer(executionHistorySize),
+		stderr:    rbuffer.NewRingBuffer(executionHistorySize),
+		listeners: make(map[*executionListener]struct{}),
+		exitCode:  -1,
+		done:      make(chan struct{}),
+	}
+}
+
+// Running returns true until the process exits.
+func (e *Execution) Running() bool {
+	select {
+	case <-e.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// ExitCode returns the exit code of the process. It is -1
+// if the process is still running or the exit code is unknown.
+func (e *Execution) ExitCode() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.exitCode
+}
+
+// ResourceUsage returns resources consumed by the process.
+// It is nil until the process exits.
+func (e *Execution) ResourceUsage() *ResourceUsage {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.usage
+}
+
+// Done returns a channel which is closed when the process exits.
+func (e *Execution) Done() <-chan struct{} {
+	return e.done
+}
+
+// Stop sends a signal to the process.
+func (e *Execution) Stop(sig os.Signal) error {
+	if !e.Running() {
+		return errors.New("execution already finished")
+	}
+	if sig == os.Kill {
+		return e.cmd.Kill()
+	}
+	return e.cmd.StopWithSignal(sig)
+}
+
+func (e *Execution) write(data output) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(data.Stdout) > 0 {
+		_, _ = e.stdout.Write(data.Stdout)
+	}
+	if len(data.Stderr) > 0 {
+		_, _ = e.stderr.Write(data.Stderr)
+	}
+
+	for l := range e.listeners {
+		if len(data.Stdout) > 0 {
+			_, _ = l.Stdout.Write(data.Stdout)
+		}
+		if len(data.Stderr) > 0 {
+			_, _ = l.Stderr.Write(data.Stderr)
+		}
+	}
+}
+
+func (e *Execution) finish(exitCode int, usage *ResourceUsage) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.exitCode = exitCode
+	e.usage = usage
+	e.finishedAt = time.Now()
+
+	for l := range e.listeners {
+		l.close()
+		delete(e.listeners, l)
+	}
+
+	close(e.done)
+}
+
+// attach returns a listener which first yields the buffered
+// output and then the output produced after attaching.
+// If the process already exited, the listener is closed
+// and contains only the buffered output.
+func (e *Execution) attach() *executionListener {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// The history fits in buffers of the same size, and
+	// ring buffers overwrite the oldest data if a listener
+	// falls behind.
+	l := &executionListener{
+		Stdout: rbuffer.NewRingBuffer(executionHistorySize),
+		Stderr: rbuffer.NewRingBuffer(executionHistorySize),
+	}
+
+	_, _ = l.Stdout.Write(e.stdout.Bytes())
+	_, _ = l.Stderr.Write(e.stderr.Bytes())
+
+	if !e.Running() {
+		l.close()
+		return l
+	}
+
+	e.listeners[l] = struct{}{}
+
+	return l
+}
+
+func (e *Execution) detach(l *executionListener) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	l.close()
+	delete(e.listeners, l)
+}
+
+type executionList struct {
+	mu    sync.Mutex
+	items map[string]*Execution
+}
+
+func newExecutionList() *executionList {
+	return &executionList{items: make(map[string]*Execution)}
+}
+
+func (l *executionList) Add(e *Execution) {
+	l.mu.Lock()
+	l.evictUnsafe(time.Now())
+	l.items[e.ID] = e
+	l.mu.Unlock()
+}
+
+// evictUnsafe removes finished executions which are past
+// the retention period or exceed maxFinishedExecutions.
+// The oldest executions are removed first.
+func (l *executionList) evictUnsafe(now time.Time) {
+	var finished []*Execution
+
+	for id, e := range l.items {
+		if e.Running() {
+			continue
+		}
+
+		e.mu.Lock()
+		finishedAt := e.finishedAt
+		e.mu.Unlock()
+
+		if now.Sub(finishedAt) > executionRetention {
+			delete(l.items, id)
+			continue
+		}
+
+		finished = append(finished, e)
+	}
+
+	if len(finished) <= maxFinishedExecutions {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool { return finished[i].ID < finished[j].ID })
+
+	for _, e := range finished[:len(finished)-maxFinishedExecutions] {
+		delete(l.items, e.ID)
+	}
+}
+
+func (l *executionList) Get(id string) (*Execution, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.evictUnsafe(time.Now())
+	e, ok := l.items[id]
+	return e, ok
+}
+
+// List returns executions in the order they were started.
+func (l *executionList) List() []*Execution {
+	l.mu.Lock()
+	l.evictUnsafe(time.Now())
+	result := make([]*Execution, 0, len(l.items))
+	for _, e := range l.items {
+		result = append(result, e)
+	}
+	l.mu.Unlock()
+
+	// IDs are ULIDs, hence, they are lexicographically sortable by time.
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	return result
+}
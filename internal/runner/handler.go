@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/bufbuild/connect-go"
+	"github.com/stateful/runme/internal/executable"
 	v1 "github.com/stateful/runme/internal/gen/proto/go/runme/runner/v1"
 	"github.com/stateful/runme/internal/gen/proto/go/runme/runner/v1/runnerv1connect"
 	"go.uber.org/zap"
@@ -15,8 +16,8 @@ type runnerServiceHandler struct {
 	service *runnerService
 }
 
-func NewRunnerServiceHandler(logger *zap.Logger) (runnerv1connect.RunnerServiceHandler, error) {
-	service, err := newRunnerService(logger)
+func NewRunnerServiceHandler(logger *zap.Logger, languages *executable.Languages) (runnerv1connect.RunnerServiceHandler, error) {
+	service, err := newRunnerService(logger, languages)
 	if err != nil {
 		return nil, err
 	}
@@ -61,3 +62,23 @@ func (h *runnerServiceHandler) DeleteSession(ctx context.Context, req *connect.R
 func (h *runnerServiceHandler) Execute(ctx context.Context, stream *connect.BidiStream[v1.ExecuteRequest, v1.ExecuteResponse]) error {
 	return status.Error(codes.Unimplemented, "Execute is not implemented")
 }
+
+func (h *runnerServiceHandler) AttachExecution(ctx context.Context, req *connect.Request[v1.AttachExecutionRequest], stream *connect.ServerStream[v1.AttachExecutionResponse]) error {
+	return status.Error(codes.Unimplemented, "AttachExecution is not implemented")
+}
+
+func (h *runnerServiceHandler) ListExecutions(ctx context.Context, req *connect.Request[v1.ListExecutionsRequest]) (*connect.Response[v1.ListExecutionsResponse], error) {
+	resp, err := h.service.ListExecutions(ctx, req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(resp), nil
+}
+
+func (h *runnerServiceHandler) StopExecution(ctx context.Context, req *connect.Request[v1.StopExecutionRequest]) (*connect.Response[v1.StopExecutionResponse], error) {
+	resp, err := h.service.StopExecution(ctx, req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(resp), nil
+}
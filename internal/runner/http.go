@@ -0,0 +1,307 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// HTTP is an executable which sends requests written in the style
+// of .http files:
+//
+//	POST https://example.com/api/items HTTP/1.1
+//	Authorization: Bearer $TOKEN
+//	Content-Type: application/json
+//
+//	{"name": "$NAME"}
+//
+// Multiple requests are separated by lines starting with "###".
+// Variables in the form of $VAR and ${VAR} are expanded using
+// the session environment. The status line, headers and body
+// of each response are written to stdout.
+type HTTP struct {
+	*ExecutableConfig
+	Script string
+	// Capture is a name of the session variable
+	// where the body of the last response is stored.
+	Capture string
+	// Client sends requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+
+	inProcess
+}
+
+var _ Executable = (*HTTP)(nil)
+
+type httpRequest struct {
+	Method  string
+	URL     string
+	Headers [][2]string
+	Body    string
+}
+
+// parseHTTPRequests parses script into requests
+// without expanding variables.
+func parseHTTPRequests(script string) ([]httpRequest, error) {
+	var (
+		result []httpRequest
+		block  []string
+	)
+
+	flush := func() error {
+		req, ok, err := parseHTTPRequest(block)
+		if err != nil {
+			return err
+		}
+		if ok {
+			result = append(result, req)
+		}
+		block = nil
+		return nil
+	}
+
+	for _, line := range strings.Split(script, "\n") {
+		if strings.HasPrefix(line, "###") {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		block = append(block, strings.TrimRight(line, "\r"))
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func isHTTPComment(line string) bool {
+	return strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//")
+}
+
+func parseHTTPRequest(lines []string) (req httpRequest, ok bool, _ error) {
+	// Skip empty lines and comments before the request line.
+	i := 0
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line != "" && !isHTTPComment(line) {
+			break
+		}
+	}
+	if i == len(lines) {
+		return req, false, nil
+	}
+
+	fields := strings.Fields(lines[i])
+	switch {
+	case len(fields) == 1:
+		req.Method, req.URL = http.MethodGet, fields[0]
+	case len(fields) == 2 || (len(fields) == 3 && strings.HasPrefix(fields[2], "HTTP/")):
+		req.Method, req.URL = strings.ToUpper(fields[0]), fields[1]
+	default:
+		return req, false, errors.Errorf("invalid request line %q", lines[i])
+	}
+
+	for i++; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			break
+		}
+		if isHTTPComment(line) {
+			continue
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			return req, false, errors.Errorf("invalid header %q", line)
+		}
+		req.Headers = append(req.Headers, [2]string{strings.TrimSpace(name), strings.TrimSpace(value)})
+	}
+
+	if i < len(lines) {
+		req.Body = strings.TrimSpace(strings.Join(lines[i+1:], "\n"))
+	}
+
+	return req, true, nil
+}
+
+// httpMaskedValue replaces values of variables in dry runs.
+const httpMaskedValue = "***"
+
+// expand replaces variables in the request. Unknown variables are left as is.
+// If mask is true, known variables are replaced with httpMaskedValue
+// so that secrets are not revealed.
+func (r httpRequest) expand(envs []string, mask bool) httpRequest {
+	mapping := func(name string) string {
+		value, ok := lookupEnv(envs, name)
+		switch {
+		case !ok:
+			return "$" + name
+		case mask:
+			return httpMaskedValue
+		default:
+			return value
+		}
+	}
+
+	result := httpRequest{
+		Method: r.Method,
+		URL:    os.Expand(r.URL, mapping),
+		Body:   os.Expand(r.Body, mapping),
+	}
+	for _, h := range r.Headers {
+		result.Headers = append(result.Headers, [2]string{h[0], os.Expand(h[1], mapping)})
+	}
+	return result
+}
+
+func (r httpRequest) String() string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "%s %s\n", r.Method, r.URL)
+	for _, h := range r.Headers {
+		_, _ = fmt.Fprintf(&b, "%s: %s\n", h[0], h[1])
+	}
+	if r.Body != "" {
+		_, _ = fmt.Fprintf(&b, "\n%s\n", r.Body)
+	}
+	return b.String()
+}
+
+func (h HTTP) DryRun(ctx context.Context, w io.Writer) {
+	requests, err := parseHTTPRequests(h.Script)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "// %s\n", err)
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "// values of variables are masked with %s\n", httpMaskedValue)
+	if h.Capture != "" {
+		_, _ = fmt.Fprintf(w, "// capture the last response body into %s\n", h.Capture)
+	}
+	_, _ = io.WriteString(w, "\n")
+
+	envs := h.envs()
+	for i, req := range requests {
+		if i > 0 {
+			_, _ = fmt.Fprintf(w, "\n###\n\n")
+		}
+		_, _ = io.WriteString(w, req.expand(envs, true).String())
+	}
+}
+
+func (h *HTTP) Run(ctx context.Context) error {
+	return h.inProcess.run(func() error { return h.execute(ctx) })
+}
+
+func (h *HTTP) execute(ctx context.Context) error {
+	// Requests are sent by the runner itself,
+	// so there is no process to restrict.
+	if h.Sandbox != nil {
+		return errors.New("sandbox is not supported for http cells")
+	}
+
+	requests, err := parseHTTPRequests(h.Script)
+	if err != nil {
+		return err
+	}
+	if len(requests) == 0 {
+		return errors.New("no request found")
+	}
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var body []byte
+
+	for i, req := range requests {
+		if i > 0 {
+			_, _ = io.WriteString(h.Stdout, "\n")
+		}
+
+		body, err = h.send(ctx, client, req.expand(h.envs(), false))
+		if err != nil {
+			return err
+		}
+	}
+
+	if h.Capture != "" && h.Session != nil {
+		if err := h.Session.SetEnv(h.Capture, string(body)); err != nil {
+			return errors.Wrapf(err, "failed to capture response into %s", h.Capture)
+		}
+	}
+
+	return nil
+}
+
+func (h *HTTP) send(ctx context.Context, client *http.Client, r httpRequest) ([]byte, error) {
+	var reqBody io.Reader
+	if r.Body != "" {
+		reqBody = strings.NewReader(r.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.URL, reqBody)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, header := range r.Headers {
+		if strings.EqualFold(header[0], "Host") {
+			req.Host = header[1]
+			continue
+		}
+		req.Header.Add(header[0], header[1])
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response body")
+	}
+
+	w := bufio.NewWriter(h.Stdout)
+
+	_, _ = fmt.Fprintf(w, "%s %s\n", resp.Proto, resp.Status)
+
+	names := make([]string, 0, len(resp.Header))
+	for name := range resp.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range resp.Header[name] {
+			_, _ = fmt.Fprintf(w, "%s: %s\n", name, value)
+		}
+	}
+
+	if len(body) > 0 {
+		_, _ = fmt.Fprintf(w, "\n%s", body)
+		if body[len(body)-1] != '\n' {
+			_, _ = w.WriteString("\n")
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Similarly to "curl --fail", error responses fail the cell.
+	if resp.StatusCode >= http.StatusBadRequest {
+		return body, errors.Errorf("%s %s failed with status %q", r.Method, r.URL, resp.Status)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_parseHTTPRequests(t *testing.T) {
+	requests, err := parseHTTPRequests(`# list items
+https://example.com/items
+
+###
+
+// create an item
+post https://example.com/items HTTP/1.1
+Content-Type: application/json
+# Authorization: none
+
+{"name": "$NAME"}
+`)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]httpRequest{
+			{Method: "GET", URL: "https://example.com/items"},
+			{
+				Method:  "POST",
+				URL:     "https://example.com/items",
+				Headers: [][2]string{{"Content-Type", "application/json"}},
+				Body:    `{"name": "$NAME"}`,
+			},
+		},
+		requests,
+	)
+
+	_, err = parseHTTPRequests("GET https://example.com\nInvalid header")
+	assert.ErrorContains(t, err, `invalid header "Invalid header"`)
+}
+
+func TestHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.Path + " " + r.Header.Get("X-Token") + " " + string(body)))
+	}))
+	defer server.Close()
+
+	session, err := NewSession([]string{"BASE_URL=" + server.URL, "TOKEN=secret"}, zap.NewNop())
+	require.NoError(t, err)
+
+	newHTTP := func(script string) (*HTTP, *bytes.Buffer) {
+		stdout := new(bytes.Buffer)
+		return &HTTP{
+			ExecutableConfig: &ExecutableConfig{
+				Stdout:  stdout,
+				Stderr:  new(bytes.Buffer),
+				Session: session,
+				Logger:  zap.NewNop(),
+			},
+			Script:  script,
+			Capture: "RESPONSE",
+		}, stdout
+	}
+
+	h, stdout := newHTTP("PUT ${BASE_URL}/items\nX-Token: $TOKEN\n\nbody $UNKNOWN")
+	require.NoError(t, h.Run(context.Background()))
+	assert.Equal(t, 0, h.ExitCode())
+	assert.Equal(
+		t,
+		"HTTP/1.1 200 OK\nContent-Length: 31\nContent-Type: text/plain\nDate: Mon, 02 Jan 2006 15:04:05 GMT\n\nPUT /items secret body $UNKNOWN\n",
+		stdout.String(),
+	)
+
+	captured, ok := lookupEnv(session.Envs(), "RESPONSE")
+	assert.True(t, ok)
+	assert.Equal(t, "PUT /items secret body $UNKNOWN", captured)
+
+	h, _ = newHTTP("GET $BASE_URL/missing")
+	err = h.Run(context.Background())
+	assert.ErrorContains(t, err, `failed with status "404 Not Found"`)
+	assert.Equal(t, 1, h.ExitCode())
+
+	t.Run("DryRun", func(t *testing.T) {
+		h, _ := newHTTP("PUT ${BASE_URL}/items\nX-Token: $TOKEN\n\nbody $UNKNOWN")
+		output := new(bytes.Buffer)
+		h.DryRun(context.Background(), output)
+		assert.Equal(
+			t,
+			"// values of variables are masked with ***\n"+
+				"// capture the last response body into RESPONSE\n"+
+				"\n"+
+				"PUT ***/items\n"+
+				"X-Token: ***\n"+
+				"\n"+
+				"body $UNKNOWN\n",
+			output.String(),
+		)
+	})
+}
@@ -0,0 +1,63 @@
+package runner
+
+import "time"
+
+// inProcess tracks the state of executables which are
+// run by the runner itself instead of starting a process.
+type inProcess struct {
+	exitCode int
+	started  time.Time
+	wallTime time.Duration
+}
+
+// run calls fn and records its result and duration.
+func (p *inProcess) run(fn func() error) error {
+	p.exitCode = -1
+	p.started = time.Now()
+	defer func() { p.wallTime = time.Since(p.started) }()
+
+	if err := fn(); err != nil {
+		p.exitCode = 1
+		return err
+	}
+
+	p.exitCode = 0
+	return nil
+}
+
+func (p inProcess) ExitCode() int {
+	if p.started.IsZero() {
+		return -1
+	}
+	return p.exitCode
+}
+
+func (p inProcess) ResourceUsage() *ResourceUsage {
+	if p.started.IsZero() || p.exitCode == -1 {
+		return nil
+	}
+	return &ResourceUsage{WallTime: p.wallTime}
+}
+
+// envs returns environment variables visible to
+// an executable in the order of precedence.
+func (c *ExecutableConfig) envs() []string {
+	var envs []string
+	envs = append(envs, c.PreEnv...)
+	if c.Session != nil {
+		envs = append(envs, c.Session.Envs()...)
+	}
+	return append(envs, c.PostEnv...)
+}
+
+// lookupEnv returns the last value of the environment variable
+// called name from envs.
+func lookupEnv(envs []string, name string) (string, bool) {
+	value, found := "", false
+	for _, env := range envs {
+		if k, v := splitEnv(env); k == name {
+			value, found = v, true
+		}
+	}
+	return value, found
+}
@@ -0,0 +1,169 @@
+package runner
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/stateful/runme/internal/document"
+)
+
+// ResourceLimits restricts resources available to a spawned program.
+// Zero values mean no limit.
+//
+// On Linux, limits are applied with setrlimit(2) before the program is
+// executed and are inherited by its children. If cgroups v2 with the memory
+// and pids controllers are delegated to the current cgroup, the memory and
+// process limits apply to the whole process tree instead.
+//
+// Note that without cgroups, the process limit is enforced with RLIMIT_NPROC
+// which counts all processes of the user, not only the ones started by
+// the program.
+type ResourceLimits struct {
+	// CPUTime is the CPU time limit. It's rounded up to full seconds.
+	CPUTime time.Duration
+	// Memory is the memory limit in bytes.
+	Memory uint64
+	// OpenFiles is the maximum number of open file descriptors.
+	OpenFiles uint64
+	// Processes is the maximum number of processes.
+	Processes uint64
+}
+
+func (l *ResourceLimits) IsZero() bool {
+	return l == nil || *l == ResourceLimits{}
+}
+
+// Rlimits are limits set with setrlimit(2) by "runme limits exec"
+// before it replaces itself with the program. Zero values mean no limit.
+type Rlimits struct {
+	CPU    uint64
+	NoFile uint64
+	AS     uint64
+	NProc  uint64
+}
+
+func (r Rlimits) IsZero() bool {
+	return r == Rlimits{}
+}
+
+// Args returns flags of "runme limits exec" which set the limits.
+func (r Rlimits) Args() []string {
+	var args []string
+	for _, item := range []struct {
+		flag  string
+		value uint64
+	}{
+		{"--cpu", r.CPU},
+		{"--nofile", r.NoFile},
+		{"--as", r.AS},
+		{"--nproc", r.NProc},
+	} {
+		if item.value > 0 {
+			args = append(args, item.flag, strconv.FormatUint(item.value, 10))
+		}
+	}
+	return args
+}
+
+// CPUTimeSeconds returns CPUTime rounded up to full seconds.
+func (l *ResourceLimits) CPUTimeSeconds() uint64 {
+	return uint64((l.CPUTime + time.Second - 1) / time.Second)
+}
+
+// CellResourceLimits returns resource limits set on the cell
+// through the "cpuLimit", "memoryLimit", "openFilesLimit",
+// and "processLimit" attributes. It returns nil if none is set.
+func CellResourceLimits(cell *document.CodeBlock) (*ResourceLimits, error) {
+	attrs := cell.Attributes()
+
+	var (
+		limits ResourceLimits
+		err    error
+	)
+
+	if val := attrs["cpuLimit"]; val != "" {
+		limits.CPUTime, err = parseCPUTime(val)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid cpuLimit")
+		}
+	}
+
+	if val := attrs["memoryLimit"]; val != "" {
+		limits.Memory, err = parseByteSize(val)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid memoryLimit")
+		}
+	}
+
+	if val := attrs["openFilesLimit"]; val != "" {
+		limits.OpenFiles, err = strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid openFilesLimit")
+		}
+	}
+
+	if val := attrs["processLimit"]; val != "" {
+		limits.Processes, err = strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid processLimit")
+		}
+	}
+
+	if limits.IsZero() {
+		return nil, nil
+	}
+
+	return &limits, nil
+}
+
+// parseCPUTime accepts a duration like "90s" or "2m",
+// or a plain number of seconds.
+func parseCPUTime(val string) (time.Duration, error) {
+	if sec, err := strconv.ParseUint(val, 10, 64); err == nil {
+		return time.Duration(sec) * time.Second, nil
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, errors.New("negative duration")
+	}
+	return d, nil
+}
+
+// parseByteSize accepts a number of bytes with an optional
+// binary unit suffix, for example: "1048576", "512k", "512MiB", "2G".
+func parseByteSize(val string) (uint64, error) {
+	s := strings.ToLower(strings.TrimSpace(val))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "b"), "i")
+
+	multiplier := uint64(1)
+
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'k':
+			multiplier = 1 << 10
+		case 'm':
+			multiplier = 1 << 20
+		case 'g':
+			multiplier = 1 << 30
+		case 't':
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			s = s[:n-1]
+		}
+	}
+
+	n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, errors.Errorf("invalid size %q", val)
+	}
+
+	return n * multiplier, nil
+}
@@ -0,0 +1,243 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"golang.org/x/exp/slices"
+	"golang.org/x/sys/unix"
+
+	ulid "github.com/stateful/runme/internal/ulid"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// resourceLimiter applies ResourceLimits to a command.
+// It's prepared before the command starts. Limits are applied
+// before the program is executed so that no child escapes them.
+type resourceLimiter struct {
+	limits *ResourceLimits
+
+	// cgroupDir is a cgroup v2 created for the command.
+	// It's empty if cgroups v2 are not available.
+	cgroupDir string
+	cgroupFd  *os.File
+}
+
+func newResourceLimiter(cmd *exec.Cmd, limits *ResourceLimits) (*resourceLimiter, error) {
+	l := &resourceLimiter{limits: limits}
+
+	if limits.Memory > 0 || limits.Processes > 0 {
+		if err := l.createCgroup(cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	rlimits := Rlimits{
+		CPU:    limits.CPUTimeSeconds(),
+		NoFile: limits.OpenFiles,
+	}
+	if l.cgroupDir == "" {
+		rlimits.AS = limits.Memory
+		rlimits.NProc = limits.Processes
+	}
+
+	if !rlimits.IsZero() {
+		// The program is executed by "runme limits exec" which sets
+		// the limits on itself and replaces itself with the program.
+		args := append([]string{}, limitsExecCmd...)
+		args = append(args, rlimits.Args()...)
+		args = append(args, "--", cmd.Path)
+		args = append(args, cmd.Args[1:]...)
+
+		cmd.Path = args[0]
+		cmd.Args = args
+	}
+
+	return l, nil
+}
+
+func (l *resourceLimiter) createCgroup(cmd *exec.Cmd) error {
+	dir, ok := delegatedCgroupDir(l.limits)
+	if !ok {
+		return nil
+	}
+
+	l.cgroupDir = filepath.Join(dir, "runme-"+ulid.GenerateID())
+	if err := os.Mkdir(l.cgroupDir, 0o755); err != nil {
+		// Not having permissions is fine; setrlimit will be used.
+		l.cgroupDir = ""
+		return nil
+	}
+
+	if l.limits.Memory > 0 {
+		if err := writeCgroupFile(l.cgroupDir, "memory.max", l.limits.Memory); err != nil {
+			return multierr.Append(err, l.Cleanup())
+		}
+	}
+
+	if l.limits.Processes > 0 {
+		if err := writeCgroupFile(l.cgroupDir, "pids.max", l.limits.Processes); err != nil {
+			return multierr.Append(err, l.Cleanup())
+		}
+	}
+
+	f, err := os.Open(l.cgroupDir)
+	if err != nil {
+		return multierr.Append(errors.WithStack(err), l.Cleanup())
+	}
+	l.cgroupFd = f
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// The process is started directly in the cgroup,
+	// so no child can escape the limits.
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(f.Fd())
+
+	return nil
+}
+
+// Started releases resources needed only to start the command.
+func (l *resourceLimiter) Started() {
+	if l.cgroupFd != nil {
+		_ = l.cgroupFd.Close()
+		l.cgroupFd = nil
+	}
+}
+
+// Cleanup removes the cgroup. If processes started by the command
+// are still running, the cgroup is removed after they exit.
+func (l *resourceLimiter) Cleanup() error {
+	l.Started()
+
+	if l.cgroupDir == "" {
+		return nil
+	}
+
+	dir := l.cgroupDir
+	l.cgroupDir = ""
+
+	err := unix.Rmdir(dir)
+	if errors.Is(err, unix.EBUSY) {
+		go removeCgroupWhenEmpty(dir)
+		return nil
+	}
+	return errors.WithStack(err)
+}
+
+// removeCgroupWhenEmpty waits for all processes
+// in the cgroup to exit and removes it.
+func removeCgroupWhenEmpty(dir string) {
+	const maxInterval = 30 * time.Second
+
+	interval := 100 * time.Millisecond
+
+	for {
+		time.Sleep(interval)
+
+		data, err := os.ReadFile(filepath.Join(dir, "cgroup.events"))
+		if err != nil {
+			// The cgroup doesn't exist anymore.
+			return
+		}
+
+		if bytes.Contains(data, []byte("populated 0")) {
+			if err := unix.Rmdir(dir); err == nil || !errors.Is(err, unix.EBUSY) {
+				return
+			}
+		}
+
+		interval = min(interval*2, maxInterval)
+	}
+}
+
+// ExecWithRlimits sets rlimits on the current process and replaces
+// it with the program described by argv. It returns only if an error occurs.
+func ExecWithRlimits(rlimits Rlimits, argv []string) error {
+	if len(argv) == 0 {
+		return errors.New("program not specified")
+	}
+
+	program, err := exec.LookPath(argv[0])
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// syscall.Setrlimit is used as, unlike unix.Setrlimit, it prevents
+	// the runtime from restoring the original RLIMIT_NOFILE on exec.
+	for _, item := range []struct {
+		resource int
+		value    uint64
+	}{
+		{syscall.RLIMIT_CPU, rlimits.CPU},
+		{syscall.RLIMIT_NOFILE, rlimits.NoFile},
+		{syscall.RLIMIT_AS, rlimits.AS},
+		{unix.RLIMIT_NPROC, rlimits.NProc},
+	} {
+		if item.value == 0 {
+			continue
+		}
+		rlimit := syscall.Rlimit{Cur: item.value, Max: item.value}
+		if err := syscall.Setrlimit(item.resource, &rlimit); err != nil {
+			return errors.Wrapf(err, "failed to set resource limit %d", item.resource)
+		}
+	}
+
+	return errors.WithStack(syscall.Exec(program, argv, os.Environ()))
+}
+
+// delegatedCgroupDir returns the cgroup v2 directory of the current
+// process if it can have child cgroups with the required controllers.
+func delegatedCgroupDir(limits *ResourceLimits) (string, bool) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", false
+	}
+
+	var path string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// The unified hierarchy is described by a line "0::<path>".
+		if p, ok := strings.CutPrefix(scanner.Text(), "0::"); ok {
+			path = p
+			break
+		}
+	}
+	if path == "" {
+		return "", false
+	}
+
+	dir := filepath.Join(cgroupRoot, path)
+
+	controllers, err := os.ReadFile(filepath.Join(dir, "cgroup.subtree_control"))
+	if err != nil {
+		return "", false
+	}
+
+	enabled := strings.Fields(string(controllers))
+	if limits.Memory > 0 && !slices.Contains(enabled, "memory") {
+		return "", false
+	}
+	if limits.Processes > 0 && !slices.Contains(enabled, "pids") {
+		return "", false
+	}
+
+	return dir, true
+}
+
+func writeCgroupFile(dir, name string, value uint64) error {
+	err := os.WriteFile(filepath.Join(dir, name), []byte(strconv.FormatUint(value, 10)), 0o644)
+	return errors.Wrapf(err, "failed to write %s", name)
+}
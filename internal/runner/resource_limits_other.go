@@ -0,0 +1,25 @@
+//go:build !linux
+
+package runner
+
+import (
+	"os/exec"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+type resourceLimiter struct{}
+
+func newResourceLimiter(cmd *exec.Cmd, limits *ResourceLimits) (*resourceLimiter, error) {
+	return nil, errors.Errorf("resource limits are not supported on %s", runtime.GOOS)
+}
+
+func (l *resourceLimiter) Started() {}
+
+func (l *resourceLimiter) Cleanup() error { return nil }
+
+// ExecWithRlimits is supported only on Linux.
+func ExecWithRlimits(rlimits Rlimits, argv []string) error {
+	return errors.Errorf("resource limits are not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,34 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseByteSize(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected uint64
+	}{
+		{"1024", 1024},
+		{"100b", 100},
+		{"512k", 512 << 10},
+		{"512KiB", 512 << 10},
+		{"64MB", 64 << 20},
+		{"2G", 2 << 30},
+		{"1t", 1 << 40},
+	}
+
+	for _, tc := range testCases {
+		size, err := parseByteSize(tc.value)
+		require.NoError(t, err, tc.value)
+		assert.Equal(t, tc.expected, size, tc.value)
+	}
+
+	for _, value := range []string{"", "MiB", "-1", "1.5G", "12X"} {
+		_, err := parseByteSize(value)
+		assert.Error(t, err, value)
+	}
+}
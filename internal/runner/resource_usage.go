@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// ResourceUsage describes resources consumed by a finished process
+// including its children which were waited for.
+type ResourceUsage struct {
+	WallTime   time.Duration
+	UserTime   time.Duration
+	SystemTime time.Duration
+	// MaxRSS is the maximum resident set size in bytes.
+	// It is zero if unknown.
+	MaxRSS int64
+}
+
+func newResourceUsage(state *os.ProcessState, wallTime time.Duration) *ResourceUsage {
+	if state == nil {
+		return nil
+	}
+
+	return &ResourceUsage{
+		WallTime:   wallTime,
+		UserTime:   state.UserTime(),
+		SystemTime: state.SystemTime(),
+		MaxRSS:     maxRSSFromProcessState(state),
+	}
+}
+
+func (u ResourceUsage) String() string {
+	result := fmt.Sprintf(
+		"wall %s, user %s, sys %s",
+		u.WallTime.Round(time.Millisecond),
+		u.UserTime.Round(time.Millisecond),
+		u.SystemTime.Round(time.Millisecond),
+	)
+
+	if u.MaxRSS > 0 {
+		result += fmt.Sprintf(", max RSS %.1f MiB", float64(u.MaxRSS)/(1<<20))
+	}
+
+	return result
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/creack/pty"
 	"github.com/pkg/errors"
@@ -16,9 +17,11 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
+	"github.com/stateful/runme/internal/executable"
 	runnerv1 "github.com/stateful/runme/internal/gen/proto/go/runme/runner/v1"
 	"github.com/stateful/runme/internal/project"
 	"github.com/stateful/runme/internal/rbuffer"
+	"github.com/stateful/runme/internal/sandbox"
 	ulid "github.com/stateful/runme/internal/ulid"
 )
 
@@ -43,25 +46,44 @@ type runnerService struct {
 
 	sessions *SessionList
 
+	// languages are custom languages available in all sessions.
+	// Sessions with a project extend them with the project's ones.
+	languages *executable.Languages
+
 	logger *zap.Logger
 }
 
-func NewRunnerService(logger *zap.Logger) (runnerv1.RunnerServiceServer, error) {
-	return newRunnerService(logger)
+func NewRunnerService(logger *zap.Logger, languages *executable.Languages) (runnerv1.RunnerServiceServer, error) {
+	return newRunnerService(logger, languages)
 }
 
-func newRunnerService(logger *zap.Logger) (*runnerService, error) {
+func newRunnerService(logger *zap.Logger, languages *executable.Languages) (*runnerService, error) {
 	sessions, err := NewSessionList()
 	if err != nil {
 		return nil, err
 	}
 
 	return &runnerService{
-		logger:   logger,
-		sessions: sessions,
+		logger:    logger,
+		sessions:  sessions,
+		languages: languages,
 	}, nil
 }
 
+// projectLanguages returns languages available to programs
+// executed in proj. Proj can be nil.
+func (r *runnerService) projectLanguages(proj *project.Project) (*executable.Languages, error) {
+	if proj == nil {
+		return r.languages, nil
+	}
+
+	languages := r.languages.Clone()
+	if err := languages.Load(executable.ProjectLanguagesPath(proj.Root())); err != nil {
+		return nil, err
+	}
+	return languages, nil
+}
+
 func toRunnerv1Session(sess *Session) *runnerv1.Session {
 	return &runnerv1.Session{
 		Id:       sess.ID,
@@ -95,6 +117,11 @@ func (r *runnerService) CreateSession(ctx context.Context, req *runnerv1.CreateS
 		return nil, err
 	}
 
+	sess.languages, err = r.projectLanguages(proj)
+	if err != nil {
+		return nil, err
+	}
+
 	r.sessions.AddSession(sess)
 
 	return &runnerv1.CreateSessionResponse{
@@ -151,6 +178,176 @@ func (r *runnerService) findSession(id string) *Session {
 	return nil
 }
 
+// sessionsByID returns a session with the given ID
+// or all sessions if the ID is empty.
+func (r *runnerService) sessionsByID(id string) ([]*Session, error) {
+	if id == "" {
+		return r.sessions.ListSessions()
+	}
+
+	sess := r.findSession(id)
+	if sess == nil {
+		return nil, status.Error(codes.NotFound, "session not found")
+	}
+
+	return []*Session{sess}, nil
+}
+
+func (r *runnerService) findExecution(sessionID, id string) (*Execution, error) {
+	sessions, err := r.sessionsByID(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sess := range sessions {
+		if execution, ok := sess.GetExecution(id); ok {
+			return execution, nil
+		}
+	}
+
+	return nil, status.Error(codes.NotFound, "execution not found")
+}
+
+func toRunnerv1Execution(execution *Execution) *runnerv1.Execution {
+	result := &runnerv1.Execution{
+		Id:        execution.ID,
+		SessionId: execution.SessionID,
+		Pid:       int64(execution.PID),
+		Running:   execution.Running(),
+	}
+
+	if exitCode := execution.ExitCode(); !result.Running && exitCode > -1 {
+		result.ExitCode = wrapperspb.UInt32(uint32(exitCode))
+	}
+
+	return result
+}
+
+func (r *runnerService) ListExecutions(_ context.Context, req *runnerv1.ListExecutionsRequest) (*runnerv1.ListExecutionsResponse, error) {
+	r.logger.Info("running ListExecutions in runnerService")
+
+	sessions, err := r.sessionsByID(req.SessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	var executions []*runnerv1.Execution
+	for _, sess := range sessions {
+		for _, execution := range sess.Executions() {
+			executions = append(executions, toRunnerv1Execution(execution))
+		}
+	}
+
+	return &runnerv1.ListExecutionsResponse{Executions: executions}, nil
+}
+
+func (r *runnerService) StopExecution(_ context.Context, req *runnerv1.StopExecutionRequest) (*runnerv1.StopExecutionResponse, error) {
+	r.logger.Info("running StopExecution in runnerService")
+
+	execution, err := r.findExecution(req.SessionId, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !execution.Running() {
+		return nil, status.Error(codes.FailedPrecondition, "execution already finished")
+	}
+
+	sig := os.Interrupt
+	if req.Stop == runnerv1.ExecuteStop_EXECUTE_STOP_KILL {
+		sig = os.Kill
+	}
+
+	if err := execution.Stop(sig); err != nil {
+		return nil, err
+	}
+
+	return &runnerv1.StopExecutionResponse{}, nil
+}
+
+func (r *runnerService) AttachExecution(req *runnerv1.AttachExecutionRequest, srv runnerv1.RunnerService_AttachExecutionServer) error {
+	logger := r.logger.With(zap.String("_id", ulid.GenerateID()), zap.String("executionID", req.Id))
+
+	logger.Info("running AttachExecution in runnerService")
+
+	execution, err := r.findExecution(req.SessionId, req.Id)
+	if err != nil {
+		return err
+	}
+
+	listener := execution.attach()
+	defer execution.detach(listener)
+
+	// Detaching closes the listener's buffers which unblocks
+	// readLoop() when the client goes away.
+	stop := context.AfterFunc(srv.Context(), func() { execution.detach(listener) })
+	defer stop()
+
+	if err := srv.Send(&runnerv1.AttachExecutionResponse{
+		Pid: &runnerv1.ProcessPID{
+			Pid: int64(execution.PID),
+		},
+	}); err != nil {
+		return err
+	}
+
+	g := new(errgroup.Group)
+	datac := make(chan output)
+
+	g.Go(func() error {
+		err := readLoop(listener.Stdout, listener.Stderr, datac)
+		close(datac)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		for data := range datac {
+			if err != nil {
+				// Drain the channel so that readLoop() can exit.
+				continue
+			}
+
+			logger.Debug("sending data", zap.Int("lenStdout", len(data.Stdout)), zap.Int("lenStderr", len(data.Stderr)))
+			err = srv.Send(&runnerv1.AttachExecutionResponse{
+				StdoutData: data.Stdout,
+				StderrData: data.Stderr,
+			})
+			if err != nil {
+				logger.Info("failed to send data; detaching", zap.Error(err))
+				execution.detach(listener)
+			}
+		}
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	select {
+	case <-execution.Done():
+	case <-srv.Context().Done():
+		logger.Info("stream canceled before the execution finished")
+		return nil
+	}
+
+	var finalExitCode *wrapperspb.UInt32Value
+	if exitCode := execution.ExitCode(); exitCode > -1 {
+		finalExitCode = wrapperspb.UInt32(uint32(exitCode))
+	}
+
+	logger.Info("sending the final response", zap.Int("exitCode", execution.ExitCode()))
+
+	return srv.Send(&runnerv1.AttachExecutionResponse{
+		ExitCode:      finalExitCode,
+		ResourceUsage: toRunnerv1ResourceUsage(execution.ResourceUsage()),
+	})
+}
+
 func ConvertRunnerProject(runnerProj *runnerv1.Project) (*project.Project, error) {
 	if runnerProj == nil {
 		return nil, nil
@@ -188,13 +385,21 @@ func (r *runnerService) Execute(srv runnerv1.RunnerService_ExecuteServer) error
 	logger.Debug("received initial request", zap.Any("req", req))
 
 	createSession := func(envs []string) (*Session, error) {
-		return NewSession(envs, r.logger)
+		sess, err := NewSession(envs, r.logger)
+		if err != nil {
+			return nil, err
+		}
+		sess.languages = r.languages
+		return sess, nil
 	}
 
 	var stdoutMem []byte
 	storeStdout := req.StoreLastOutput
 
-	var sess *Session
+	var (
+		sess      *Session
+		adhocSess bool
+	)
 	switch req.SessionStrategy {
 	case runnerv1.SessionStrategy_SESSION_STRATEGY_UNSPECIFIED:
 		if req.SessionId != "" {
@@ -207,6 +412,7 @@ func (r *runnerService) Execute(srv runnerv1.RunnerService_ExecuteServer) error
 			if err != nil {
 				return err
 			}
+			adhocSess = true
 		}
 
 		if len(req.Envs) > 0 {
@@ -242,6 +448,10 @@ func (r *runnerService) Execute(srv runnerv1.RunnerService_ExecuteServer) error
 		Winsize:       runnerWinsizeToPty(req.Winsize),
 		LanguageID:    req.LanguageId,
 		FileExtension: req.FileExtension,
+
+		ResourceLimits: convertRunnerv1ResourceLimits(req.ResourceLimits),
+		Sandbox:        convertRunnerv1Sandbox(req.Sandbox, req.Project),
+		Languages:      sess.Languages(),
 	}
 
 	switch req.CommandMode {
@@ -265,6 +475,11 @@ func (r *runnerService) Execute(srv runnerv1.RunnerService_ExecuteServer) error
 		}
 
 		cfg.PreEnv = append(cfg.PreEnv, projEnvs...)
+
+		cfg.Languages, err = r.projectLanguages(proj)
+		if err != nil {
+			return err
+		}
 	}
 
 	logger.Debug("command config", zap.Any("cfg", cfg))
@@ -317,11 +532,31 @@ func (r *runnerService) Execute(srv runnerv1.RunnerService_ExecuteServer) error
 		return err
 	}
 
-	if err := srv.Send(&runnerv1.ExecuteResponse{
+	var execution *Execution
+
+	if req.Background {
+		execution = newExecution(sess.ID, cmd)
+		sess.AddExecution(execution)
+
+		// Background executions can be attached to after the stream
+		// is closed, hence, they need to be reachable through a session.
+		if adhocSess {
+			r.sessions.AddSession(sess)
+		}
+
+		logger.Info("registered background execution", zap.String("executionID", execution.ID))
+	}
+
+	initialResp := &runnerv1.ExecuteResponse{
 		Pid: &runnerv1.ProcessPID{
 			Pid: int64(cmd.cmd.Process.Pid),
 		},
-	}); err != nil {
+	}
+	if execution != nil {
+		initialResp.ExecutionId = execution.ID
+	}
+
+	if err := srv.Send(initialResp); err != nil {
 		return err
 	}
 
@@ -419,14 +654,28 @@ func (r *runnerService) Execute(srv runnerv1.RunnerService_ExecuteServer) error
 	})
 
 	g.Go(func() error {
+		detached := false
+
 		for data := range datac {
-			logger.Debug("sending data", zap.Int("lenStdout", len(data.Stdout)), zap.Int("lenStderr", len(data.Stderr)))
-			err := srv.Send(&runnerv1.ExecuteResponse{
-				StdoutData: data.Stdout,
-				StderrData: data.Stderr,
-			})
-			if err != nil {
-				return err
+			if execution != nil {
+				execution.write(data)
+			}
+
+			if !detached {
+				logger.Debug("sending data", zap.Int("lenStdout", len(data.Stdout)), zap.Int("lenStderr", len(data.Stderr)))
+				err := srv.Send(&runnerv1.ExecuteResponse{
+					StdoutData: data.Stdout,
+					StderrData: data.Stderr,
+				})
+				if err != nil {
+					if execution == nil {
+						return err
+					}
+					// Keep consuming the output of the background execution
+					// so that it can be replayed to attaching clients.
+					logger.Info("failed to send data; background execution continues detached", zap.Error(err))
+					detached = true
+				}
 			}
 
 			if storeStdout && len(stdoutMem) < maxEnvSize {
@@ -444,6 +693,21 @@ func (r *runnerService) Execute(srv runnerv1.RunnerService_ExecuteServer) error
 
 	logger.Info("command finished", zap.Int("exitCode", exitCode))
 
+	usage := cmd.ResourceUsage()
+
+	if execution != nil {
+		defer execution.finish(exitCode, usage)
+
+		// Ad-hoc sessions exist only for the sake of the background
+		// execution. Remove them once the execution can no longer be
+		// attached to.
+		if adhocSess {
+			time.AfterFunc(executionRetention, func() {
+				r.sessions.DeleteSession(sess.ID)
+			})
+		}
+	}
+
 	// Close the stdinWriter so that the loops in the `cmd` will finish.
 	// The problem occurs only with TTY.
 	_ = stdinWriter.Close()
@@ -482,7 +746,8 @@ func (r *runnerService) Execute(srv runnerv1.RunnerService_ExecuteServer) error
 	}
 
 	if err := srv.Send(&runnerv1.ExecuteResponse{
-		ExitCode: finalExitCode,
+		ExitCode:      finalExitCode,
+		ResourceUsage: toRunnerv1ResourceUsage(usage),
 	}); err != nil {
 		logger.Info("failed to send exit code", zap.Error(err))
 		if werr == nil {
@@ -576,3 +841,45 @@ func runnerWinsizeToPty(winsize *runnerv1.Winsize) *pty.Winsize {
 		Y:    uint16(winsize.Y),
 	}
 }
+
+func toRunnerv1ResourceUsage(usage *ResourceUsage) *runnerv1.ResourceUsage {
+	if usage == nil {
+		return nil
+	}
+
+	return &runnerv1.ResourceUsage{
+		WallTimeMs:   usage.WallTime.Milliseconds(),
+		UserTimeMs:   usage.UserTime.Milliseconds(),
+		SystemTimeMs: usage.SystemTime.Milliseconds(),
+		MaxRssBytes:  usage.MaxRSS,
+	}
+}
+
+func convertRunnerv1ResourceLimits(limits *runnerv1.ResourceLimits) *ResourceLimits {
+	if limits == nil {
+		return nil
+	}
+
+	return &ResourceLimits{
+		CPUTime:   time.Duration(limits.CpuTimeSec) * time.Second,
+		Memory:    limits.MemoryBytes,
+		OpenFiles: limits.OpenFiles,
+		Processes: limits.Processes,
+	}
+}
+
+func convertRunnerv1Sandbox(cfg *runnerv1.Sandbox, proj *runnerv1.Project) *sandbox.Config {
+	if cfg == nil {
+		return nil
+	}
+
+	writablePaths := cfg.WritablePaths
+	if root := proj.GetRoot(); root != "" {
+		writablePaths = append([]string{root}, writablePaths...)
+	}
+
+	return &sandbox.Config{
+		WritablePaths:  writablePaths,
+		DisableNetwork: cfg.DisableNetwork,
+	}
+}
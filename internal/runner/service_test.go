@@ -9,11 +9,13 @@ import (
 	"net"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/stateful/runme/internal/executable"
 	runnerv1 "github.com/stateful/runme/internal/gen/proto/go/runme/runner/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -41,7 +43,7 @@ func testStartRunnerServiceServer(t *testing.T) (
 	require.NoError(t, err)
 	lis := bufconn.Listen(1024 << 10)
 	server := grpc.NewServer()
-	runnerService, err := newRunnerService(logger)
+	runnerService, err := newRunnerService(logger, executable.NewLanguages())
 	require.NoError(t, err)
 	runnerv1.RegisterRunnerServiceServer(server, runnerService)
 	go server.Serve(lis)
@@ -95,6 +97,31 @@ func getExecuteResult(
 	resultc <- result
 }
 
+func getAttachExecutionResult(
+	stream runnerv1.RunnerService_AttachExecutionClient,
+	resultc chan<- executeResult,
+) {
+	result := executeResult{ExitCode: -1}
+
+	for {
+		r, rerr := stream.Recv()
+		if rerr != nil {
+			if rerr == io.EOF {
+				rerr = nil
+			}
+			result.Err = rerr
+			break
+		}
+		result.Stdout = append(result.Stdout, r.StdoutData...)
+		result.Stderr = append(result.Stderr, r.StderrData...)
+		if r.ExitCode != nil {
+			result.ExitCode = int(r.ExitCode.Value)
+		}
+	}
+
+	resultc <- result
+}
+
 func Test_runnerService(t *testing.T) {
 	t.Parallel()
 
@@ -157,6 +184,67 @@ func Test_runnerService(t *testing.T) {
 		assert.EqualValues(t, 0, result.ExitCode)
 	})
 
+	t.Run("ExecuteResourceUsage", func(t *testing.T) {
+		t.Parallel()
+
+		stream, err := client.Execute(context.Background())
+		require.NoError(t, err)
+
+		err = stream.Send(&runnerv1.ExecuteRequest{
+			ProgramName: "bash",
+			CommandMode: runnerv1.CommandMode_COMMAND_MODE_INLINE_SHELL,
+			Commands:    []string{"sleep 0.5"},
+		})
+		require.NoError(t, err)
+
+		var final *runnerv1.ExecuteResponse
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			if resp.ExitCode != nil {
+				final = resp
+			}
+		}
+
+		require.NotNil(t, final)
+		require.NotNil(t, final.ResourceUsage)
+		assert.GreaterOrEqual(t, final.ResourceUsage.WallTimeMs, int64(500))
+		assert.Greater(t, final.ResourceUsage.MaxRssBytes, int64(0))
+	})
+
+	t.Run("ExecuteResourceLimits", func(t *testing.T) {
+		t.Parallel()
+
+		if runtime.GOOS != "linux" {
+			t.Skip("resource limits are supported only on Linux")
+		}
+
+		stream, err := client.Execute(context.Background())
+		require.NoError(t, err)
+
+		execResult := make(chan executeResult)
+		go getExecuteResult(stream, execResult)
+
+		err = stream.Send(&runnerv1.ExecuteRequest{
+			ProgramName: "bash",
+			CommandMode: runnerv1.CommandMode_COMMAND_MODE_INLINE_SHELL,
+			Commands:    []string{"ulimit -n"},
+			ResourceLimits: &runnerv1.ResourceLimits{
+				OpenFiles: 32,
+			},
+		})
+		assert.NoError(t, err)
+
+		result := <-execResult
+
+		assert.NoError(t, result.Err)
+		assert.Equal(t, "32\n", string(result.Stdout))
+		assert.EqualValues(t, 0, result.ExitCode)
+	})
+
 	t.Run("ExecuteBasicTempFile", func(t *testing.T) {
 		t.Parallel()
 
@@ -180,6 +268,44 @@ func Test_runnerService(t *testing.T) {
 		assert.EqualValues(t, 0, result.ExitCode)
 	})
 
+	t.Run("ExecuteProjectLanguage", func(t *testing.T) {
+		t.Parallel()
+
+		root := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(root, ".runme"), 0o700))
+		require.NoError(t, os.WriteFile(
+			executable.ProjectLanguagesPath(root),
+			[]byte("languages:\n  custom:\n    interpreters: [bash]\n    extension: sh\n"),
+			0o600,
+		))
+
+		sessResp, err := client.CreateSession(context.Background(), &runnerv1.CreateSessionRequest{
+			Project: &runnerv1.Project{Root: root},
+		})
+		require.NoError(t, err)
+
+		stream, err := client.Execute(context.Background())
+		require.NoError(t, err)
+
+		execResult := make(chan executeResult)
+		go getExecuteResult(stream, execResult)
+
+		err = stream.Send(&runnerv1.ExecuteRequest{
+			SessionId:   sessResp.Session.Id,
+			CommandMode: runnerv1.CommandMode_COMMAND_MODE_TEMP_FILE,
+			LanguageId:  "custom",
+			Directory:   root,
+			Script:      "echo custom",
+		})
+		assert.NoError(t, err)
+
+		result := <-execResult
+
+		assert.NoError(t, result.Err)
+		assert.Equal(t, "custom\n", string(result.Stdout))
+		assert.EqualValues(t, 0, result.ExitCode)
+	})
+
 	t.Run("ExecuteBasicJavaScript", func(t *testing.T) {
 		t.Parallel()
 
@@ -908,6 +1034,125 @@ func Test_runnerService(t *testing.T) {
 		)
 	})
 
+	t.Run("ExecuteBackgroundAttach", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		stream, err := client.Execute(ctx)
+		require.NoError(t, err)
+
+		err = stream.Send(&runnerv1.ExecuteRequest{
+			ProgramName: "bash",
+			CommandMode: runnerv1.CommandMode_COMMAND_MODE_INLINE_SHELL,
+			Commands:    []string{"echo 1", "sleep 2", "echo 2"},
+			Background:  true,
+		})
+		require.NoError(t, err)
+
+		msg, err := stream.Recv()
+		require.NoError(t, err)
+		require.NotNil(t, msg.Pid)
+		require.NotEmpty(t, msg.ExecutionId)
+
+		// Detach from the background execution.
+		cancel()
+
+		listResp, err := client.ListExecutions(context.Background(), &runnerv1.ListExecutionsRequest{})
+		require.NoError(t, err)
+		var found *runnerv1.Execution
+		for _, e := range listResp.Executions {
+			if e.Id == msg.ExecutionId {
+				found = e
+			}
+		}
+		require.NotNil(t, found)
+		assert.Equal(t, msg.Pid.Pid, found.Pid)
+		assert.True(t, found.Running)
+
+		attachStream, err := client.AttachExecution(
+			context.Background(),
+			&runnerv1.AttachExecutionRequest{Id: msg.ExecutionId},
+		)
+		require.NoError(t, err)
+		result := make(chan executeResult)
+		go getAttachExecutionResult(attachStream, result)
+		attachResult := <-result
+		assert.NoError(t, attachResult.Err)
+		assert.Equal(t, "1\n2\n", string(attachResult.Stdout))
+		assert.Equal(t, 0, attachResult.ExitCode)
+
+		// Attaching to a finished execution replays the output.
+		attachStream, err = client.AttachExecution(
+			context.Background(),
+			&runnerv1.AttachExecutionRequest{SessionId: found.SessionId, Id: msg.ExecutionId},
+		)
+		require.NoError(t, err)
+		go getAttachExecutionResult(attachStream, result)
+		attachResult = <-result
+		assert.NoError(t, attachResult.Err)
+		assert.Equal(t, "1\n2\n", string(attachResult.Stdout))
+		assert.Equal(t, 0, attachResult.ExitCode)
+
+		attachStream, err = client.AttachExecution(
+			context.Background(),
+			&runnerv1.AttachExecutionRequest{Id: "non-existent"},
+		)
+		require.NoError(t, err)
+		_, err = attachStream.Recv()
+		assert.Equal(t, codes.NotFound, status.Convert(err).Code())
+	})
+
+	t.Run("StopExecution", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		stream, err := client.Execute(ctx)
+		require.NoError(t, err)
+
+		err = stream.Send(&runnerv1.ExecuteRequest{
+			ProgramName: "bash",
+			CommandMode: runnerv1.CommandMode_COMMAND_MODE_INLINE_SHELL,
+			Commands:    []string{"sleep 1000"},
+			Background:  true,
+		})
+		require.NoError(t, err)
+
+		msg, err := stream.Recv()
+		require.NoError(t, err)
+		require.NotEmpty(t, msg.ExecutionId)
+		cancel()
+
+		attachStream, err := client.AttachExecution(
+			context.Background(),
+			&runnerv1.AttachExecutionRequest{Id: msg.ExecutionId},
+		)
+		require.NoError(t, err)
+		result := make(chan executeResult)
+		go getAttachExecutionResult(attachStream, result)
+
+		_, err = client.StopExecution(
+			context.Background(),
+			&runnerv1.StopExecutionRequest{Id: msg.ExecutionId, Stop: runnerv1.ExecuteStop_EXECUTE_STOP_KILL},
+		)
+		require.NoError(t, err)
+
+		attachResult := <-result
+		assert.NoError(t, attachResult.Err)
+		assert.Equal(t, 137, attachResult.ExitCode)
+
+		_, err = client.StopExecution(
+			context.Background(),
+			&runnerv1.StopExecutionRequest{Id: msg.ExecutionId},
+		)
+		assert.Equal(t, codes.FailedPrecondition, status.Convert(err).Code())
+
+		_, err = client.StopExecution(
+			context.Background(),
+			&runnerv1.StopExecutionRequest{Id: "non-existent"},
+		)
+		assert.Equal(t, codes.NotFound, status.Convert(err).Code())
+	})
+
 	t.Run("ExecuteStoreLastOutput", func(t *testing.T) {
 		t.Parallel()
 		s, err := client.CreateSession(context.Background(), &runnerv1.CreateSessionRequest{})
@@ -5,19 +5,23 @@ import (
 	"sync"
 
 	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/stateful/runme/internal/executable"
 	ulid "github.com/stateful/runme/internal/ulid"
 	"go.uber.org/zap"
 )
 
 // Session is an abstract entity separate from
 // an execution. Currently, its main role is to
-// keep track of environment variables.
+// keep track of environment variables and
+// background executions.
 type Session struct {
 	ID       string
 	Metadata map[string]string
 
-	envStore *envStore
-	logger   *zap.Logger
+	envStore   *envStore
+	executions *executionList
+	languages  *executable.Languages
+	logger     *zap.Logger
 }
 
 func NewSession(envs []string, logger *zap.Logger) (*Session, error) {
@@ -26,8 +30,9 @@ func NewSession(envs []string, logger *zap.Logger) (*Session, error) {
 	s := &Session{
 		ID: ulid.GenerateID(),
 
-		envStore: newEnvStore(sessionEnvs...),
-		logger:   logger,
+		envStore:   newEnvStore(sessionEnvs...),
+		executions: newExecutionList(),
+		logger:     logger,
 	}
 	return s, nil
 }
@@ -36,10 +41,35 @@ func (s *Session) AddEnvs(envs []string) {
 	s.envStore.Add(envs...)
 }
 
+// SetEnv sets a single environment variable. It fails
+// if the environment would exceed the size limit.
+func (s *Session) SetEnv(k, v string) error {
+	_, err := s.envStore.Set(k, v)
+	return err
+}
+
 func (s *Session) Envs() []string {
 	return s.envStore.Values()
 }
 
+func (s *Session) AddExecution(e *Execution) {
+	s.executions.Add(e)
+}
+
+func (s *Session) GetExecution(id string) (*Execution, bool) {
+	return s.executions.Get(id)
+}
+
+// Languages returns custom languages available in the session.
+func (s *Session) Languages() *executable.Languages {
+	return s.languages
+}
+
+// Executions returns background executions in the order they were started.
+func (s *Session) Executions() []*Execution {
+	return s.executions.List()
+}
+
 // thread-safe session list
 type SessionList struct {
 	// WARNING: this mutex is created to prevent race conditions on certain
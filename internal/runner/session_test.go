@@ -2,9 +2,12 @@ package runner
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	ulid "github.com/stateful/runme/internal/ulid"
 )
 
 func Test_SessionList(t *testing.T) {
@@ -128,3 +131,40 @@ func Test_SessionList(t *testing.T) {
 		list.DeleteSession(session2.ID)
 	})
 }
+
+func Test_executionList_Evict(t *testing.T) {
+	t.Parallel()
+
+	newFinished := func(finishedAt time.Time) *Execution {
+		e := &Execution{ID: ulid.GenerateID(), finishedAt: finishedAt, done: make(chan struct{})}
+		close(e.done)
+		return e
+	}
+
+	now := time.Now()
+	list := newExecutionList()
+
+	running := &Execution{ID: ulid.GenerateID(), done: make(chan struct{})}
+	list.Add(running)
+
+	expired := newFinished(now.Add(-2 * executionRetention))
+	list.Add(expired)
+
+	var finished []*Execution
+	for i := 0; i < maxFinishedExecutions+2; i++ {
+		e := newFinished(now)
+		finished = append(finished, e)
+		list.Add(e)
+	}
+
+	executions := list.List()
+	require.Len(t, executions, maxFinishedExecutions+1)
+	assert.Equal(t, running.ID, executions[0].ID)
+
+	_, ok := list.Get(expired.ID)
+	assert.False(t, ok)
+	_, ok = list.Get(finished[0].ID)
+	assert.False(t, ok)
+	_, ok = list.Get(finished[len(finished)-1].ID)
+	assert.True(t, ok)
+}
@@ -1,9 +1,7 @@
 package runner
 
 import (
-	"bytes"
 	"context"
-	"fmt"
 	"io"
 	"log"
 	"os"
@@ -15,6 +13,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/executable"
 )
 
 type Shell struct {
@@ -35,36 +34,35 @@ func (s Shell) ShellType() string {
 }
 
 func (s Shell) DryRun(ctx context.Context, w io.Writer) {
-	var b bytes.Buffer
-
-	_, _ = b.WriteString(fmt.Sprintf("#!%s\n\n", s.ProgramPath()))
-	_, _ = b.WriteString(fmt.Sprintf("// run in %q\n\n", s.Dir))
-	_, _ = b.WriteString(prepareScriptFromCommands(s.Cmds, s.ShellType()))
-
-	_, err := w.Write(b.Bytes())
-	if err != nil {
+	if err := dryRun(w, s.commandConfig()); err != nil {
 		log.Fatalf("failed to write: %s", err)
 	}
 }
 
+func (s Shell) commandConfig() *commandConfig {
+	return &commandConfig{
+		ProgramName: s.ProgramPath(),
+		Directory:   s.Dir,
+		Session:     s.Session,
+		Tty:         s.Tty,
+		Stdin:       s.Stdin,
+		Stdout:      s.Stdout,
+		Stderr:      s.Stderr,
+		PreEnv:      s.PreEnv,
+		PostEnv:     s.PostEnv,
+		CommandMode: CommandModeInlineShell,
+		Commands:    s.Cmds,
+		Script:      "",
+		Logger:      s.Logger,
+
+		ResourceLimits: s.ResourceLimits,
+		Sandbox:        s.Sandbox,
+		Languages:      s.Languages,
+	}
+}
+
 func (s *Shell) Run(ctx context.Context) error {
-	cmd, err := newCommand(
-		&commandConfig{
-			ProgramName: s.ProgramPath(),
-			Directory:   s.Dir,
-			Session:     s.Session,
-			Tty:         s.Tty,
-			Stdin:       s.Stdin,
-			Stdout:      s.Stdout,
-			Stderr:      s.Stderr,
-			PreEnv:      s.PreEnv,
-			PostEnv:     s.PostEnv,
-			CommandMode: CommandModeInlineShell,
-			Commands:    s.Cmds,
-			Script:      "",
-			Logger:      s.Logger,
-		},
-	)
+	cmd, err := newCommand(s.commandConfig())
 	if err != nil {
 		return err
 	}
@@ -80,9 +78,24 @@ func (s Shell) ExitCode() int {
 	return s.command.cmd.ProcessState.ExitCode()
 }
 
+func (s Shell) ResourceUsage() *ResourceUsage {
+	if s.command == nil {
+		return nil
+	}
+
+	return s.command.ResourceUsage()
+}
+
 func (s Shell) run(ctx context.Context, cmd *command) error {
+	return runCommand(ctx, cmd, s.Name, s.Tty)
+}
+
+// runCommand starts cmd and waits for it to finish. Only exit errors
+// are reported; other errors from Wait, like a canceled stdin copy
+// after the process exited, are ignored.
+func runCommand(ctx context.Context, cmd *command, name string, tty bool) error {
 	opts := &startOpts{}
-	if s.Tty {
+	if tty {
 		opts.DisableEcho = true
 	}
 
@@ -90,28 +103,35 @@ func (s Shell) run(ctx context.Context, cmd *command) error {
 		return err
 	}
 
-	if err := cmd.Wait(); err != nil {
-		var exiterr *exec.ExitError
-		// Ignore errors caused by SIGINT.
-		if errors.As(err, &exiterr) {
-			var rerr error = ExitErrorFromExec(exiterr)
+	err := cmd.Wait()
+	if err == nil {
+		return nil
+	}
+
+	var exiterr *exec.ExitError
+	if !errors.As(err, &exiterr) {
+		return nil
+	}
 
-			if exiterr.ProcessState.Sys().(syscall.WaitStatus).Signal() != os.Kill {
-				msg := "failed to run command"
-				if len(s.Name) > 0 {
-					msg += " " + strconv.Quote(s.Name)
-				}
-				return errors.Wrap(rerr, msg)
-			}
+	var rerr error = ExitErrorFromExec(exiterr)
 
-			return rerr
+	// Don't wrap errors caused by SIGKILL.
+	if exiterr.ProcessState.Sys().(syscall.WaitStatus).Signal() != os.Kill {
+		msg := "failed to run command"
+		if len(name) > 0 {
+			msg += " " + strconv.Quote(name)
 		}
+		return errors.Wrap(rerr, msg)
 	}
 
-	return nil
+	return rerr
 }
 
-func IsShellLanguage(languageID string) bool {
+func IsShellLanguage(languageID string, languages *executable.Languages) bool {
+	if lang, ok := languages.Lookup(languageID); ok {
+		return lang.Shell
+	}
+
 	switch strings.ToLower(languageID) {
 	// shellscripts
 	case "sh", "bash", "zsh", "ksh", "shell", "shellscript":
@@ -134,10 +154,16 @@ func IsShellLanguage(languageID string) bool {
 	}
 }
 
-func GetCellProgram(languageID string, customShell string, cell *document.CodeBlock) (program string, commandMode CommandMode) {
-	if IsShellLanguage(languageID) {
+func GetCellProgram(languageID string, customShell string, cell *document.CodeBlock, languages *executable.Languages) (program string, commandMode CommandMode) {
+	if IsShellLanguage(languageID, languages) {
 		program = ResolveShellPath(customShell)
 		commandMode = CommandModeInlineShell
+
+		// Shell-like languages from the config file are executed
+		// with their own interpreter inferred from the language ID.
+		if _, ok := languages.Lookup(languageID); ok {
+			program = ""
+		}
 	} else {
 		commandMode = CommandModeTempFile
 	}
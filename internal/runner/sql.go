@@ -0,0 +1,543 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	_ "github.com/lib/pq" // registers "postgres"
+	"github.com/mattn/go-runewidth"
+	"github.com/pkg/errors"
+	_ "modernc.org/sqlite" // registers "sqlite"
+)
+
+// SQL output formats.
+const (
+	SQLFormatTable = "table"
+	SQLFormatJSON  = "json"
+	SQLFormatCSV   = "csv"
+)
+
+// SQL is an executable which runs statements from a cell against
+// a database and writes result sets to stdout.
+//
+// The database is selected by name. Its DSN is read from the
+// environment variable RUNME_DB_<NAME>, or DATABASE_URL if the name
+// is empty. Supported DSNs are "sqlite://path/to/file.db", "file:..."
+// and ":memory:" for SQLite, and "postgres://..." for PostgreSQL.
+type SQL struct {
+	*ExecutableConfig
+	Script string
+	// Database is a name of the connection, for example "analytics".
+	Database string
+	// Format is one of SQLFormatTable (default), SQLFormatJSON or SQLFormatCSV.
+	Format string
+
+	inProcess
+}
+
+var _ Executable = (*SQL)(nil)
+
+// SQLDatabaseEnvName returns a name of the environment
+// variable with the DSN of the database called name.
+func SQLDatabaseEnvName(name string) string {
+	if name == "" {
+		return "DATABASE_URL"
+	}
+
+	normalized := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, name)
+
+	return "RUNME_DB_" + normalized
+}
+
+// parseSQLDSN returns a database/sql driver name and
+// a data source name accepted by that driver.
+func parseSQLDSN(dsn string, dir string) (driver, source string, err error) {
+	switch {
+	case dsn == ":memory:":
+		return "sqlite", dsn, nil
+	case strings.HasPrefix(dsn, "file:"):
+		return "sqlite", dsn, nil
+	case strings.HasPrefix(dsn, "sqlite://"), strings.HasPrefix(dsn, "sqlite3://"):
+		_, path, _ := strings.Cut(dsn, "://")
+		if path != ":memory:" && !filepath.IsAbs(path) && dir != "" {
+			path = filepath.Join(dir, path)
+		}
+		return "sqlite", path, nil
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"):
+		return "postgres", dsn, nil
+	}
+
+	scheme, _, _ := strings.Cut(dsn, ":")
+	return "", "", errors.Errorf("unsupported database %q", scheme)
+}
+
+func (s SQL) format() (string, error) {
+	switch f := strings.ToLower(s.Format); f {
+	case "":
+		return SQLFormatTable, nil
+	case SQLFormatTable, SQLFormatJSON, SQLFormatCSV:
+		return f, nil
+	default:
+		return "", errors.Errorf("unsupported format %q", s.Format)
+	}
+}
+
+func (s SQL) lookupDSN() (string, error) {
+	name := SQLDatabaseEnvName(s.Database)
+
+	dsn, _ := lookupEnv(s.envs(), name)
+	if dsn == "" {
+		return "", errors.Errorf("database DSN not found; set %s", name)
+	}
+
+	return dsn, nil
+}
+
+func (s SQL) DryRun(ctx context.Context, w io.Writer) {
+	name := SQLDatabaseEnvName(s.Database)
+
+	if dsn, err := s.lookupDSN(); err != nil {
+		_, _ = fmt.Fprintf(w, "// %s\n", err)
+	} else if driver, _, err := parseSQLDSN(dsn, s.Dir); err != nil {
+		_, _ = fmt.Fprintf(w, "// %s\n", err)
+	} else {
+		_, _ = fmt.Fprintf(w, "// connect to %s database from %s\n", driver, name)
+	}
+
+	format, _ := s.format()
+	_, _ = fmt.Fprintf(w, "// write results as %s\n\n", format)
+
+	for _, stmt := range splitSQLStatements(s.Script) {
+		_, _ = fmt.Fprintf(w, "%s;\n", stmt)
+	}
+}
+
+func (s *SQL) Run(ctx context.Context) error {
+	return s.inProcess.run(func() error { return s.execute(ctx) })
+}
+
+func (s *SQL) execute(ctx context.Context) error {
+	// Statements are executed by the runner itself,
+	// so there is no process to restrict.
+	if s.Sandbox != nil {
+		return errors.New("sandbox is not supported for sql cells")
+	}
+
+	format, err := s.format()
+	if err != nil {
+		return err
+	}
+
+	dsn, err := s.lookupDSN()
+	if err != nil {
+		return err
+	}
+
+	driver, source, err := parseSQLDSN(dsn, s.Dir)
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open(driver, source)
+	if err != nil {
+		return errors.Wrap(err, "failed to open database")
+	}
+	defer db.Close()
+
+	// A single connection makes statements like "CREATE TEMP TABLE"
+	// visible to the following ones.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to database")
+	}
+	defer conn.Close()
+
+	for i, stmt := range splitSQLStatements(s.Script) {
+		if !sqlStatementReturnsRows(stmt) {
+			result, err := conn.ExecContext(ctx, stmt)
+			if err != nil {
+				return errors.Wrapf(err, "failed to execute statement %d", i+1)
+			}
+			if format == SQLFormatTable && sqlStatementIsDML(stmt) {
+				if n, err := result.RowsAffected(); err == nil {
+					_, _ = fmt.Fprintf(s.Stdout, "(%d %s affected)\n", n, pluralizeRows(n))
+				}
+			}
+			continue
+		}
+
+		columns, rows, err := querySQL(ctx, conn, stmt)
+		if err != nil {
+			return errors.Wrapf(err, "failed to execute statement %d", i+1)
+		}
+		if len(columns) == 0 {
+			continue
+		}
+
+		switch format {
+		case SQLFormatJSON:
+			err = writeSQLJSON(s.Stdout, columns, rows)
+		case SQLFormatCSV:
+			err = writeSQLCSV(s.Stdout, columns, rows)
+		default:
+			err = writeSQLTable(s.Stdout, columns, rows)
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+func querySQL(ctx context.Context, conn *sql.Conn, stmt string) ([]string, [][]any, error) {
+	rows, err := conn.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var result [][]any
+
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, nil, err
+		}
+		for i, v := range values {
+			// Drivers return text columns as []byte.
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		result = append(result, values)
+	}
+
+	return columns, result, rows.Err()
+}
+
+func formatSQLValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func pluralizeRows(n int64) string {
+	if n == 1 {
+		return "row"
+	}
+	return "rows"
+}
+
+func writeSQLTable(w io.Writer, columns []string, rows [][]any) error {
+	widths := make([]int, len(columns))
+	for i, col := range columns {
+		widths[i] = runewidth.StringWidth(col)
+	}
+
+	cells := make([][]string, len(rows))
+	for i, row := range rows {
+		cells[i] = make([]string, len(row))
+		for j, v := range row {
+			cells[i][j] = formatSQLValue(v)
+			widths[j] = max(widths[j], runewidth.StringWidth(cells[i][j]))
+		}
+	}
+
+	var b strings.Builder
+
+	writeRow := func(values []string) {
+		for i, v := range values {
+			if i > 0 {
+				_, _ = b.WriteString(" | ")
+			}
+			// Avoid trailing whitespace.
+			if i == len(values)-1 {
+				_, _ = b.WriteString(v)
+				continue
+			}
+			_, _ = b.WriteString(runewidth.FillRight(v, widths[i]))
+		}
+		_, _ = b.WriteString("\n")
+	}
+
+	writeRow(columns)
+
+	for i, width := range widths {
+		if i > 0 {
+			_, _ = b.WriteString("-+-")
+		}
+		_, _ = b.WriteString(strings.Repeat("-", width))
+	}
+	_, _ = b.WriteString("\n")
+
+	for _, row := range cells {
+		writeRow(row)
+	}
+
+	_, _ = fmt.Fprintf(&b, "(%d %s)\n", len(rows), pluralizeRows(int64(len(rows))))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeSQLJSON(w io.Writer, columns []string, rows [][]any) error {
+	// Build objects manually to preserve the order of columns.
+	var b strings.Builder
+
+	_, _ = b.WriteString("[")
+	for i, row := range rows {
+		if i > 0 {
+			_, _ = b.WriteString(",")
+		}
+		_, _ = b.WriteString("\n  {")
+		for j, v := range row {
+			if j > 0 {
+				_, _ = b.WriteString(", ")
+			}
+			key, err := json.Marshal(columns[j])
+			if err != nil {
+				return err
+			}
+			value, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			_, _ = b.Write(key)
+			_, _ = b.WriteString(": ")
+			_, _ = b.Write(value)
+		}
+		_, _ = b.WriteString("}")
+	}
+	if len(rows) > 0 {
+		_, _ = b.WriteString("\n")
+	}
+	_, _ = b.WriteString("]\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeSQLCSV(w io.Writer, columns []string, rows [][]any) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, v := range row {
+			if v != nil {
+				record[i] = formatSQLValue(v)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// sqlKeyword returns the first keyword of stmt in lower case.
+func sqlKeyword(stmt string) string {
+	words := sqlWords(stmt)
+	if len(words) == 0 {
+		return ""
+	}
+	return words[0]
+}
+
+func sqlStatementReturnsRows(stmt string) bool {
+	words := sqlWords(stmt)
+	if len(words) == 0 {
+		return false
+	}
+
+	switch words[0] {
+	case "select", "with", "values", "pragma", "show", "explain", "describe", "table":
+		return true
+	}
+
+	for _, word := range words[1:] {
+		if word == "returning" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sqlStatementIsDML(stmt string) bool {
+	switch sqlKeyword(stmt) {
+	case "insert", "update", "delete", "replace", "merge":
+		return true
+	}
+	return false
+}
+
+func isSQLWordByte(c byte) bool {
+	return c == '_' || c >= 0x80 ||
+		'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// sqlQuote returns the delimiter of a quoted string, a quoted
+// identifier or a dollar-quoted string, like $$ or $tag$,
+// which starts at the beginning of s. Otherwise, it's empty.
+func sqlQuote(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	switch s[0] {
+	case '\'', '"', '`':
+		return s[:1]
+	case '$':
+		// A tag doesn't start with a digit. It distinguishes
+		// dollar quotes from positional parameters like $1.
+		i := 1
+		for i < len(s) && isSQLWordByte(s[i]) && !(i == 1 && '0' <= s[i] && s[i] <= '9') {
+			i++
+		}
+		if i < len(s) && s[i] == '$' {
+			return s[:i+1]
+		}
+	}
+
+	return ""
+}
+
+// sqlCommentEnd returns the length of a comment which starts
+// at the beginning of s, or 0 if there is none.
+func sqlCommentEnd(s string) int {
+	switch {
+	case strings.HasPrefix(s, "--"):
+		if end := strings.IndexByte(s, '\n'); end != -1 {
+			return end
+		}
+		return len(s)
+	case strings.HasPrefix(s, "/*"):
+		if end := strings.Index(s[2:], "*/"); end != -1 {
+			return end + 4
+		}
+		return len(s)
+	}
+	return 0
+}
+
+// sqlWords returns words of stmt in lower case. Quoted strings,
+// quoted identifiers and comments are skipped.
+func sqlWords(stmt string) []string {
+	var words []string
+
+	for i := 0; i < len(stmt); {
+		rest := stmt[i:]
+
+		if n := sqlCommentEnd(rest); n > 0 {
+			i += n
+			continue
+		}
+
+		if quote := sqlQuote(rest); quote != "" {
+			end := strings.Index(rest[len(quote):], quote)
+			if end == -1 {
+				break
+			}
+			i += end + 2*len(quote)
+			continue
+		}
+
+		if !isSQLWordByte(stmt[i]) {
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(stmt) && isSQLWordByte(stmt[j]) {
+			j++
+		}
+		words = append(words, strings.ToLower(stmt[i:j]))
+		i = j
+	}
+
+	return words
+}
+
+// splitSQLStatements splits a script into statements separated
+// by semicolons. Semicolons in quotes, comments and dollar-quoted
+// strings are ignored. Comments preceding a statement are removed.
+func splitSQLStatements(script string) []string {
+	var (
+		result  []string
+		current strings.Builder
+	)
+
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			result = append(result, stmt)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(script); {
+		rest := script[i:]
+
+		if n := sqlCommentEnd(rest); n > 0 {
+			if strings.TrimSpace(current.String()) != "" {
+				_, _ = current.WriteString(rest[:n])
+			}
+			i += n
+			continue
+		}
+
+		if quote := sqlQuote(rest); quote != "" {
+			end := len(rest)
+			if idx := strings.Index(rest[len(quote):], quote); idx != -1 {
+				end = idx + 2*len(quote)
+			}
+			_, _ = current.WriteString(rest[:end])
+			i += end
+			continue
+		}
+
+		if script[i] == ';' {
+			flush()
+		} else {
+			_ = current.WriteByte(script[i])
+		}
+		i++
+	}
+
+	flush()
+
+	return result
+}
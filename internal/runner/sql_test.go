@@ -0,0 +1,141 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_splitSQLStatements(t *testing.T) {
+	stmts := splitSQLStatements(`-- create a table
+CREATE TABLE t (name TEXT); /* comment; */
+INSERT INTO t VALUES ('a;b'), ("c"";d");
+CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;
+CREATE FUNCTION g() RETURNS text AS $body$ SELECT '$$;'; $body$ LANGUAGE sql
+`)
+	assert.Equal(
+		t,
+		[]string{
+			"CREATE TABLE t (name TEXT)",
+			`INSERT INTO t VALUES ('a;b'), ("c"";d")`,
+			"CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql",
+			"CREATE FUNCTION g() RETURNS text AS $body$ SELECT '$$;'; $body$ LANGUAGE sql",
+		},
+		stmts,
+	)
+}
+
+func Test_sqlStatementReturnsRows(t *testing.T) {
+	testCases := []struct {
+		stmt     string
+		expected bool
+	}{
+		{"SELECT 1", true},
+		{"/* comment */ with t AS (SELECT 1) SELECT * FROM t", true},
+		{"INSERT INTO t VALUES (1) RETURNING id", true},
+		{"INSERT INTO t VALUES ('returning')", false},
+		{`UPDATE t SET "returning" = 1`, false},
+		{"UPDATE t SET returning_at = now() -- returning", false},
+		{"UPDATE t SET v = $1 RETURNING v", true},
+		{"CREATE FUNCTION f() AS $fn$ SELECT 1 RETURNING x $fn$", false},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, sqlStatementReturnsRows(tc.stmt), tc.stmt)
+	}
+}
+
+func TestSQLDatabaseEnvName(t *testing.T) {
+	assert.Equal(t, "DATABASE_URL", SQLDatabaseEnvName(""))
+	assert.Equal(t, "RUNME_DB_ANALYTICS_V2", SQLDatabaseEnvName("analytics-v2"))
+}
+
+func TestSQL(t *testing.T) {
+	dir := t.TempDir()
+
+	run := func(t *testing.T, script, format string) (string, error) {
+		t.Helper()
+
+		session, err := NewSession(append(os.Environ(), "RUNME_DB_LOCAL=sqlite://test.db"), zap.NewNop())
+		require.NoError(t, err)
+
+		stdout := new(bytes.Buffer)
+		sql := &SQL{
+			ExecutableConfig: &ExecutableConfig{
+				Dir:     dir,
+				Stdout:  stdout,
+				Stderr:  new(bytes.Buffer),
+				Session: session,
+				Logger:  zap.NewNop(),
+			},
+			Script:   script,
+			Database: "local",
+			Format:   format,
+		}
+		err = sql.Run(context.Background())
+		return stdout.String(), err
+	}
+
+	output, err := run(t, `
+CREATE TABLE users (id INTEGER, name TEXT, email TEXT);
+INSERT INTO users VALUES (1, 'alice', 'alice@example.com'), (2, 'bob', NULL);
+`, "")
+	require.NoError(t, err)
+	assert.Equal(t, "(2 rows affected)\n", output)
+
+	query := "SELECT id, name, email FROM users ORDER BY id"
+
+	t.Run("Table", func(t *testing.T) {
+		output, err := run(t, query, "table")
+		require.NoError(t, err)
+		assert.Equal(
+			t,
+			"id | name  | email\n"+
+				"---+-------+------------------\n"+
+				"1  | alice | alice@example.com\n"+
+				"2  | bob   | NULL\n"+
+				"(2 rows)\n",
+			output,
+		)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		output, err := run(t, query, "json")
+		require.NoError(t, err)
+		assert.Equal(
+			t,
+			"[\n"+
+				`  {"id": 1, "name": "alice", "email": "alice@example.com"},`+"\n"+
+				`  {"id": 2, "name": "bob", "email": null}`+"\n"+
+				"]\n",
+			output,
+		)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		output, err := run(t, query, "csv")
+		require.NoError(t, err)
+		assert.Equal(t, "id,name,email\n1,alice,alice@example.com\n2,bob,\n", output)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := run(t, "SELECT * FROM missing", "")
+		assert.ErrorContains(t, err, "no such table: missing")
+	})
+
+	t.Run("MissingDSN", func(t *testing.T) {
+		sql := &SQL{
+			ExecutableConfig: &ExecutableConfig{Stdout: new(bytes.Buffer)},
+			Script:           query,
+			Database:         "missing",
+		}
+		err := sql.Run(context.Background())
+		assert.ErrorContains(t, err, "set RUNME_DB_MISSING")
+		assert.Equal(t, 1, sql.ExitCode())
+	})
+}
@@ -1,7 +1,6 @@
 package runner
 
 import (
-	"bytes"
 	"context"
 	"io"
 	"log"
@@ -22,32 +21,35 @@ type TempFile struct {
 }
 
 func (s TempFile) DryRun(ctx context.Context, w io.Writer) {
-	var b bytes.Buffer
-
-	_, err := w.Write(b.Bytes())
-	if err != nil {
+	if err := dryRun(w, s.commandConfig()); err != nil {
 		log.Fatalf("failed to write: %s", err)
 	}
 }
 
+func (s TempFile) commandConfig() *commandConfig {
+	return &commandConfig{
+		ProgramName: s.ProgramName,
+		LanguageID:  s.LanguageID,
+		Directory:   s.Dir,
+		Session:     s.Session,
+		Tty:         s.Tty,
+		Stdin:       s.Stdin,
+		Stdout:      s.Stdout,
+		Stderr:      s.Stderr,
+		PreEnv:      s.PreEnv,
+		PostEnv:     s.PostEnv,
+		CommandMode: CommandModeTempFile,
+		Script:      s.Script,
+		Logger:      s.Logger,
+
+		ResourceLimits: s.ResourceLimits,
+		Sandbox:        s.Sandbox,
+		Languages:      s.Languages,
+	}
+}
+
 func (s *TempFile) Run(ctx context.Context) error {
-	cmd, err := newCommand(
-		&commandConfig{
-			ProgramName: s.ProgramName,
-			LanguageID:  s.LanguageID,
-			Directory:   s.Dir,
-			Session:     s.Session,
-			Tty:         s.Tty,
-			Stdin:       s.Stdin,
-			Stdout:      s.Stdout,
-			Stderr:      s.Stderr,
-			PreEnv:      s.PreEnv,
-			PostEnv:     s.PostEnv,
-			CommandMode: CommandModeTempFile,
-			Script:      s.Script,
-			Logger:      s.Logger,
-		},
-	)
+	cmd, err := newCommand(s.commandConfig())
 	if err != nil {
 		return err
 	}
@@ -64,6 +66,14 @@ func (s TempFile) ExitCode() int {
 	return s.command.cmd.ProcessState.ExitCode()
 }
 
+func (s TempFile) ResourceUsage() *ResourceUsage {
+	if s.command == nil {
+		return nil
+	}
+
+	return s.command.ResourceUsage()
+}
+
 func (s TempFile) run(ctx context.Context, cmd *command) error {
 	opts := &startOpts{}
 	if s.Tty {
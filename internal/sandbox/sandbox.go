@@ -0,0 +1,61 @@
+// Package sandbox restricts what programs executed by the runner can do.
+//
+// Currently, it's supported only on Linux. Writes to the file system
+// are restricted using Landlock and the network is disabled by starting
+// a program in a new network namespace.
+package sandbox
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Config describes restrictions applied to a program.
+type Config struct {
+	// WritablePaths is a list of files and directories where
+	// the program is allowed to write. Writing anywhere else fails.
+	WritablePaths []string
+	// DisableNetwork, when true, starts the program in
+	// a network namespace with no interfaces configured.
+	DisableNetwork bool
+}
+
+// Level describes how strict a sandbox is.
+type Level int
+
+const (
+	// LevelOff disables the sandbox.
+	LevelOff Level = iota
+	// LevelFilesystem restricts writes to the file system.
+	LevelFilesystem
+	// LevelOffline restricts writes to the file system
+	// and disables the network.
+	LevelOffline
+)
+
+// ParseLevel parses a value of the "sandbox" cell attribute
+// or the --sandbox flag. Boolean values turn the file system
+// restrictions on or off, and "offline" disables the network as well.
+func ParseLevel(value string) (Level, error) {
+	switch strings.ToLower(value) {
+	case "", "0", "false", "off":
+		return LevelOff, nil
+	case "1", "true", "on":
+		return LevelFilesystem, nil
+	case "offline":
+		return LevelOffline, nil
+	}
+	return LevelOff, errors.Errorf("invalid sandbox level %q", value)
+}
+
+func (l Level) String() string {
+	switch l {
+	case LevelFilesystem:
+		return "true"
+	case LevelOffline:
+		return "offline"
+	default:
+		return "false"
+	}
+}
@@ -0,0 +1,171 @@
+package sandbox
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	accessFileWrite = unix.LANDLOCK_ACCESS_FS_WRITE_FILE
+
+	accessDirWrite = unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM
+)
+
+// Supported returns an error if the sandbox can't be used
+// in the current environment.
+func Supported() error {
+	_, err := landlockABIVersion()
+	return err
+}
+
+// IsolateNetwork sets up cmd to start in a new network namespace.
+// If the current user is not root, a user namespace which maps
+// the current user and group to themselves is created as well.
+func IsolateNetwork(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWNET
+
+	if uid, gid := os.Geteuid(), os.Getegid(); uid != 0 {
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWUSER
+		cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}}
+		cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}}
+	}
+}
+
+// Exec restricts writes to writablePaths and replaces
+// the current process with the program described by argv.
+// It returns only if an error occurs.
+func Exec(writablePaths []string, argv []string) error {
+	if len(argv) == 0 {
+		return errors.New("program not specified")
+	}
+
+	program, err := exec.LookPath(argv[0])
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Landlock restricts only the calling thread. The program
+	// must be executed by the same thread to inherit the restrictions.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := restrictWrites(writablePaths); err != nil {
+		return err
+	}
+
+	return errors.WithStack(unix.Exec(program, argv, os.Environ()))
+}
+
+func restrictWrites(writablePaths []string) error {
+	abi, err := landlockABIVersion()
+	if err != nil {
+		return err
+	}
+
+	fileAccess := uint64(accessFileWrite)
+	if abi >= 3 {
+		fileAccess |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+
+	dirAccess := fileAccess | accessDirWrite
+	if abi >= 2 {
+		dirAccess |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+
+	attr := unix.LandlockRulesetAttr{Access_fs: dirAccess}
+	rulesetFd, _, errno := unix.Syscall(
+		unix.SYS_LANDLOCK_CREATE_RULESET,
+		uintptr(unsafe.Pointer(&attr)),
+		unsafe.Sizeof(attr),
+		0,
+	)
+	if errno != 0 {
+		return errors.Wrap(errno, "failed to create landlock ruleset")
+	}
+	defer func() { _ = unix.Close(int(rulesetFd)) }()
+
+	for _, path := range writablePaths {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+
+		access := dirAccess
+		if !info.IsDir() {
+			access = fileAccess
+		}
+
+		if err := addPathRule(int(rulesetFd), path, access); err != nil {
+			return err
+		}
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return errors.Wrap(err, "failed to set no_new_privs")
+	}
+
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, rulesetFd, 0, 0); errno != 0 {
+		return errors.Wrap(errno, "failed to enforce landlock ruleset")
+	}
+
+	return nil
+}
+
+func addPathRule(rulesetFd int, path string, access uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", path)
+	}
+	defer func() { _ = unix.Close(fd) }()
+
+	attr := unix.LandlockPathBeneathAttr{
+		Allowed_access: access,
+		Parent_fd:      int32(fd),
+	}
+
+	_, _, errno := unix.Syscall6(
+		unix.SYS_LANDLOCK_ADD_RULE,
+		uintptr(rulesetFd),
+		unix.LANDLOCK_RULE_PATH_BENEATH,
+		uintptr(unsafe.Pointer(&attr)),
+		0, 0, 0,
+	)
+	if errno != 0 {
+		return errors.Wrapf(errno, "failed to allow writes to %s", path)
+	}
+
+	return nil
+}
+
+func landlockABIVersion() (int, error) {
+	version, _, errno := unix.Syscall(
+		unix.SYS_LANDLOCK_CREATE_RULESET,
+		0,
+		0,
+		unix.LANDLOCK_CREATE_RULESET_VERSION,
+	)
+	if errno != 0 {
+		return 0, errors.Wrap(errno, "landlock is not available")
+	}
+	return int(version), nil
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"os/exec"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+func Supported() error {
+	return errors.Errorf("sandbox is not supported on %s", runtime.GOOS)
+}
+
+func IsolateNetwork(cmd *exec.Cmd) {}
+
+func Exec(writablePaths []string, argv []string) error {
+	return Supported()
+}
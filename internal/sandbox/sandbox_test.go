@@ -0,0 +1,35 @@
+package sandbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected Level
+	}{
+		{"", LevelOff},
+		{"false", LevelOff},
+		{"true", LevelFilesystem},
+		{"True", LevelFilesystem},
+		{"offline", LevelOffline},
+	}
+
+	for _, tc := range testCases {
+		level, err := ParseLevel(tc.value)
+		require.NoError(t, err, tc.value)
+		assert.Equal(t, tc.expected, level, tc.value)
+
+		// String() must round-trip.
+		parsed, err := ParseLevel(level.String())
+		require.NoError(t, err)
+		assert.Equal(t, level, parsed)
+	}
+
+	_, err := ParseLevel("strict")
+	assert.Error(t, err)
+}
@@ -1,9 +1,15 @@
 package tasks
 
 import (
+	"path"
+	"path/filepath"
+	"strings"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/google/shlex"
 	"github.com/pkg/errors"
+
+	"github.com/stateful/runme/internal/project"
 )
 
 func Generate(descriptions ...TaskDescription) (*TaskConfiguration, error) {
@@ -45,11 +51,103 @@ func GenerateFromShellCommand(name, command string, opts *ShellCommandOpts) (*Ta
 	}
 
 	return Generate(TaskDescription{
-		Label:   name,
-		Type:    "shell",
-		Command: fragments[0],
-		Args:    args,
-		Group:   "build",
-		Options: options,
+		Label:          name,
+		Type:           "shell",
+		Command:        fragments[0],
+		Args:           args,
+		Group:          "build",
+		Options:        options,
+		ProblemMatcher: []string{},
 	})
 }
+
+type ProjectTasksOpts struct {
+	// Dir is a workspace folder. Working directories
+	// of tasks are relative to it.
+	Dir string
+	Env map[string]string
+	// Include filters tasks. Labels are made unique
+	// across all tasks so that they are stable.
+	Include func(project.Task) bool
+}
+
+// GenerateFromProjectTasks generates a VS Code task for every named task
+// which runs it with "runme run" from the directory of its document.
+// Tasks in the "test" category are put into the test group. Categories
+// are also used to group terminals.
+func GenerateFromProjectTasks(projTasks []project.Task, opts *ProjectTasksOpts) (*TaskConfiguration, error) {
+	if opts == nil {
+		opts = &ProjectTasksOpts{}
+	}
+
+	var named []project.Task
+	for _, task := range projTasks {
+		if !task.CodeBlock.IsUnnamed() {
+			named = append(named, task)
+		}
+	}
+
+	nameCount := make(map[string]int)
+	for _, task := range named {
+		nameCount[task.CodeBlock.Name()]++
+	}
+
+	descriptions := make([]TaskDescription, 0, len(named))
+
+	for _, task := range named {
+		if opts.Include != nil && !opts.Include(task) {
+			continue
+		}
+
+		block := task.CodeBlock
+
+		relDir := filepath.Dir(task.DocumentPath)
+		if opts.Dir != "" {
+			if rel, err := filepath.Rel(opts.Dir, relDir); err == nil {
+				relDir = rel
+			}
+		}
+
+		cwd := "${workspaceFolder}"
+		if relDir != "." {
+			cwd = path.Join(cwd, filepath.ToSlash(relDir))
+		}
+
+		label := block.Name()
+		// Labels must be unique, so tasks with the same name
+		// in different documents are prefixed with a path.
+		if nameCount[label] > 1 {
+			label = path.Join(filepath.ToSlash(relDir), filepath.Base(task.DocumentPath)) + ": " + label
+		}
+
+		group := "build"
+		var presentation *PresentationOptions
+
+		categories := strings.Split(block.Category(), ",")
+		for _, c := range categories {
+			if strings.TrimSpace(c) == "test" {
+				group = "test"
+			}
+		}
+		if c := strings.TrimSpace(categories[0]); c != "" {
+			presentation = &PresentationOptions{Group: &c}
+		}
+
+		descriptions = append(descriptions, TaskDescription{
+			Label:        label,
+			Type:         "shell",
+			Command:      "runme",
+			Args:         []string{"run", "--filename", filepath.Base(task.DocumentPath), block.Name()},
+			IsBackground: block.Background(),
+			Group:        group,
+			Options: &CommandOptions{
+				Cwd: cwd,
+				Env: opts.Env,
+			},
+			Presentation:   presentation,
+			ProblemMatcher: []string{},
+		})
+	}
+
+	return Generate(descriptions...)
+}
@@ -0,0 +1,126 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/document/identity"
+	"github.com/stateful/runme/internal/project"
+)
+
+func TestGenerateFromProjectTasks(t *testing.T) {
+	resolver := identity.NewResolver(identity.UnspecifiedLifecycleIdentity)
+
+	newTasks := func(path, source string) (result []project.Task) {
+		node, err := document.New([]byte(source), resolver).Root()
+		require.NoError(t, err)
+		for _, block := range document.CollectCodeBlocks(node) {
+			result = append(result, project.Task{CodeBlock: block, DocumentPath: path})
+		}
+		return result
+	}
+
+	projTasks := append(
+		newTasks("/project/README.md", "```sh {\"name\":\"build\",\"category\":\"ci\"}\nmake\nmake install\n```\n\n```sh {\"name\":\"unit\",\"category\":\"test\"}\ngo test\n```\n\n```sh\necho unnamed\n```\n"),
+		newTasks("/project/docs/guide.md", "```sh {\"name\":\"build\",\"background\":\"true\"}\nnpm start\n```\n")...,
+	)
+
+	tc, err := GenerateFromProjectTasks(projTasks, &ProjectTasksOpts{
+		Dir:     "/project",
+		Env:     map[string]string{"KEY": "value"},
+		Include: func(task project.Task) bool { return task.CodeBlock.Name() != "unit" },
+	})
+	require.NoError(t, err)
+
+	ci := "ci"
+	assert.Equal(
+		t,
+		[]TaskDescription{
+			{
+				Label:          "README.md: build",
+				Type:           "shell",
+				Command:        "runme",
+				Args:           []string{"run", "--filename", "README.md", "build"},
+				Group:          "build",
+				Options:        &CommandOptions{Cwd: "${workspaceFolder}", Env: map[string]string{"KEY": "value"}},
+				Presentation:   &PresentationOptions{Group: &ci},
+				ProblemMatcher: []string{},
+			},
+			{
+				Label:          "docs/guide.md: build",
+				Type:           "shell",
+				Command:        "runme",
+				Args:           []string{"run", "--filename", "guide.md", "build"},
+				IsBackground:   true,
+				Group:          "build",
+				Options:        &CommandOptions{Cwd: "${workspaceFolder}/docs", Env: map[string]string{"KEY": "value"}},
+				ProblemMatcher: []string{},
+			},
+		},
+		tc.Tasks,
+	)
+
+	tc, err = GenerateFromProjectTasks(projTasks, &ProjectTasksOpts{
+		Dir:     "/project",
+		Include: func(task project.Task) bool { return task.CodeBlock.Name() == "unit" },
+	})
+	require.NoError(t, err)
+	require.Len(t, tc.Tasks, 1)
+	assert.Equal(t, "unit", tc.Tasks[0].Label)
+	assert.Equal(t, "test", tc.Tasks[0].Group)
+}
+
+func TestMerge(t *testing.T) {
+	existing := []byte(`{
+  // See https://go.microsoft.com/fwlink/?LinkId=733558
+  "version": "2.0.0",
+  "tasks": [
+    {"label": "mine", "command": "echo // not a comment", /* inline */},
+    {"label": "build", "command": "old"},
+  ],
+}`)
+
+	tc, err := Generate(
+		TaskDescription{Label: "build", Type: "shell", Command: "runme", Group: "build", ProblemMatcher: []string{}},
+		TaskDescription{Label: "test", Type: "shell", Command: "runme", Group: "test", ProblemMatcher: []string{}},
+	)
+	require.NoError(t, err)
+
+	data, err := Merge(existing, tc)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`{
+  "tasks": [
+    {
+      "label": "mine",
+      "command": "echo // not a comment"
+    },
+    {
+      "label": "build",
+      "type": "shell",
+      "command": "runme",
+      "group": "build",
+      "problemMatcher": []
+    },
+    {
+      "label": "test",
+      "type": "shell",
+      "command": "runme",
+      "group": "test",
+      "problemMatcher": []
+    }
+  ],
+  "version": "2.0.0"
+}
+`,
+		string(data),
+	)
+
+	data, err = Merge(nil, tc)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"version": "2.0.0"`)
+}
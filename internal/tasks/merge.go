@@ -0,0 +1,142 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// Merge adds tasks from tc to an existing tasks.json. Tasks with
+// the same labels are replaced and other tasks and properties are kept.
+// Comments and trailing commas, allowed by VS Code, are removed.
+func Merge(existing []byte, tc *TaskConfiguration) ([]byte, error) {
+	var config map[string]json.RawMessage
+	if len(bytes.TrimSpace(existing)) > 0 {
+		if err := json.Unmarshal(stripJSONC(existing), &config); err != nil {
+			return nil, errors.Wrap(err, "failed to parse existing tasks")
+		}
+	}
+	if config == nil {
+		config = make(map[string]json.RawMessage)
+	}
+
+	var existingTasks []json.RawMessage
+	if raw, ok := config["tasks"]; ok {
+		if err := json.Unmarshal(raw, &existingTasks); err != nil {
+			return nil, errors.Wrap(err, "failed to parse existing tasks")
+		}
+	}
+
+	generated := make(map[string]json.RawMessage, len(tc.Tasks))
+	labels := make([]string, 0, len(tc.Tasks))
+	for _, task := range tc.Tasks {
+		raw, err := json.Marshal(task)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		generated[task.Label] = raw
+		labels = append(labels, task.Label)
+	}
+
+	result := make([]json.RawMessage, 0, len(existingTasks)+len(tc.Tasks))
+
+	for _, raw := range existingTasks {
+		var task struct {
+			Label string `json:"label"`
+		}
+		_ = json.Unmarshal(raw, &task)
+
+		if replacement, ok := generated[task.Label]; ok && task.Label != "" {
+			result = append(result, replacement)
+			delete(generated, task.Label)
+			continue
+		}
+		result = append(result, raw)
+	}
+
+	for _, label := range labels {
+		if raw, ok := generated[label]; ok {
+			result = append(result, raw)
+		}
+	}
+
+	tasksRaw, err := json.Marshal(result)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	config["tasks"] = tasksRaw
+
+	if _, ok := config["version"]; !ok {
+		config["version"], _ = json.Marshal(tc.Version)
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return append(data, '\n'), nil
+}
+
+// stripJSONC removes comments and trailing commas from JSON with comments.
+func stripJSONC(data []byte) []byte {
+	var (
+		result  = make([]byte, 0, len(data))
+		inStr   bool
+		escaped bool
+	)
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if inStr {
+			result = append(result, c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inStr = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inStr = true
+			result = append(result, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				result = append(result, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == ']' || c == '}':
+			// Remove a trailing comma before the closing bracket.
+			j := len(result) - 1
+			for j >= 0 && isJSONSpace(result[j]) {
+				j--
+			}
+			if j >= 0 && result[j] == ',' {
+				result = append(result[:j], result[j+1:]...)
+			}
+			result = append(result, c)
+		default:
+			result = append(result, c)
+		}
+	}
+
+	return result
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
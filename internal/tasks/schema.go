@@ -38,7 +38,9 @@ type TaskDescription struct {
 	Args         []string             `json:"args,omitempty"`
 	Group        string               `json:"group"`
 	Presentation *PresentationOptions `json:"presentation,omitempty"`
-	// ProblemMatcher struct{} `json:"problemMatcher,omitempty"`
+	// ProblemMatcher is a list of problem matchers.
+	// An empty list disables scanning the output.
+	ProblemMatcher []string `json:"problemMatcher"`
 	// RunOptions struct{} `json:"runOptions"`
 }
 
@@ -53,11 +55,11 @@ type TaskDescription struct {
 // }
 
 type PresentationOptions struct {
-	Reveal           *string `json:"reveal" validate:"eq=never|eq=silent|eq=always"`
-	Echo             *bool   `json:"echo"`
-	Focus            *bool   `json:"focus"`
-	Panel            *string `json:"panel" validate:"eq=shared|eq=dedicated|eq=new"`
-	ShowReuseMessage *bool   `json:"showReuseMessage"`
-	Clear            *bool   `json:"clear"`
-	Group            *string `json:"group"`
+	Reveal           *string `json:"reveal,omitempty" validate:"omitempty,eq=never|eq=silent|eq=always"`
+	Echo             *bool   `json:"echo,omitempty"`
+	Focus            *bool   `json:"focus,omitempty"`
+	Panel            *string `json:"panel,omitempty" validate:"omitempty,eq=shared|eq=dedicated|eq=new"`
+	ShowReuseMessage *bool   `json:"showReuseMessage,omitempty"`
+	Clear            *bool   `json:"clear,omitempty"`
+	Group            *string `json:"group,omitempty"`
 }